@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+	"github.com/lukeberry99/puzzle/internal/puzzlefile"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+const usage = `usage:
+  app [serve]                                  start the HTTP server
+  app games list                               list all games
+  app games show <id>                          print a game with its groups and tiles
+  app games delete <id>                        delete a game
+  app games import [-format f] <file|->        import puzzles from a file
+  app games export [-format f] [-o file] <id>  export a puzzle
+  app seed                                     load the sample puzzles
+  app stats                                    print content statistics`
+
+//go:embed seed.json
+var seedPuzzles []byte
+
+// app holds what the admin commands need. Commands go through the same
+// GameService as the API so they are subject to the same rules.
+type app struct {
+	games      *service.GameService
+	moderation *service.ModerationService
+	queries    *db.Queries
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	switch args[0] {
+	case "games":
+		return a.runGames(ctx, args[1:])
+	case "seed":
+		return a.seed(ctx)
+	case "stats":
+		return a.stats(ctx)
+	default:
+		return errors.New(usage)
+	}
+}
+
+func (a *app) seed(ctx context.Context) error {
+	doc, err := puzzlefile.Decode(bytes.NewReader(seedPuzzles), puzzlefile.JSON)
+	if err != nil {
+		return err
+	}
+	return a.importDocument(ctx, doc)
+}
+
+func (a *app) stats(ctx context.Context) error {
+	counts, err := a.queries.GetContentCounts(ctx)
+	if err != nil {
+		return err
+	}
+	byDifficulty, err := a.queries.CountGamesByDifficulty(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "games\t%d\n", counts.Games)
+	fmt.Fprintf(tw, "groups\t%d\n", counts.Groups)
+	fmt.Fprintf(tw, "tiles\t%d\n", counts.Tiles)
+	for _, row := range byDifficulty {
+		fmt.Fprintf(tw, "  %s\t%d\n", row.Difficulty, row.Games)
+	}
+	return tw.Flush()
+}
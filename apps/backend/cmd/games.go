@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/puzzlefile"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+func (a *app) runGames(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "list":
+		return a.listGames(ctx)
+	case "show":
+		return a.showGame(ctx, args[1:])
+	case "delete":
+		return a.deleteGame(ctx, args[1:])
+	case "import":
+		return a.importGames(ctx, args[1:])
+	case "export":
+		return a.exportGame(ctx, args[1:])
+	default:
+		return errors.New(usage)
+	}
+}
+
+func (a *app) listGames(ctx context.Context) error {
+	// Admins see every game, including those scheduled as future dailies.
+	games, err := a.queries.GetAllGames(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tAUTHOR\tDIFFICULTY\tSTATUS\tCREATED")
+	for _, g := range games {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", g.ID, g.Author, g.Difficulty, g.ModerationStatus, g.CreatedAt.Format(time.DateTime))
+	}
+	return tw.Flush()
+}
+
+func (a *app) showGame(ctx context.Context, args []string) error {
+	gameID, err := gameIDArg(args)
+	if err != nil {
+		return err
+	}
+
+	game, err := a.games.ExportGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("game %d by %s (%s, time limit %s, %dx%d)\n", gameID, game.Author, game.Difficulty, game.TimeLimit, game.GroupCount, game.GroupSize)
+	for _, group := range game.Groups {
+		fmt.Printf("\ntier %d: %s [%s]\n", group.Tier, group.Link, strings.Join(group.LinkTerms, ", "))
+		for _, tile := range group.Tiles {
+			fmt.Printf("   %6d  %s\n", tile.ID, tile.Title)
+		}
+	}
+	return nil
+}
+
+func (a *app) deleteGame(ctx context.Context, args []string) error {
+	gameID, err := gameIDArg(args)
+	if err != nil {
+		return err
+	}
+
+	if err := a.games.DeleteGame(ctx, gameID); err != nil {
+		return err
+	}
+	fmt.Printf("deleted game %d\n", gameID)
+	return nil
+}
+
+func (a *app) importGames(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("games import", flag.ContinueOnError)
+	format := fs.String("format", "", "json, yaml or csv (default: from file extension)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(usage)
+	}
+	path := fs.Arg(0)
+
+	enc, err := importEncoding(*format, path)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	doc, err := puzzlefile.Decode(in, enc)
+	if err != nil {
+		return err
+	}
+	return a.importDocument(ctx, doc)
+}
+
+// importDocument publishes what it imports straight away, as the HTTP import
+// does: puzzles loaded from the command line come from the operator.
+func (a *app) importDocument(ctx context.Context, doc *puzzlefile.Document) error {
+	reqs := make([]models.CreateGameRequest, 0, len(doc.Puzzles))
+	for _, p := range doc.Puzzles {
+		reqs = append(reqs, p.Request())
+	}
+
+	failed := 0
+	for _, result := range a.moderation.ImportGames(ctx, reqs) {
+		if result.Status == service.ImportCreated {
+			fmt.Printf("puzzle %d: created game %d\n", result.Index+1, result.GameID)
+			continue
+		}
+		failed++
+		fmt.Printf("puzzle %d: %s\n", result.Index+1, result.Status)
+		for _, problem := range result.Problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d puzzles were not imported", failed, len(reqs))
+	}
+	return nil
+}
+
+func importEncoding(format, path string) (puzzlefile.Encoding, error) {
+	if format != "" || path == "-" {
+		return puzzlefile.ParseEncoding(format)
+	}
+	return puzzlefile.EncodingForPath(path)
+}
+
+func (a *app) exportGame(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("games export", flag.ContinueOnError)
+	format := fs.String("format", "json", "json, yaml or csv")
+	output := fs.String("o", "", "write to file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	gameID, err := gameIDArg(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	enc, err := puzzlefile.ParseEncoding(*format)
+	if err != nil {
+		return err
+	}
+
+	game, err := a.games.ExportGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return puzzlefile.Encode(out, enc, puzzlefile.NewDocument(puzzlefile.FromRequest(game)))
+}
+
+func gameIDArg(args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, errors.New(usage)
+	}
+	gameID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || gameID <= 0 {
+		return 0, fmt.Errorf("invalid game ID %q", args[0])
+	}
+	return gameID, nil
+}
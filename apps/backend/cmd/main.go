@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
 	"log"
 	"net/http"
@@ -12,7 +13,6 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/lukeberry99/puzzle/internal/api/handlers"
 	"github.com/lukeberry99/puzzle/internal/config"
-	"github.com/lukeberry99/puzzle/internal/db"
 	"github.com/lukeberry99/puzzle/internal/service"
 )
 
@@ -34,20 +34,137 @@ func main() {
 	}
 	defer dbConn.Close()
 
-	queries := db.New(dbConn)
-	gameService := service.NewGameService(queries)
+	blocklist, err := service.LoadBlocklist(cfg.BlocklistFile)
+	if err != nil {
+		log.Fatalf("failed to load blocklist: %v", err)
+	}
+
+	store := service.NewPostgresStore(dbConn)
+	activity := service.NewActivityFeed(service.ActivityBacklog)
+	gameService := service.NewGameService(store, cfg.DailyLocation, blocklist, activity)
+	statsService := service.NewStatsService(store, cfg.DailyLocation)
+	sessionService := service.NewSessionService(store, gameService, statsService, service.DefaultScorer, cfg.PublicURL)
+	leaderboardService := service.NewLeaderboardService(store, gameService)
+	analyticsService := service.NewAnalyticsService(store)
+	collectionService := service.NewCollectionService(store, gameService)
+	ratingService := service.NewRatingService(store, gameService)
+	moderationService := service.NewModerationService(store, gameService)
+	raceService := service.NewRaceService(gameService)
+	coopService := service.NewCoopService(gameService)
+	accountService := service.NewAccountService(store, statsService)
+
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		cli := &app{games: gameService, moderation: moderationService, queries: store.Queries}
+		if err := cli.run(context.Background(), os.Args[1:]); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	gameHandler := handlers.NewGameHandler(gameService)
+	sessionHandler := handlers.NewSessionHandler(sessionService, statsService)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	collectionHandler := handlers.NewCollectionHandler(collectionService)
+	ratingHandler := handlers.NewRatingHandler(ratingService)
+	moderationHandler := handlers.NewModerationHandler(moderationService)
+	raceHandler := handlers.NewRaceHandler(raceService)
+	coopHandler := handlers.NewCoopHandler(coopService)
+	activityHandler := handlers.NewActivityHandler(activity)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	requireAdmin := handlers.AdminMiddleware(cfg.AdminToken)
+
+	admin := http.NewServeMux()
+	admin.HandleFunc("GET /api/admin/daily", gameHandler.ListSchedule)
+	admin.HandleFunc("PUT /api/admin/daily/{date}", gameHandler.ScheduleDaily)
+	admin.HandleFunc("PATCH /api/admin/daily/{date}", gameHandler.RescheduleDaily)
+	admin.HandleFunc("DELETE /api/admin/daily/{date}", gameHandler.UnscheduleDaily)
+	admin.HandleFunc("POST /api/admin/tags", gameHandler.CreateTag)
+	admin.HandleFunc("PUT /api/admin/tags/{id}", gameHandler.RenameTag)
+	admin.HandleFunc("DELETE /api/admin/tags/{id}", gameHandler.DeleteTag)
+	admin.HandleFunc("PUT /api/admin/games/{id}/tags", gameHandler.SetGameTags)
+	admin.HandleFunc("GET /api/admin/moderation", moderationHandler.ListQueue)
+	admin.HandleFunc("POST /api/admin/games/import", moderationHandler.ImportGames)
+	admin.HandleFunc("GET /api/admin/games/{id}/export", gameHandler.ExportGame)
+	admin.HandleFunc("GET /api/admin/games/{id}/review", moderationHandler.ReviewGame)
+	admin.HandleFunc("POST /api/admin/games/{id}/approve", moderationHandler.ApproveGame)
+	admin.HandleFunc("POST /api/admin/games/{id}/reject", moderationHandler.RejectGame)
+	admin.HandleFunc("POST /api/admin/games/{id}/flag", moderationHandler.FlagGame)
+	admin.HandleFunc("POST /api/admin/collections", collectionHandler.CreateCollection)
+	admin.HandleFunc("PUT /api/admin/collections/{id}", collectionHandler.UpdateCollection)
+	admin.HandleFunc("DELETE /api/admin/collections/{id}", collectionHandler.DeleteCollection)
 
 	router := http.NewServeMux()
 	router.HandleFunc("/api/game", gameHandler.CreateGame)
 	router.HandleFunc("/api/games", gameHandler.ListGames)
+	router.HandleFunc("POST /api/games/validate", gameHandler.ValidateGame)
+	router.HandleFunc("GET /api/games/search", gameHandler.SearchGames)
+	router.HandleFunc("GET /api/games/{id}", gameHandler.GetGame)
+	router.HandleFunc("GET /api/games/{id}/leaderboard", leaderboardHandler.GetLeaderboard)
+	router.HandleFunc("GET /api/games/{id}/rating", ratingHandler.GetRating)
+	router.HandleFunc("PUT /api/games/{id}/rating", ratingHandler.RateGame)
+	router.HandleFunc("POST /api/games/{id}/report", moderationHandler.ReportGame)
+	router.Handle("GET /api/games/{id}/analytics", requireAdmin(http.HandlerFunc(analyticsHandler.GetAnalytics)))
+	// Import and export moved under /api/admin/; these are kept as aliases
+	router.Handle("POST /api/games/import", requireAdmin(http.HandlerFunc(moderationHandler.ImportGames)))
+	router.Handle("GET /api/games/{id}/export", requireAdmin(http.HandlerFunc(gameHandler.ExportGame)))
+	router.HandleFunc("POST /api/games/check", gameHandler.CheckTiles)
+	router.HandleFunc("GET /api/tags", gameHandler.ListTags)
+	router.HandleFunc("GET /api/collections", collectionHandler.ListCollections)
+	router.HandleFunc("GET /api/collections/{id}", collectionHandler.GetCollection)
+	router.HandleFunc("GET /api/daily", gameHandler.GetDaily)
+	router.HandleFunc("GET /api/daily/{date}", gameHandler.GetDaily)
+	router.HandleFunc("POST /api/sessions", sessionHandler.StartSession)
+	router.HandleFunc("GET /api/sessions/{id}", sessionHandler.GetSession)
+	router.HandleFunc("POST /api/sessions/{id}/guesses", sessionHandler.SubmitGuess)
+	router.HandleFunc("POST /api/sessions/{id}/hint", sessionHandler.TakeHint)
+	router.HandleFunc("GET /api/sessions/{id}/share", sessionHandler.ShareSession)
+	router.HandleFunc("GET /api/share/{code}", sessionHandler.GetShare)
+	router.HandleFunc("GET /api/me/stats", sessionHandler.MyStats)
+	router.HandleFunc("POST /api/accounts", accountHandler.Register)
+	router.HandleFunc("POST /api/login", accountHandler.Login)
+	router.HandleFunc("POST /api/logout", accountHandler.Logout)
+	router.HandleFunc("POST /api/races", raceHandler.CreateRace)
+	router.HandleFunc("GET /api/races/{code}", raceHandler.GetRace)
+	router.HandleFunc("GET /api/races/{code}/ws", raceHandler.JoinRace)
+	router.HandleFunc("POST /api/coop", coopHandler.CreateCoop)
+	router.HandleFunc("GET /api/coop/{code}", coopHandler.GetCoop)
+	router.HandleFunc("GET /api/coop/{code}/events", coopHandler.CoopEvents)
+	router.HandleFunc("PUT /api/coop/{code}/selection", coopHandler.SelectTiles)
+	router.HandleFunc("POST /api/coop/{code}/guesses", coopHandler.SubmitGuess)
+	router.HandleFunc("GET /api/events", activityHandler.Events)
+	router.Handle("/api/admin/", requireAdmin(admin))
+
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	defer stopRefresh()
+	go analyticsService.RefreshEvery(refreshCtx, service.AnalyticsRefreshInterval)
+	go gameService.AnnounceDailies(refreshCtx)
+
+	serve(handlers.PlayerMiddleware(playerSigner(cfg))(router), activity)
+}
+
+func playerSigner(cfg *config.Config) *service.PlayerSigner {
+	if cfg.PlayerSecret != "" {
+		return service.NewPlayerSigner([]byte(cfg.PlayerSecret))
+	}
+	log.Printf("PLAYER_SECRET is not set: player cookies will not survive a restart")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("failed to generate player signing key: %v", err)
+	}
+	return service.NewPlayerSigner(key)
+}
 
+func serve(router http.Handler, activity *service.ActivityFeed) {
+	// Event streams clear the WriteTimeout for themselves
 	srv := &http.Server{
 		Addr:         ":8181",
 		Handler:      handlers.LoggingMiddleware(handlers.CorsMiddleware(router)),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 	}
+	// Activity streams never end by themselves, so Shutdown would wait on them
+	srv.RegisterOnShutdown(activity.Close)
 
 	go func() {
 		log.Printf("Server starting on :8181")
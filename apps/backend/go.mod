@@ -2,4 +2,9 @@ module github.com/lukeberry99/puzzle
 
 go 1.23.3
 
-require github.com/lib/pq v1.10.9
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.23.0
+	gopkg.in/yaml.v3 v3.0.1
+)
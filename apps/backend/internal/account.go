@@ -0,0 +1,43 @@
+package models
+
+import "strings"
+
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 32
+	MinPasswordLength = 8
+	// MaxPasswordLength is as much of a password as bcrypt looks at.
+	MaxPasswordLength = 72
+)
+
+// AccountRequest signs a player up or logs them in. Usernames are
+// case-insensitive.
+type AccountRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=32"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// Validate checks a new account's details. Logging in skips it, so that
+// rules added later don't lock anyone out.
+func (r AccountRequest) Validate() error {
+	verr := &ValidationError{}
+
+	username := strings.TrimSpace(r.Username)
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		verr.add("username must be %d to %d characters", MinUsernameLength, MaxUsernameLength)
+	}
+	for _, r := range strings.ToLower(username) {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' {
+			verr.add("username may only contain letters, digits, _ and -")
+			break
+		}
+	}
+	if len(r.Password) < MinPasswordLength || len(r.Password) > MaxPasswordLength {
+		verr.add("password must be %d to %d bytes", MinPasswordLength, MaxPasswordLength)
+	}
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
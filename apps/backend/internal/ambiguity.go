@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateGameResponse is the result of a dry run: structural problems stop a
+// game being created, warnings only point out where players may be marked
+// wrong unfairly.
+type ValidateGameResponse struct {
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+	Warnings []string `json:"warnings"`
+}
+
+// Warnings runs heuristic checks for tiles that could fairly belong to more
+// than one group. Tiles marked as a decoy for a group are expected to look
+// like they fit it, so aren't reported against that group.
+func (r CreateGameRequest) Warnings() []string {
+	warnings := []string{}
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	type placement struct {
+		group, tile int
+		title       string
+	}
+	seen := make(map[string]placement)
+
+	for i, group := range r.Groups {
+		link := words(group.Link)
+		for j, tile := range group.Tiles {
+			title := words(tile.Title)
+			if len(title) == 0 {
+				continue
+			}
+
+			key := strings.Join(title, " ")
+			if first, ok := seen[key]; ok {
+				kind := "near-duplicate"
+				if strings.TrimSpace(first.title) == strings.TrimSpace(tile.Title) {
+					kind = "duplicate"
+				}
+				warn("group %d tile %d: %q is a %s of group %d tile %d %q",
+					i+1, j+1, tile.Title, kind, first.group, first.tile, first.title)
+			} else {
+				seen[key] = placement{group: i + 1, tile: j + 1, title: tile.Title}
+			}
+
+			if contains(link, title) {
+				warn("group %d tile %d: link %q gives away %q", i+1, j+1, group.Link, tile.Title)
+			}
+
+			for k, other := range r.Groups {
+				if k == i || (tile.Decoy != nil && tile.Decoy.Tier == other.Tier) {
+					continue
+				}
+				for _, term := range other.LinkTerms {
+					// Either way round: "Mercury" fits a "planet mercury"
+					// group as well as "Freddie Mercury" fits a "mercury" one
+					termWords := words(term)
+					if contains(title, termWords) || contains(termWords, title) {
+						warn("group %d tile %d: %q matches group %d link term %q",
+							i+1, j+1, tile.Title, k+1, term)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// words splits text into lowercase words with plurals reduced to their
+// singular, so "Red  Apples" and "red apple" compare equal.
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r == '\'' || r > 127)
+	})
+	for i, field := range fields {
+		fields[i] = singular(field)
+	}
+	return fields
+}
+
+func singular(word string) string {
+	switch {
+	case len(word) <= 3:
+		return word
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// contains reports whether needle appears as a run of whole words in haystack.
+func contains(haystack, needle []string) bool {
+	if len(needle) == 0 || len(needle) > len(haystack) {
+		return false
+	}
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j, word := range needle {
+			if haystack[i+j] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package models_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestWarnings(t *testing.T) {
+	if warnings := testutil.NewGame().Request().Warnings(); len(warnings) != 0 {
+		t.Errorf("default game: got warnings %q, want none", warnings)
+	}
+
+	req := testutil.NewGame().
+		Group(testutil.NewGroup(1).Tiles("Mercury", "Venus", "Freddie Mercury", "Saturn").
+			Tile(3, testutil.NewTile("Saturn").DecoyFor(4, "also a car"))).
+		Group(testutil.NewGroup(2).Link("Elements", "mercury").DefaultTiles(4)).
+		Group(testutil.NewGroup(3).Link("Planets", "planet venus").DefaultTiles(4)).
+		Group(testutil.NewGroup(4).Link("Car makes", "saturn").DefaultTiles(4)).
+		Request()
+
+	want := []string{
+		`group 1 tile 1: "Mercury" matches group 2 link term "mercury"`,
+		`group 1 tile 2: "Venus" matches group 3 link term "planet venus"`,
+		`group 1 tile 3: "Freddie Mercury" matches group 2 link term "mercury"`,
+	}
+	if got := req.Warnings(); !slices.Equal(got, want) {
+		t.Errorf("got warnings %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+type AccountHandler struct {
+	accountService *service.AccountService
+}
+
+func NewAccountHandler(as *service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: as,
+	}
+}
+
+// Register signs the player up. An anonymous player's history comes along,
+// and from then on the player cookie holds the account's player ID.
+func (h *AccountHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.AccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	account, err := h.accountService.Register(r.Context(), req, anonymousPlayerID(r))
+	if err != nil {
+		accountError(w, err)
+		return
+	}
+
+	setPlayerCookie(w, r, account.PlayerID)
+	response.JSON(w, http.StatusCreated, account)
+}
+
+// Login is Register for an existing account.
+func (h *AccountHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.AccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	account, err := h.accountService.Login(r.Context(), req, anonymousPlayerID(r))
+	if err != nil {
+		accountError(w, err)
+		return
+	}
+
+	setPlayerCookie(w, r, account.PlayerID)
+	response.JSON(w, http.StatusOK, account)
+}
+
+// Logout forgets the player. Their next session start gives them a new
+// anonymous ID.
+func (h *AccountHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	clearPlayerCookie(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// anonymousPlayerID is the player from the cookie, the only place an
+// anonymous ID can be trusted to be the caller's own.
+func anonymousPlayerID(r *http.Request) string {
+	if player := cookiePlayer(r); player != nil {
+		return player.id
+	}
+	return ""
+}
+
+func accountError(w http.ResponseWriter, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		response.Invalid(w, verr.Problems)
+	case errors.Is(err, service.ErrInvalidLogin):
+		response.Error(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrUsernameTaken):
+		response.Error(w, http.StatusConflict, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
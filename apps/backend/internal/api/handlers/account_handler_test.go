@@ -0,0 +1,146 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/api/handlers"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestAccountHandlers(t *testing.T) {
+	s := newServer(t, service.NewMemoryStore())
+	accountHandler := handlers.NewAccountHandler(service.NewAccountService(s.store, service.NewStatsService(s.store, time.UTC)))
+	s.router.HandleFunc("POST /api/accounts", accountHandler.Register)
+	s.router.HandleFunc("POST /api/login", accountHandler.Login)
+	s.router.HandleFunc("POST /api/logout", accountHandler.Logout)
+	s.router.HandleFunc("GET /api/games/search", handlers.NewGameHandler(s.games).SearchGames)
+	signer := service.NewPlayerSigner([]byte("secret"))
+	srv := httptest.NewServer(handlers.PlayerMiddleware(signer)(s.router))
+	t.Cleanup(srv.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar}
+	send := func(method, path string, body any, out any) int {
+		t.Helper()
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(method, srv.URL+path, bytes.NewReader(encoded))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+		}
+		return resp.StatusCode
+	}
+	player := func() string {
+		t.Helper()
+		u, _ := url.Parse(srv.URL)
+		for _, cookie := range jar.Cookies(u) {
+			if cookie.Name == handlers.PlayerCookieName {
+				id, ok := signer.Verify(cookie.Value)
+				if !ok {
+					t.Fatalf("player cookie %q doesn't verify", cookie.Value)
+				}
+				return id
+			}
+		}
+		return ""
+	}
+
+	game := testutil.Seed(t, s.games, s.store, testutil.NewGame())
+
+	// The first session start hands out an anonymous ID
+	if status := send(http.MethodGet, "/api/me/stats", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("stats before playing: status %d, want 401", status)
+	}
+	var session service.SessionState
+	if status := send(http.MethodPost, "/api/sessions", map[string]any{"game_id": game.ID}, &session); status != http.StatusOK {
+		t.Fatalf("starting session: status %d", status)
+	}
+	anonymous := player()
+	if !service.IsAnonymousPlayer(anonymous) {
+		t.Fatalf("got player %q, want an anonymous one", anonymous)
+	}
+	for _, group := range game.Groups {
+		if status := send(http.MethodPost, fmt.Sprintf("/api/sessions/%d/guesses", session.ID), map[string]any{"tiles": testutil.Tokens(t, session.Tiles, group.Titles...)}, nil); status != http.StatusOK {
+			t.Fatalf("guessing: status %d", status)
+		}
+	}
+	var stats service.PlayerStats
+	if status := send(http.MethodGet, "/api/me/stats", nil, &stats); status != http.StatusOK || stats.Won != 1 {
+		t.Errorf("anonymous stats: status %d, %+v", status, stats)
+	}
+	var results []service.SearchResult
+	if status := send(http.MethodGet, "/api/games/search?q=link", nil, &results); status != http.StatusOK || len(results) != 1 || results[0].LinkSnippet == "" {
+		t.Errorf("searching a finished game: status %d, %+v, want link snippets", status, results)
+	}
+
+	// A header can't claim an ID only the cookie can carry
+	if status := s.do(http.MethodGet, fmt.Sprintf("/api/sessions/%d", session.ID), anonymous, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("forged header: status %d, want 401", status)
+	}
+	var forged []service.SearchResult
+	if status := s.do(http.MethodGet, "/api/games/search?q=link", anonymous, nil, &forged); status != http.StatusOK || len(forged) != 0 {
+		t.Errorf("searching with a forged header: status %d, %+v, want no link matches", status, forged)
+	}
+
+	// A forged cookie is no one
+	u, _ := url.Parse(srv.URL)
+	jar.SetCookies(u, []*http.Cookie{{Name: handlers.PlayerCookieName, Value: "anon-forged.c2lnbmF0dXJl"}})
+	if status := send(http.MethodGet, fmt.Sprintf("/api/sessions/%d", session.ID), nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("forged cookie: status %d, want 401", status)
+	}
+	jar.SetCookies(u, []*http.Cookie{{Name: handlers.PlayerCookieName, Value: signer.Sign(anonymous)}})
+
+	if status := send(http.MethodPost, "/api/accounts", map[string]string{"username": "a"}, nil); status != http.StatusBadRequest {
+		t.Errorf("invalid account: status %d, want 400", status)
+	}
+	var account service.Account
+	if status := send(http.MethodPost, "/api/accounts", map[string]string{"username": "alice", "password": "correct horse"}, &account); status != http.StatusCreated {
+		t.Fatalf("registering: status %d", status)
+	}
+	if account.MergedSessions != 1 || player() == anonymous {
+		t.Errorf("registered %+v as %q, want the session merged into a new player", account, player())
+	}
+	if status := send(http.MethodGet, fmt.Sprintf("/api/sessions/%d", session.ID), nil, nil); status != http.StatusOK {
+		t.Errorf("merged session: status %d, want 200", status)
+	}
+	if status := send(http.MethodPost, "/api/accounts", map[string]string{"username": "alice", "password": "correct horse"}, nil); status != http.StatusConflict {
+		t.Errorf("taken username: status %d, want 409", status)
+	}
+
+	if status := send(http.MethodPost, "/api/logout", nil, nil); status != http.StatusNoContent || player() != "" {
+		t.Errorf("logging out: status %d, player %q", status, player())
+	}
+	if status := send(http.MethodPost, "/api/login", map[string]string{"username": "alice", "password": "wrong horse"}, nil); status != http.StatusUnauthorized {
+		t.Errorf("wrong password: status %d, want 401", status)
+	}
+	if status := send(http.MethodPost, "/api/login", map[string]string{"username": "alice", "password": "correct horse"}, &account); status != http.StatusOK {
+		t.Fatalf("logging in: status %d", status)
+	}
+	if status := send(http.MethodGet, "/api/me/stats", nil, &stats); status != http.StatusOK || stats.Won != 1 {
+		t.Errorf("account stats: status %d, %+v", status, stats)
+	}
+}
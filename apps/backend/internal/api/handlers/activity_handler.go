@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+type ActivityHandler struct {
+	activity *service.ActivityFeed
+}
+
+func NewActivityHandler(activity *service.ActivityFeed) *ActivityHandler {
+	return &ActivityHandler{
+		activity: activity,
+	}
+}
+
+// Events streams the activity feed as Server-Sent Events. A client
+// reconnecting with Last-Event-ID, which browsers send by themselves, first
+// gets what it missed; last_event_id in the query does the same for a
+// client starting afresh.
+func (h *ActivityHandler) Events(w http.ResponseWriter, r *http.Request) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		var err error
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			response.Error(w, http.StatusBadRequest, "Invalid last event ID")
+			return
+		}
+	}
+
+	sub, backlog := h.activity.Subscribe(lastID)
+	defer sub.Close()
+
+	stream, err := newEventStream(w)
+	if err != nil {
+		return
+	}
+	for _, activity := range backlog {
+		if err := stream.send(strconv.FormatInt(activity.ID, 10), activity.Type, activity); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case activity, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if err := stream.send(strconv.FormatInt(activity.ID, 10), activity.Type, activity); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := stream.heartbeat(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/api/handlers"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+func TestActivityEvents(t *testing.T) {
+	s := newServer(t, service.NewMemoryStore())
+	srv := httptest.NewUnstartedServer(handlers.LoggingMiddleware(s.router))
+	// Much shorter than the real server's, so the test can outlast it
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	for gameID := range int64(3) {
+		s.activity.Publish(service.Activity{Type: service.ActivityPublished, GameID: gameID + 1})
+	}
+
+	if status := s.do(http.MethodGet, "/api/events?last_event_id=nope", "", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("bad last event ID: status %d, want 400", status)
+	}
+
+	events, _ := readEvents(t, srv.URL+"/api/events", http.Header{"Last-Event-ID": {"1"}})
+	for _, want := range []string{"2", "3"} {
+		if event := nextEvent(t, events, service.ActivityPublished); event.ID != want {
+			t.Errorf("resumed with event %s, want %s", event.ID, want)
+		}
+	}
+
+	// The stream outlives the write timeout
+	time.Sleep(3 * srv.Config.WriteTimeout)
+	s.activity.Publish(service.Activity{Type: service.ActivitySolved, GameID: 1, Player: service.PlayerHandle("alice")})
+	if event := nextEvent(t, events, service.ActivitySolved); event.ID != "4" {
+		t.Errorf("got event %s, want 4", event.ID)
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+type AnalyticsHandler struct {
+	analyticsService *service.AnalyticsService
+}
+
+func NewAnalyticsHandler(as *service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: as,
+	}
+}
+
+// GetAnalytics gives away answers, so it is mounted behind AdminMiddleware.
+func (h *AnalyticsHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	analytics, err := h.analyticsService.GameAnalytics(r.Context(), gameID)
+	if errors.Is(err, service.ErrGameNotFound) {
+		response.Error(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch analytics")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, analytics)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+type CollectionHandler struct {
+	collectionService *service.CollectionService
+}
+
+func NewCollectionHandler(cs *service.CollectionService) *CollectionHandler {
+	return &CollectionHandler{
+		collectionService: cs,
+	}
+}
+
+func (h *CollectionHandler) ListCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := h.collectionService.ListCollections(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch collections")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, collections)
+}
+
+// GetCollection includes the player's progress through the collection when
+// they identify themselves.
+func (h *CollectionHandler) GetCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid collection ID")
+		return
+	}
+
+	playerID := optionalPlayerID(r)
+	collection, err := h.collectionService.Collection(r.Context(), collectionID, playerID)
+	if err != nil {
+		collectionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, collection)
+}
+
+func (h *CollectionHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	var req models.CollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	collectionID, err := h.collectionService.CreateCollection(r.Context(), req)
+	if err != nil {
+		collectionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]interface{}{
+		"collection_id": collectionID,
+		"status":        "success",
+	})
+}
+
+func (h *CollectionHandler) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid collection ID")
+		return
+	}
+
+	var req models.CollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.collectionService.UpdateCollection(r.Context(), collectionID, req); err != nil {
+		collectionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CollectionHandler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid collection ID")
+		return
+	}
+
+	if err := h.collectionService.DeleteCollection(r.Context(), collectionID); err != nil {
+		collectionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func collectionError(w http.ResponseWriter, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		response.Invalid(w, verr.Problems)
+	case errors.Is(err, service.ErrCollectionNotFound):
+		response.Error(w, http.StatusNotFound, "Collection not found")
+	default:
+		response.Error(w, http.StatusInternalServerError, "Failed to update collection")
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+type CoopHandler struct {
+	coopService *service.CoopService
+}
+
+func NewCoopHandler(cs *service.CoopService) *CoopHandler {
+	return &CoopHandler{
+		coopService: cs,
+	}
+}
+
+func (h *CoopHandler) CreateCoop(w http.ResponseWriter, r *http.Request) {
+	if _, ok := playerID(w, r); !ok {
+		return
+	}
+
+	var req struct {
+		GameID int64 `json:"game_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GameID <= 0 {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	state, err := h.coopService.CreateCoop(r.Context(), req.GameID)
+	if err != nil {
+		coopError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, state)
+}
+
+func (h *CoopHandler) GetCoop(w http.ResponseWriter, r *http.Request) {
+	room, err := h.coopService.Room(r.PathValue("code"))
+	if err != nil {
+		coopError(w, err)
+		return
+	}
+	state, err := room.State()
+	if err != nil {
+		coopError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, state)
+}
+
+// CoopEvents joins the game and streams its events as Server-Sent Events,
+// starting with the whole state. The player stays in the game for as long
+// as the stream is open.
+func (h *CoopHandler) CoopEvents(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+
+	room, err := h.coopService.Room(r.PathValue("code"))
+	if err != nil {
+		coopError(w, err)
+		return
+	}
+	member, err := room.Join(playerID)
+	if err != nil {
+		coopError(w, err)
+		return
+	}
+	defer member.Leave()
+
+	stream, err := newEventStream(w)
+	if err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-member.Events:
+			if !ok {
+				return
+			}
+			if err := stream.send(strconv.FormatInt(event.Seq, 10), event.Type, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := stream.heartbeat(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// SelectTiles shows the player's selection to the others. An empty selection
+// clears it.
+func (h *CoopHandler) SelectTiles(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Tiles []string `json:"tiles"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	room, err := h.coopService.Room(r.PathValue("code"))
+	if err != nil {
+		coopError(w, err)
+		return
+	}
+	if err := room.Select(playerID, req.Tiles); err != nil {
+		coopError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CoopHandler) SubmitGuess(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Tiles []string `json:"tiles"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	room, err := h.coopService.Room(r.PathValue("code"))
+	if err != nil {
+		coopError(w, err)
+		return
+	}
+	result, err := room.Guess(r.Context(), playerID, req.Tiles)
+	if err != nil {
+		coopError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
+func coopError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrGameNotFound):
+		response.Error(w, http.StatusNotFound, "Game not found")
+	case errors.Is(err, service.ErrCoopNotFound):
+		response.Error(w, http.StatusNotFound, "Co-op game not found")
+	case errors.Is(err, service.ErrNotCoopPlayer):
+		response.Error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInvalidSelection):
+		response.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrCoopFull), errors.Is(err, service.ErrSessionFinished),
+		errors.Is(err, service.ErrAlreadyGuessed):
+		response.Error(w, http.StatusConflict, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/api/handlers"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+// sseEvent is one event read off a stream.
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// readEvents streams the events at url into a channel, until the response
+// ends.
+func readEvents(t *testing.T, url string, header http.Header) (<-chan sseEvent, *http.Response) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if event.Event != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				event.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.Event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.Data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events, resp
+}
+
+// nextEvent waits for the next event of the given type, skipping others.
+func nextEvent(t *testing.T, events <-chan sseEvent, eventType string) sseEvent {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("stream ended waiting for %s", eventType)
+			}
+			if event.Event == eventType {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("no %s event", eventType)
+		}
+	}
+}
+
+func TestCoopHandlers(t *testing.T) {
+	s := newServer(t, service.NewMemoryStore())
+	game := testutil.Seed(t, s.games, s.store, testutil.NewGame())
+
+	coopHandler := handlers.NewCoopHandler(service.NewCoopService(s.games))
+	s.router.HandleFunc("POST /api/coop", coopHandler.CreateCoop)
+	s.router.HandleFunc("GET /api/coop/{code}", coopHandler.GetCoop)
+	s.router.HandleFunc("GET /api/coop/{code}/events", coopHandler.CoopEvents)
+	s.router.HandleFunc("PUT /api/coop/{code}/selection", coopHandler.SelectTiles)
+	s.router.HandleFunc("POST /api/coop/{code}/guesses", coopHandler.SubmitGuess)
+	srv := httptest.NewServer(handlers.LoggingMiddleware(s.router))
+	// Closing waits for open streams, whose bodies close in later cleanups
+	t.Cleanup(srv.Close)
+
+	var coop service.CoopState
+	if status := s.do(http.MethodPost, "/api/coop", "alice", map[string]any{"game_id": game.ID}, &coop); status != http.StatusCreated {
+		t.Fatalf("creating a co-op game: status %d, want 201", status)
+	}
+	if status := s.do(http.MethodGet, "/api/coop/NOPE42", "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("unknown co-op game: status %d, want 404", status)
+	}
+
+	first := testutil.Tokens(t, coop.Tiles, game.Groups[0].Titles...)
+	guesses := "/api/coop/" + coop.Code + "/guesses"
+	if status := s.do(http.MethodPost, guesses, "alice", map[string]any{"tiles": first}, nil); status != http.StatusForbidden {
+		t.Errorf("guessing without joining: status %d, want 403", status)
+	}
+
+	events, resp := readEvents(t, srv.URL+"/api/coop/"+strings.ToLower(coop.Code)+"/events", http.Header{handlers.PlayerIDHeader: {"alice"}})
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("got content type %q", ct)
+	}
+	nextEvent(t, events, service.CoopEventState)
+
+	if status := s.do(http.MethodPut, "/api/coop/"+coop.Code+"/selection", "alice", map[string]any{"tiles": first[:2]}, nil); status != http.StatusNoContent {
+		t.Errorf("selecting: status %d, want 204", status)
+	}
+	nextEvent(t, events, service.CoopEventSelection)
+
+	var result service.CoopGuessResult
+	if status := s.do(http.MethodPost, guesses, "alice", map[string]any{"tiles": first}, &result); status != http.StatusOK || !result.Correct {
+		t.Fatalf("guessing: status %d, got %+v", status, result)
+	}
+	if status := s.do(http.MethodPost, guesses, "alice", map[string]any{"tiles": first}, nil); status != http.StatusBadRequest {
+		t.Errorf("guessing solved tiles: status %d, want 400", status)
+	}
+
+	guess := nextEvent(t, events, service.CoopEventGuess)
+	var event service.CoopEvent
+	if err := json.Unmarshal([]byte(guess.Data), &event); err != nil {
+		t.Fatal(err)
+	}
+	if guess.ID != strconv.FormatInt(result.State.Seq, 10) || event.State == nil || len(event.State.Solved) != 1 {
+		t.Errorf("got event %s %+v, want the state after the guess", guess.ID, event)
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+const defaultScheduleDays = 30
+
+func (h *GameHandler) GetDaily(w http.ResponseWriter, r *http.Request) {
+	date := h.gameService.Today()
+	if value := r.PathValue("date"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+			return
+		}
+		date = parsed
+	}
+
+	daily, err := h.gameService.DailyPuzzle(r.Context(), date)
+	if errors.Is(err, service.ErrNoDailyPuzzle) {
+		response.Error(w, http.StatusNotFound, "No puzzle scheduled for that date")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch daily puzzle")
+		return
+	}
+
+	// The game may have been taken down since it was scheduled
+	board, err := h.gameService.FetchTilesForGame(r.Context(), daily.GameID)
+	if errors.Is(err, service.ErrGameNotFound) {
+		response.Error(w, http.StatusNotFound, "No puzzle scheduled for that date")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch daily puzzle")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, models.DailyPuzzleResponse{
+		Date:              daily.Date,
+		GameTilesResponse: board,
+	})
+}
+
+// ListSchedule returns the schedule between the from and to query parameters,
+// defaulting to the next 30 days.
+func (h *GameHandler) ListSchedule(w http.ResponseWriter, r *http.Request) {
+	from := h.gameService.Today()
+	to := from.AddDate(0, 0, defaultScheduleDays)
+
+	for name, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid "+name+" date, expected YYYY-MM-DD")
+			return
+		}
+		*dst = parsed
+	}
+
+	schedule, err := h.gameService.ListSchedule(r.Context(), from, to)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch schedule")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, schedule)
+}
+
+func (h *GameHandler) ScheduleDaily(w http.ResponseWriter, r *http.Request) {
+	date, err := time.Parse(time.DateOnly, r.PathValue("date"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	var req struct {
+		GameID int64 `json:"game_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GameID <= 0 {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	daily, err := h.gameService.ScheduleDaily(r.Context(), date, req.GameID)
+	if err != nil {
+		scheduleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, daily)
+}
+
+func (h *GameHandler) RescheduleDaily(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse(time.DateOnly, r.PathValue("date"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	var req struct {
+		Date string `json:"date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	to, err := time.Parse(time.DateOnly, req.Date)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	daily, err := h.gameService.RescheduleDaily(r.Context(), from, to)
+	if err != nil {
+		scheduleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, daily)
+}
+
+func (h *GameHandler) UnscheduleDaily(w http.ResponseWriter, r *http.Request) {
+	date, err := time.Parse(time.DateOnly, r.PathValue("date"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	if err := h.gameService.UnscheduleDaily(r.Context(), date); err != nil {
+		scheduleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func scheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrGameNotFound):
+		response.Error(w, http.StatusNotFound, "Game not found")
+	case errors.Is(err, service.ErrNoDailyPuzzle):
+		response.Error(w, http.StatusNotFound, "No puzzle scheduled for that date")
+	case errors.Is(err, service.ErrDateInPast):
+		response.Error(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, service.ErrGameNotReleased), errors.Is(err, service.ErrDateTaken):
+		response.Error(w, http.StatusConflict, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, "Failed to update schedule")
+	}
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	models "github.com/lukeberry99/puzzle/internal"
 	"github.com/lukeberry99/puzzle/internal/api/response"
@@ -28,6 +30,11 @@ func (h *GameHandler) CreateGame(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	gameID, err := h.gameService.CreateGame(r.Context(), req)
+	var verr *models.ValidationError
+	if errors.As(err, &verr) {
+		response.Invalid(w, verr.Problems)
+		return
+	}
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "Failed to create game")
 		return
@@ -39,8 +46,26 @@ func (h *GameHandler) CreateGame(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ValidateGame checks a game without creating it. Problems and warnings are
+// both part of a successful response, since reporting them is the point.
+func (h *GameHandler) ValidateGame(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateGameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	response.JSON(w, http.StatusOK, h.gameService.ValidateGame(req))
+}
+
+// ListGames filters by the tag query parameter when it is given, and sorts
+// by quality with sort=rating.
 func (h *GameHandler) ListGames(w http.ResponseWriter, r *http.Request) {
-	games, err := h.gameService.FetchAllGames(r.Context())
+	games, err := h.gameService.FetchAllGames(r.Context(), service.GameListOptions{
+		Tag:  r.URL.Query().Get("tag"),
+		Sort: r.URL.Query().Get("sort"),
+	})
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, "Failed to fetch games")
 		return
@@ -48,3 +73,74 @@ func (h *GameHandler) ListGames(w http.ResponseWriter, r *http.Request) {
 
 	response.JSON(w, http.StatusOK, games)
 }
+
+// SearchGames takes the query as q, and pages like the leaderboard. Players
+// identify themselves to see link snippets for games they have finished.
+func (h *GameHandler) SearchGames(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := pagination(w, r)
+	if !ok {
+		return
+	}
+
+	playerID := optionalPlayerID(r)
+	results, err := h.gameService.SearchGames(r.Context(), r.URL.Query().Get("q"), playerID, limit, offset)
+	if errors.Is(err, service.ErrEmptyQuery) {
+		response.Error(w, http.StatusBadRequest, "Missing search query")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to search games")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, results)
+}
+
+func (h *GameHandler) GetGame(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	board, err := h.gameService.FetchTilesForGame(r.Context(), gameID)
+	if errors.Is(err, service.ErrGameNotFound) {
+		response.Error(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, board)
+}
+
+func (h *GameHandler) CheckTiles(w http.ResponseWriter, r *http.Request) {
+	var req models.CheckTilesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.GameID <= 0 {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+	result, err := h.gameService.CheckTileSelection(r.Context(), req.GameID, req.Tiles)
+	if errors.Is(err, service.ErrGameNotFound) {
+		response.Error(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	if errors.Is(err, service.ErrInvalidSelection) {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to check tiles")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
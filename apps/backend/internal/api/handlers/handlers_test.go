@@ -0,0 +1,387 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/api/handlers"
+	"github.com/lukeberry99/puzzle/internal/db"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.Main(m))
+}
+
+// adminToken guards the server's admin routes.
+const adminToken = "admin-token"
+
+// server wires the handlers under test the way cmd/main.go does.
+type server struct {
+	t        *testing.T
+	store    service.GameStore
+	games    *service.GameService
+	activity *service.ActivityFeed
+	router   *http.ServeMux
+}
+
+func newServer(t *testing.T, store service.GameStore) *server {
+	activity := service.NewActivityFeed(service.ActivityBacklog)
+	games := service.NewGameService(store, time.UTC, service.NewBlocklist(nil), activity)
+	stats := service.NewStatsService(store, time.UTC)
+	sessions := service.NewSessionService(store, games, stats, service.DefaultScorer, "http://puzzle.test")
+
+	gameHandler := handlers.NewGameHandler(games)
+	sessionHandler := handlers.NewSessionHandler(sessions, stats)
+	activityHandler := handlers.NewActivityHandler(activity)
+	moderationHandler := handlers.NewModerationHandler(service.NewModerationService(store, games))
+	requireAdmin := handlers.AdminMiddleware(adminToken)
+
+	router := http.NewServeMux()
+	router.HandleFunc("/api/game", gameHandler.CreateGame)
+	router.HandleFunc("POST /api/games/validate", gameHandler.ValidateGame)
+	router.HandleFunc("GET /api/games/{id}", gameHandler.GetGame)
+	router.HandleFunc("GET /api/daily", gameHandler.GetDaily)
+	router.HandleFunc("POST /api/games/check", gameHandler.CheckTiles)
+	router.HandleFunc("POST /api/sessions", sessionHandler.StartSession)
+	router.HandleFunc("GET /api/sessions/{id}", sessionHandler.GetSession)
+	router.HandleFunc("POST /api/sessions/{id}/guesses", sessionHandler.SubmitGuess)
+	router.HandleFunc("POST /api/sessions/{id}/hint", sessionHandler.TakeHint)
+	router.HandleFunc("GET /api/me/stats", sessionHandler.MyStats)
+	router.HandleFunc("GET /api/events", activityHandler.Events)
+	router.Handle("POST /api/games/import", requireAdmin(http.HandlerFunc(moderationHandler.ImportGames)))
+	router.Handle("GET /api/games/{id}/export", requireAdmin(http.HandlerFunc(gameHandler.ExportGame)))
+
+	admin := http.NewServeMux()
+	admin.HandleFunc("PUT /api/admin/daily/{date}", gameHandler.ScheduleDaily)
+	admin.HandleFunc("POST /api/admin/games/import", moderationHandler.ImportGames)
+	admin.HandleFunc("GET /api/admin/games/{id}/export", gameHandler.ExportGame)
+	router.Handle("/api/admin/", requireAdmin(admin))
+
+	return &server{t: t, store: store, games: games, activity: activity, router: router}
+}
+
+// do sends body, JSON encoded unless it is already a string, and decodes
+// the response into out when it is not nil.
+func (s *server) do(method, path, player string, body any, out any) int {
+	s.t.Helper()
+	req := s.request(method, path, body)
+	if player != "" {
+		req.Header.Set(handlers.PlayerIDHeader, player)
+	}
+	return s.serve(req, out)
+}
+
+// admin is do with the admin token.
+func (s *server) admin(method, path string, body any, out any) int {
+	s.t.Helper()
+	req := s.request(method, path, body)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	return s.serve(req, out)
+}
+
+func (s *server) request(method, path string, body any) *http.Request {
+	s.t.Helper()
+
+	var reader *bytes.Reader
+	switch body := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(body))
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	return httptest.NewRequest(method, path, reader)
+}
+
+func (s *server) serve(req *http.Request, out any) int {
+	s.t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decoding %q: %v", req.Method, req.URL, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// board fetches a game's public board.
+func (s *server) board(gameID int64) []models.BoardTile {
+	s.t.Helper()
+	var board models.GameTilesResponse
+	if status := s.do(http.MethodGet, fmt.Sprintf("/api/games/%d", gameID), "", nil, &board); status != http.StatusOK {
+		s.t.Fatalf("fetching game %d: status %d", gameID, status)
+	}
+	return board.Tiles
+}
+
+func TestCreateGameHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     any
+		status   int
+		problems bool
+	}{
+		{name: "valid", body: testutil.NewGame().Request(), status: http.StatusCreated},
+		{name: "malformed JSON", body: `{"author":`, status: http.StatusBadRequest},
+		{name: "invalid game", body: testutil.NewGame().Author("").Request(), status: http.StatusBadRequest, problems: true},
+	}
+
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		s := newServer(t, store)
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var got struct {
+					GameID   int64    `json:"game_id"`
+					Problems []string `json:"problems"`
+				}
+				status := s.do(http.MethodPost, "/api/game", "", tt.body, &got)
+				if status != tt.status {
+					t.Fatalf("status %d, want %d", status, tt.status)
+				}
+				if tt.status == http.StatusCreated && got.GameID == 0 {
+					t.Error("no game_id in response")
+				}
+				if tt.problems != (len(got.Problems) > 0) {
+					t.Errorf("problems %q, want some: %v", got.Problems, tt.problems)
+				}
+			})
+		}
+	})
+}
+
+func TestValidateGameHandler(t *testing.T) {
+	s := newServer(t, service.NewMemoryStore())
+
+	var got struct {
+		Valid    bool     `json:"valid"`
+		Problems []string `json:"problems"`
+	}
+	if status := s.do(http.MethodPost, "/api/games/validate", "", testutil.NewGame().Author("").Request(), &got); status != http.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	if got.Valid || len(got.Problems) == 0 {
+		t.Errorf("got valid=%v problems=%q, want invalid with problems", got.Valid, got.Problems)
+	}
+}
+
+func TestGetGameHandler(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		s := newServer(t, store)
+		game := testutil.Seed(t, s.games, store, testutil.NewGame())
+
+		tests := []struct {
+			name   string
+			path   string
+			status int
+		}{
+			{name: "published", path: fmt.Sprintf("/api/games/%d", game.ID), status: http.StatusOK},
+			{name: "unknown", path: fmt.Sprintf("/api/games/%d", game.ID+1000), status: http.StatusNotFound},
+			{name: "bad ID", path: "/api/games/abc", status: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var board models.GameTilesResponse
+				status := s.do(http.MethodGet, tt.path, "", nil, &board)
+				if status != tt.status {
+					t.Fatalf("status %d, want %d", status, tt.status)
+				}
+				if status == http.StatusOK && len(board.Tiles) != 16 {
+					t.Errorf("got %d tiles, want 16", len(board.Tiles))
+				}
+			})
+		}
+	})
+}
+
+func TestDailyHandlers(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		s := newServer(t, store)
+		ctx := context.Background()
+		today := "/api/admin/daily/" + s.games.Today().Format(time.DateOnly)
+
+		pending, err := s.games.CreateGame(ctx, testutil.NewGame().Request())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := s.admin(http.MethodPut, today, map[string]int64{"game_id": pending}, nil); status != http.StatusConflict {
+			t.Errorf("scheduling an unpublished game: status %d, want 409", status)
+		}
+
+		game := testutil.Seed(t, s.games, store, testutil.NewGame())
+		if status := s.admin(http.MethodPut, today, map[string]int64{"game_id": game.ID}, nil); status != http.StatusOK {
+			t.Fatalf("scheduling: status %d", status)
+		}
+		var daily models.DailyPuzzleResponse
+		if status := s.do(http.MethodGet, "/api/daily", "", nil, &daily); status != http.StatusOK || daily.GameID != game.ID {
+			t.Fatalf("today's puzzle: status %d, game %d", status, daily.GameID)
+		}
+
+		// A game taken down after it was scheduled leaves no puzzle for the day
+		if _, err := store.SetModerationStatus(ctx, db.SetModerationStatusParams{
+			ID:               game.ID,
+			ModerationStatus: db.ModerationStatusFlagged,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if status := s.do(http.MethodGet, "/api/daily", "", nil, nil); status != http.StatusNotFound {
+			t.Errorf("flagged daily puzzle: status %d, want 404", status)
+		}
+	})
+}
+
+func TestCheckTilesHandler(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		s := newServer(t, store)
+		game := testutil.Seed(t, s.games, store, testutil.NewGame())
+		other := testutil.Seed(t, s.games, store, testutil.NewGame())
+		board := s.board(game.ID)
+		first := testutil.Tokens(t, board, game.Groups[0].Titles...)
+		second := testutil.Tokens(t, board, game.Groups[1].Titles...)
+
+		tests := []struct {
+			name    string
+			body    any
+			status  int
+			correct bool
+		}{
+			{
+				name:    "correct",
+				body:    map[string]any{"game_id": game.ID, "tiles": first},
+				status:  http.StatusOK,
+				correct: true,
+			},
+			{
+				name:   "incorrect",
+				body:   map[string]any{"game_id": game.ID, "tiles": append(first[:3:3], second[0])},
+				status: http.StatusOK,
+			},
+			{
+				name:   "another game's tiles",
+				body:   map[string]any{"game_id": game.ID, "tiles": testutil.Tokens(t, s.board(other.ID), other.Groups[0].Titles...)},
+				status: http.StatusBadRequest,
+			},
+			{
+				name:   "repeated tile",
+				body:   map[string]any{"game_id": game.ID, "tiles": []string{first[0], first[0], first[1], first[2]}},
+				status: http.StatusBadRequest,
+			},
+			{
+				name:   "wrong number of tiles",
+				body:   map[string]any{"game_id": game.ID, "tiles": first[:2]},
+				status: http.StatusBadRequest,
+			},
+			{
+				name:   "missing game",
+				body:   map[string]any{"tiles": first},
+				status: http.StatusBadRequest,
+			},
+			{
+				name:   "unknown game",
+				body:   map[string]any{"game_id": game.ID + 1000, "tiles": first},
+				status: http.StatusNotFound,
+			},
+			{
+				name:   "malformed JSON",
+				body:   "tiles",
+				status: http.StatusBadRequest,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var got struct {
+					Correct bool `json:"correct"`
+				}
+				status := s.do(http.MethodPost, "/api/games/check", "", tt.body, &got)
+				if status != tt.status {
+					t.Fatalf("status %d, want %d", status, tt.status)
+				}
+				if got.Correct != tt.correct {
+					t.Errorf("correct %v, want %v", got.Correct, tt.correct)
+				}
+			})
+		}
+	})
+}
+
+func TestSessionHandlers(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		s := newServer(t, store)
+		game := testutil.Seed(t, s.games, store, testutil.NewGame())
+		other := testutil.Seed(t, s.games, store, testutil.NewGame())
+		start := map[string]any{"game_id": game.ID}
+
+		var unauthorized struct {
+			Error string `json:"error"`
+		}
+		if status := s.do(http.MethodPost, "/api/sessions", "", start, &unauthorized); status != http.StatusUnauthorized {
+			t.Fatalf("start without a player: status %d, want 401", status)
+		}
+		if !strings.Contains(unauthorized.Error, handlers.PlayerIDHeader) {
+			t.Errorf("error %q does not name the header", unauthorized.Error)
+		}
+
+		var session service.SessionState
+		if status := s.do(http.MethodPost, "/api/sessions", "player", start, &session); status != http.StatusOK {
+			t.Fatalf("start: status %d, want 200", status)
+		}
+		if status := s.do(http.MethodGet, fmt.Sprintf("/api/sessions/%d", session.ID), "someone-else", nil, nil); status != http.StatusNotFound {
+			t.Errorf("another player's session: status %d, want 404", status)
+		}
+
+		guesses := fmt.Sprintf("/api/sessions/%d/guesses", session.ID)
+		borrowed := map[string]any{"tiles": testutil.Tokens(t, s.board(other.ID), other.Groups[0].Titles...)}
+		if status := s.do(http.MethodPost, guesses, "player", borrowed, nil); status != http.StatusBadRequest {
+			t.Errorf("guessing another game's tiles: status %d, want 400", status)
+		}
+
+		hint := fmt.Sprintf("/api/sessions/%d/hint", session.ID)
+		var hinted service.HintResult
+		if status := s.do(http.MethodPost, hint, "player", nil, &hinted); status != http.StatusOK {
+			t.Fatalf("hint: status %d, want 200", status)
+		}
+		if hinted.Hint.Kind != "tile" || len(hinted.Session.Hints) != 1 {
+			t.Errorf("got hint %+v", hinted)
+		}
+
+		var result service.GuessResult
+		for _, group := range game.Groups {
+			if status := s.do(http.MethodPost, guesses, "player", map[string]any{"tiles": testutil.Tokens(t, session.Tiles, group.Titles...)}, &result); status != http.StatusOK {
+				t.Fatalf("guessing group %d: status %d, want 200", group.Tier, status)
+			}
+			if !result.Correct || result.LinkText != group.Link {
+				t.Errorf("guessing group %d: got %+v", group.Tier, result)
+			}
+		}
+		if result.Session.Status != "won" || result.Session.Mistakes != 0 {
+			t.Errorf("got %s with %d mistakes after solving every group, want won with none", result.Session.Status, result.Session.Mistakes)
+		}
+
+		status := s.do(http.MethodPost, guesses, "player", map[string]any{"tiles": testutil.Tokens(t, session.Tiles, game.Groups[0].Titles...)}, nil)
+		if status != http.StatusConflict {
+			t.Errorf("guessing after winning: status %d, want 409", status)
+		}
+		if status := s.do(http.MethodPost, hint, "player", nil, nil); status != http.StatusConflict {
+			t.Errorf("hint after winning: status %d, want 409", status)
+		}
+	})
+}
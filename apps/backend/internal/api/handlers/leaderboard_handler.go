@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+type LeaderboardHandler struct {
+	leaderboardService *service.LeaderboardService
+}
+
+func NewLeaderboardHandler(ls *service.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		leaderboardService: ls,
+	}
+}
+
+// GetLeaderboard pages with the limit and offset query parameters. When the
+// player identifies themselves their own rank is included as "me".
+func (h *LeaderboardHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	limit, offset, ok := pagination(w, r)
+	if !ok {
+		return
+	}
+
+	playerID := optionalPlayerID(r)
+	board, err := h.leaderboardService.Leaderboard(r.Context(), gameID, playerID, limit, offset)
+	switch {
+	case errors.Is(err, service.ErrGameNotFound):
+		response.Error(w, http.StatusNotFound, "Game not found")
+	case errors.Is(err, service.ErrNotTimed):
+		response.Error(w, http.StatusNotFound, err.Error())
+	case err != nil:
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch leaderboard")
+	default:
+		response.JSON(w, http.StatusOK, board)
+	}
+}
+
+func pagination(w http.ResponseWriter, r *http.Request) (limit, offset int, ok bool) {
+	for name, dst := range map[string]*int{"limit": &limit, "offset": &offset} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			response.Error(w, http.StatusBadRequest, "Invalid "+name)
+			return 0, 0, false
+		}
+		*dst = n
+	}
+	return limit, offset, true
+}
@@ -1,16 +1,24 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"time"
+
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
 )
 
 func CorsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorizaton")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Player-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -21,6 +29,38 @@ func CorsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// AdminMiddleware only lets through requests carrying the admin token as a
+// bearer token. With no token configured every request is refused.
+func AdminMiddleware(token string) func(http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := []byte(r.Header.Get("Authorization"))
+			if token == "" || subtle.ConstantTimeCompare(got, expected) != 1 {
+				response.Error(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// PlayerMiddleware reads the signed player cookie, leaving the player it
+// names for playerID to find. A cookie that doesn't verify is ignored.
+func PlayerMiddleware(signer *service.PlayerSigner) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			player := &playerCookie{signer: signer}
+			if cookie, err := r.Cookie(PlayerCookieName); err == nil {
+				player.id, _ = signer.Verify(cookie.Value)
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), playerCookieKey{}, player)))
+		})
+	}
+}
+
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -49,3 +89,19 @@ type responseWriter struct {
 func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
 	return &responseWriter{w, http.StatusOK}
 }
+
+// Hijack lets WebSocket upgrades through the logging wrapper.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	rw.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// Unwrap gives http.ResponseController the underlying writer, to flush and
+// set deadlines through.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+type ModerationHandler struct {
+	moderationService *service.ModerationService
+}
+
+func NewModerationHandler(ms *service.ModerationService) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: ms,
+	}
+}
+
+type reasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ListQueue lists games by the status query parameter, pending by default.
+func (h *ModerationHandler) ListQueue(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+
+	items, err := h.moderationService.Queue(r.Context(), status)
+	if err != nil {
+		moderationError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, items)
+}
+
+func (h *ModerationHandler) ReviewGame(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	review, err := h.moderationService.Review(r.Context(), gameID)
+	if err != nil {
+		moderationError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, review)
+}
+
+func (h *ModerationHandler) ApproveGame(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	item, err := h.moderationService.Approve(r.Context(), gameID)
+	if err != nil {
+		moderationError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, item)
+}
+
+func (h *ModerationHandler) RejectGame(w http.ResponseWriter, r *http.Request) {
+	h.withReason(w, r, h.moderationService.Reject)
+}
+
+func (h *ModerationHandler) FlagGame(w http.ResponseWriter, r *http.Request) {
+	h.withReason(w, r, h.moderationService.Flag)
+}
+
+func (h *ModerationHandler) withReason(w http.ResponseWriter, r *http.Request, moderate func(ctx context.Context, gameID int64, reason string) (service.ModerationItem, error)) {
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	var req reasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	item, err := moderate(r.Context(), gameID, req.Reason)
+	if err != nil {
+		moderationError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, item)
+}
+
+// ReportGame lets any identified player report a published game. Only players
+// the server issued an ID to count towards taking it down for review.
+func (h *ModerationHandler) ReportGame(w http.ResponseWriter, r *http.Request) {
+	player, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	var req reasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.moderationService.Report(r.Context(), player, gameID, req.Reason); err != nil {
+		moderationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func moderationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrGameNotFound):
+		response.Error(w, http.StatusNotFound, "Game not found")
+	case errors.Is(err, service.ErrInvalidReason), errors.Is(err, service.ErrInvalidModeration):
+		response.Error(w, http.StatusBadRequest, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, "Failed to moderate game")
+	}
+}
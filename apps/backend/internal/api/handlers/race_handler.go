@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+const (
+	raceWriteWait  = 10 * time.Second
+	racePongWait   = 60 * time.Second
+	racePingPeriod = racePongWait * 9 / 10
+	// raceMaxMessage is plenty for a guess of tokens.
+	raceMaxMessage = 4096
+)
+
+// raceMessage is what racers send over the socket: {"type": "start"} from
+// the host, and {"type": "guess", "tiles": [...]} from anyone.
+type raceMessage struct {
+	Type  string   `json:"type"`
+	Tiles []string `json:"tiles"`
+}
+
+type RaceHandler struct {
+	raceService *service.RaceService
+	upgrader    websocket.Upgrader
+}
+
+func NewRaceHandler(rs *service.RaceService) *RaceHandler {
+	return &RaceHandler{
+		raceService: rs,
+		upgrader: websocket.Upgrader{
+			// Like the rest of the API, races are open to any origin
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+func (h *RaceHandler) CreateRace(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		GameID int64 `json:"game_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GameID <= 0 {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	race, err := h.raceService.CreateRace(r.Context(), playerID, req.GameID)
+	if err != nil {
+		raceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, race)
+}
+
+func (h *RaceHandler) GetRace(w http.ResponseWriter, r *http.Request) {
+	race, err := h.raceService.Race(r.PathValue("code"))
+	if err != nil {
+		raceError(w, err)
+		return
+	}
+	summary, err := race.Summary()
+	if err != nil {
+		raceError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, summary)
+}
+
+// JoinRace upgrades to a WebSocket carrying the race's events.
+func (h *RaceHandler) JoinRace(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+
+	race, err := h.raceService.Race(r.PathValue("code"))
+	if err != nil {
+		raceError(w, err)
+		return
+	}
+	racer, err := race.Join(playerID)
+	if err != nil {
+		raceError(w, err)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already replied
+		racer.Leave()
+		return
+	}
+	defer conn.Close()
+
+	go writeRaceEvents(conn, racer)
+	readRaceMessages(conn, racer, r)
+}
+
+// readRaceMessages passes what the racer sends on to the race until the
+// connection drops, then leaves.
+func readRaceMessages(conn *websocket.Conn, racer *service.Racer, r *http.Request) {
+	defer racer.Leave()
+
+	conn.SetReadLimit(raceMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(racePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(racePongWait))
+	})
+
+	for {
+		var msg raceMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			var syntax *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntax) || errors.As(err, &typeErr) {
+				racer.Reject(errors.New("invalid message format"))
+				continue
+			}
+			return
+		}
+
+		switch msg.Type {
+		case "start":
+			racer.Start()
+		case "guess":
+			racer.Guess(r.Context(), msg.Tiles)
+		default:
+			racer.Reject(fmt.Errorf("unknown message type %q", msg.Type))
+		}
+	}
+}
+
+// writeRaceEvents is the only writer on the connection. It sends the race's
+// events and keeps the connection alive with pings, and closes the
+// connection once the race stops sending.
+func writeRaceEvents(conn *websocket.Conn, racer *service.Racer) {
+	ping := time.NewTicker(racePingPeriod)
+	defer func() {
+		ping.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case event, ok := <-racer.Events:
+			conn.SetWriteDeadline(time.Now().Add(raceWriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(raceWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func raceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrGameNotFound):
+		response.Error(w, http.StatusNotFound, "Game not found")
+	case errors.Is(err, service.ErrRaceNotFound):
+		response.Error(w, http.StatusNotFound, "Race not found")
+	case errors.Is(err, service.ErrRaceStarted), errors.Is(err, service.ErrRaceFull):
+		response.Error(w, http.StatusConflict, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
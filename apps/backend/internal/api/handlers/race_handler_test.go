@@ -0,0 +1,93 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lukeberry99/puzzle/internal/api/handlers"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestRaceHandlers(t *testing.T) {
+	s := newServer(t, service.NewMemoryStore())
+	game := testutil.Seed(t, s.games, s.store, testutil.NewGame())
+
+	raceHandler := handlers.NewRaceHandler(service.NewRaceService(s.games))
+	s.router.HandleFunc("POST /api/races", raceHandler.CreateRace)
+	s.router.HandleFunc("GET /api/races/{code}", raceHandler.GetRace)
+	s.router.HandleFunc("GET /api/races/{code}/ws", raceHandler.JoinRace)
+	// The logging middleware wraps the writer, which upgrades must get through
+	srv := httptest.NewServer(handlers.LoggingMiddleware(s.router))
+	defer srv.Close()
+
+	if status := s.do(http.MethodPost, "/api/races", "host", map[string]any{"game_id": game.ID + 1000}, nil); status != http.StatusNotFound {
+		t.Errorf("racing an unknown game: status %d, want 404", status)
+	}
+	var race service.RaceSummary
+	if status := s.do(http.MethodPost, "/api/races", "host", map[string]any{"game_id": game.ID}, &race); status != http.StatusCreated {
+		t.Fatalf("creating a race: status %d, want 201", status)
+	}
+	if status := s.do(http.MethodGet, "/api/races/NOPE42", "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("unknown race: status %d, want 404", status)
+	}
+
+	socket := "ws" + strings.TrimPrefix(srv.URL, "http") + fmt.Sprintf("/api/races/%s/ws", strings.ToLower(race.Code))
+	dial := func(player string) *websocket.Conn {
+		t.Helper()
+		conn, resp, err := websocket.DefaultDialer.Dial(socket, http.Header{handlers.PlayerIDHeader: {player}})
+		if err != nil {
+			t.Fatalf("%s joining: %v (%v)", player, err, resp)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return conn
+	}
+	read := func(conn *websocket.Conn, eventType string) service.RaceEvent {
+		t.Helper()
+		for {
+			var event service.RaceEvent
+			if err := conn.ReadJSON(&event); err != nil {
+				t.Fatalf("waiting for %s: %v", eventType, err)
+			}
+			if event.Type == eventType {
+				return event
+			}
+		}
+	}
+
+	host := dial("host")
+	defer host.Close()
+	guest := dial("guest")
+	defer guest.Close()
+
+	host.WriteJSON(map[string]any{"type": "dance"})
+	if event := read(host, service.RaceEventError); !strings.Contains(event.Error, "dance") {
+		t.Errorf("unknown message: got %q", event.Error)
+	}
+
+	host.WriteJSON(map[string]any{"type": "start"})
+	board := read(guest, service.RaceEventStart).Board
+	read(host, service.RaceEventStart)
+
+	if _, resp, err := websocket.DefaultDialer.Dial(socket, http.Header{handlers.PlayerIDHeader: {"late"}}); err == nil || resp.StatusCode != http.StatusConflict {
+		t.Errorf("joining a running race: got %v, want 409", resp)
+	}
+
+	for _, group := range game.Groups {
+		guest.WriteJSON(map[string]any{"type": "guess", "tiles": testutil.Tokens(t, board.Tiles, group.Titles...)})
+		if result := read(guest, service.RaceEventGuess).Result; !result.Correct || result.LinkText != group.Link {
+			t.Fatalf("guessing group %d: got %+v", group.Tier, result)
+		}
+	}
+	host.Close()
+
+	standings := read(guest, service.RaceEventStandings).Standings
+	if len(standings) != 2 || standings[0].Player != service.PlayerHandle("guest") || !standings[0].Won {
+		t.Errorf("got standings %+v, want guest first", standings)
+	}
+}
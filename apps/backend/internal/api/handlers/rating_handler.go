@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+type RatingHandler struct {
+	ratingService *service.RatingService
+}
+
+func NewRatingHandler(rs *service.RatingService) *RatingHandler {
+	return &RatingHandler{
+		ratingService: rs,
+	}
+}
+
+// RateGame submits the player's rating, or updates it if they already rated.
+func (h *RatingHandler) RateGame(w http.ResponseWriter, r *http.Request) {
+	player, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	var req models.RatingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	rating, err := h.ratingService.RateGame(r.Context(), player, gameID, req)
+	if err != nil {
+		ratingError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, rating)
+}
+
+func (h *RatingHandler) GetRating(w http.ResponseWriter, r *http.Request) {
+	player, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	rating, err := h.ratingService.Rating(r.Context(), player, gameID)
+	if err != nil {
+		ratingError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, rating)
+}
+
+func ratingError(w http.ResponseWriter, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		response.Invalid(w, verr.Problems)
+	case errors.Is(err, service.ErrGameNotFound):
+		response.Error(w, http.StatusNotFound, "Game not found")
+	case errors.Is(err, service.ErrRatingNotFound):
+		response.Error(w, http.StatusNotFound, "Rating not found")
+	case errors.Is(err, service.ErrNotPlayed):
+		response.Error(w, http.StatusConflict, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, "Failed to save rating")
+	}
+}
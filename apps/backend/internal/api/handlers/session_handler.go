@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+// PlayerIDHeader identifies the player making a request, for clients that
+// keep their own player ID. The player cookie takes precedence over it, and
+// IDs the server issues are refused in it, as they only count when signed.
+const PlayerIDHeader = "X-Player-ID"
+
+// PlayerCookieName is the cookie holding the signed player ID the server
+// hands out: an anonymous one on the first session start, or an account's
+// once the player signs up or logs in.
+const PlayerCookieName = "player"
+
+// playerCookieMaxAge is as long as browsers keep a cookie.
+const playerCookieMaxAge = 400 * 24 * 60 * 60
+
+const maxPlayerIDLength = 64
+
+type SessionHandler struct {
+	sessionService *service.SessionService
+	statsService   *service.StatsService
+}
+
+func NewSessionHandler(ss *service.SessionService, sts *service.StatsService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: ss,
+		statsService:   sts,
+	}
+}
+
+func (h *SessionHandler) StartSession(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := startPlayerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		GameID int64 `json:"game_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.GameID <= 0 {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	state, err := h.sessionService.StartSession(r.Context(), playerID, req.GameID)
+	if err != nil {
+		sessionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, state)
+}
+
+func (h *SessionHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	sessionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	state, err := h.sessionService.GetSession(r.Context(), playerID, sessionID)
+	if err != nil {
+		sessionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, state)
+}
+
+func (h *SessionHandler) SubmitGuess(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	sessionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	var req struct {
+		Tiles []string `json:"tiles"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	result, err := h.sessionService.SubmitGuess(r.Context(), playerID, sessionID, req.Tiles)
+	if err != nil {
+		sessionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
+// TakeHint gives the session's next hint. It takes no body: hints escalate
+// on their own.
+func (h *SessionHandler) TakeHint(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	sessionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	result, err := h.sessionService.TakeHint(r.Context(), playerID, sessionID)
+	if err != nil {
+		sessionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, result)
+}
+
+func (h *SessionHandler) ShareSession(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	sessionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	share, err := h.sessionService.Share(r.Context(), playerID, sessionID)
+	if err != nil {
+		sessionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, share)
+}
+
+func (h *SessionHandler) GetShare(w http.ResponseWriter, r *http.Request) {
+	share, err := h.sessionService.SharedResult(r.Context(), r.PathValue("code"))
+	if err != nil {
+		sessionError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, share)
+}
+
+func (h *SessionHandler) MyStats(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.statsService.PlayerStats(r.Context(), playerID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch stats")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, stats)
+}
+
+// playerID is the player making the request, who must say who they are.
+// This covers WebSockets and event streams too: browsers can't add headers
+// to them, but do send the cookie.
+func playerID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := optionalPlayerID(r)
+	if id == "" {
+		response.Error(w, http.StatusUnauthorized, "Missing or invalid "+PlayerIDHeader+" header")
+		return "", false
+	}
+	return id, true
+}
+
+// optionalPlayerID is the player making the request, from the cookie or else
+// the header, or empty when they don't say or the header can't be trusted.
+func optionalPlayerID(r *http.Request) string {
+	if player := cookiePlayer(r); player != nil && player.id != "" {
+		return player.id
+	}
+	id := strings.TrimSpace(r.Header.Get(PlayerIDHeader))
+	if len(id) > maxPlayerIDLength || service.IsIssuedPlayer(id) {
+		return ""
+	}
+	return id
+}
+
+// startPlayerID is playerID for starting a session. A player with neither
+// cookie nor header is new, and is given an anonymous ID in a cookie.
+func startPlayerID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	player := cookiePlayer(r)
+	if player == nil || player.id != "" || r.Header.Get(PlayerIDHeader) != "" {
+		return playerID(w, r)
+	}
+	id := service.NewAnonymousPlayer()
+	setPlayerCookie(w, r, id)
+	return id, true
+}
+
+type playerCookieKey struct{}
+
+// playerCookie is what PlayerMiddleware found: the player the cookie names,
+// if any, and the signer for issuing a new cookie.
+type playerCookie struct {
+	signer *service.PlayerSigner
+	id     string
+}
+
+func cookiePlayer(r *http.Request) *playerCookie {
+	player, _ := r.Context().Value(playerCookieKey{}).(*playerCookie)
+	return player
+}
+
+// setPlayerCookie signs playerID into the player cookie. Outside
+// PlayerMiddleware there is nothing to sign with, and no cookie is set.
+func setPlayerCookie(w http.ResponseWriter, r *http.Request, playerID string) {
+	player := cookiePlayer(r)
+	if player == nil {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     PlayerCookieName,
+		Value:    player.signer.Sign(playerID),
+		Path:     "/",
+		MaxAge:   playerCookieMaxAge,
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearPlayerCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     PlayerCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// secureRequest reports whether the request came over HTTPS, directly or
+// through a proxy.
+func secureRequest(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func sessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrGameNotFound):
+		response.Error(w, http.StatusNotFound, "Game not found")
+	case errors.Is(err, service.ErrSessionNotFound):
+		response.Error(w, http.StatusNotFound, "Session not found")
+	case errors.Is(err, service.ErrInvalidSelection):
+		response.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrSessionFinished), errors.Is(err, service.ErrAlreadyGuessed),
+		errors.Is(err, service.ErrSessionNotFinished), errors.Is(err, service.ErrNoHintsLeft),
+		errors.Is(err, service.ErrHintUnaffordable):
+		response.Error(w, http.StatusConflict, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// sseHeartbeat is how often an idle event stream sends a comment, so proxies
+// and clients can tell a quiet stream from a dead one.
+const sseHeartbeat = 25 * time.Second
+
+// eventStream writes Server-Sent Events.
+type eventStream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newEventStream sends the headers for an event stream. The stream is exempt
+// from the server's WriteTimeout, which is meant for ordinary requests and
+// would otherwise cut every stream off after a few seconds.
+func newEventStream(w http.ResponseWriter) (*eventStream, error) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Stop nginx buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &eventStream{w: w, rc: rc}
+	return s, s.rc.Flush()
+}
+
+// send writes one event with data as JSON. An empty id leaves the client's
+// last event ID as it was.
+func (s *eventStream) send(id, event string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, encoded); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// heartbeat writes a comment, which clients ignore.
+func (s *eventStream) heartbeat() error {
+	if _, err := fmt.Fprint(s.w, ": heartbeat\n\n"); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
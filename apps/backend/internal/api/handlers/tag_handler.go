@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+func (h *GameHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.gameService.ListTags(r.Context())
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch tags")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, tags)
+}
+
+func (h *GameHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var req models.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	tag, err := h.gameService.CreateTag(r.Context(), req)
+	if err != nil {
+		tagError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, tag)
+}
+
+func (h *GameHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	var req models.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	tag, err := h.gameService.RenameTag(r.Context(), tagID, req)
+	if err != nil {
+		tagError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, tag)
+}
+
+func (h *GameHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	if err := h.gameService.DeleteTag(r.Context(), tagID); err != nil {
+		tagError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetGameTags replaces a game's tags with the ones given.
+func (h *GameHandler) SetGameTags(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	var req models.GameTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	defer r.Body.Close()
+
+	tags, err := h.gameService.SetGameTags(r.Context(), gameID, req)
+	if err != nil {
+		tagError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"game_id": gameID,
+		"tags":    tags,
+	})
+}
+
+func tagError(w http.ResponseWriter, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		response.Invalid(w, verr.Problems)
+	case errors.Is(err, service.ErrGameNotFound):
+		response.Error(w, http.StatusNotFound, "Game not found")
+	case errors.Is(err, service.ErrTagNotFound):
+		response.Error(w, http.StatusNotFound, "Tag not found")
+	case errors.Is(err, service.ErrTagExists):
+		response.Error(w, http.StatusConflict, err.Error())
+	default:
+		response.Error(w, http.StatusInternalServerError, "Failed to update tags")
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/api/response"
+	"github.com/lukeberry99/puzzle/internal/puzzlefile"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+const maxImportSize = 10 << 20
+
+// ExportGame is for admins only, as exports include the answers. Any game can
+// be exported, released or not.
+func (h *GameHandler) ExportGame(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid game ID")
+		return
+	}
+
+	enc, err := puzzlefile.ParseEncoding(r.URL.Query().Get("format"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	game, err := h.gameService.ExportGame(r.Context(), gameID)
+	if errors.Is(err, service.ErrGameNotFound) {
+		response.Error(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to export game")
+		return
+	}
+
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="puzzle-%d.%s"`, gameID, enc))
+	if err := puzzlefile.Encode(w, enc, puzzlefile.NewDocument(puzzlefile.FromRequest(game))); err != nil {
+		log.Printf("unable to encode game %d: %v", gameID, err)
+	}
+}
+
+// ImportGames accepts a puzzle file in any supported encoding and publishes
+// the puzzles in it, as the games import command does. The encoding is taken
+// from the format query parameter, falling back to the Content-Type.
+func (h *ModerationHandler) ImportGames(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = r.Header.Get("Content-Type")
+	}
+	enc, err := puzzlefile.ParseEncoding(format)
+	if err != nil {
+		response.Error(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	doc, err := puzzlefile.Decode(http.MaxBytesReader(w, r.Body, maxImportSize), enc)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reqs := make([]models.CreateGameRequest, 0, len(doc.Puzzles))
+	for _, p := range doc.Puzzles {
+		reqs = append(reqs, p.Request())
+	}
+	results := h.moderationService.ImportGames(r.Context(), reqs)
+
+	created := 0
+	for _, result := range results {
+		if result.Status == service.ImportCreated {
+			created++
+		}
+	}
+
+	response.JSON(w, http.StatusOK, map[string]interface{}{
+		"created": created,
+		"failed":  len(results) - created,
+		"results": results,
+	})
+}
@@ -0,0 +1,62 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestTransferHandlers(t *testing.T) {
+	s := newServer(t, service.NewMemoryStore())
+	game := testutil.Seed(t, s.games, s.store, testutil.NewGame())
+	export := fmt.Sprintf("/api/admin/games/%d/export?format=json", game.ID)
+
+	// Exports carry the answers, so only admins get them
+	if status := s.do(http.MethodGet, export, "alice", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("anonymous export: status %d, want 401", status)
+	}
+	if status := s.do(http.MethodPost, "/api/admin/games/import?format=json", "alice", `{"version":1,"puzzles":[]}`, nil); status != http.StatusUnauthorized {
+		t.Errorf("anonymous import: status %d, want 401", status)
+	}
+	if status := s.admin(http.MethodGet, fmt.Sprintf("/api/admin/games/%d/export", game.ID+1000), nil, nil); status != http.StatusNotFound {
+		t.Errorf("exporting an unknown game: status %d, want 404", status)
+	}
+	if status := s.do(http.MethodGet, fmt.Sprintf("/api/games/%d/export", game.ID), "alice", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("anonymous export at the alias: status %d, want 401", status)
+	}
+	if status := s.admin(http.MethodGet, fmt.Sprintf("/api/games/%d/export", game.ID), nil, nil); status != http.StatusOK {
+		t.Errorf("admin export at the alias: status %d, want 200", status)
+	}
+
+	var file map[string]any
+	if status := s.admin(http.MethodGet, export, nil, &file); status != http.StatusOK {
+		t.Fatalf("exporting: status %d", status)
+	}
+	if !strings.Contains(fmt.Sprint(file), game.Groups[0].Link) {
+		t.Errorf("export %v is missing the link %q", file, game.Groups[0].Link)
+	}
+
+	// Both paths publish what they import, as the command line does
+	for _, path := range []string{"/api/admin/games/import?format=json", "/api/games/import?format=json"} {
+		var imported struct {
+			Created int `json:"created"`
+			Failed  int `json:"failed"`
+			Results []struct {
+				GameID int64 `json:"game_id"`
+			} `json:"results"`
+		}
+		if status := s.admin(http.MethodPost, path, file, &imported); status != http.StatusOK {
+			t.Fatalf("importing through %s: status %d", path, status)
+		}
+		if imported.Created != 1 || imported.Failed != 0 {
+			t.Fatalf("imported %+v through %s, want the exported game created again", imported, path)
+		}
+		if status := s.do(http.MethodGet, fmt.Sprintf("/api/games/%d", imported.Results[0].GameID), "alice", nil, nil); status != http.StatusOK {
+			t.Errorf("playing a game imported through %s: status %d, want it published", path, status)
+		}
+	}
+}
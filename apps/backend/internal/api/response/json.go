@@ -22,3 +22,17 @@ func Error(w http.ResponseWriter, status int, message string) {
 		Code:  status,
 	})
 }
+
+type ValidationErrorResponse struct {
+	Error    string   `json:"error"`
+	Code     int      `json:"code"`
+	Problems []string `json:"problems"`
+}
+
+func Invalid(w http.ResponseWriter, problems []string) {
+	JSON(w, http.StatusBadRequest, ValidationErrorResponse{
+		Error:    "Validation failed",
+		Code:     http.StatusBadRequest,
+		Problems: problems,
+	})
+}
@@ -3,10 +3,24 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	DBConnString string
+	// AdminToken guards the admin endpoints. They are disabled when it is empty.
+	AdminToken string
+	// DailyLocation decides when the daily puzzle rolls over to the next date.
+	DailyLocation *time.Location
+	// PublicURL is the frontend's address, used to build share links.
+	PublicURL string
+	// BlocklistFile lists terms that get new games flagged for moderation,
+	// one per line. No file means nothing is flagged automatically.
+	BlocklistFile string
+	// PlayerSecret signs player cookies. Without one a random secret is used,
+	// and players are forgotten whenever the server restarts.
+	PlayerSecret string
 }
 
 func Load() (*Config, error) {
@@ -15,7 +29,26 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("DB_CONN_STR environment variable is not set")
 	}
 
+	dailyTZ := os.Getenv("DAILY_TIMEZONE")
+	if dailyTZ == "" {
+		dailyTZ = "UTC"
+	}
+	dailyLocation, err := time.LoadLocation(dailyTZ)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DAILY_TIMEZONE %q: %w", dailyTZ, err)
+	}
+
+	publicURL := strings.TrimRight(os.Getenv("PUBLIC_URL"), "/")
+	if publicURL == "" {
+		publicURL = "https://connections.lberry.dev"
+	}
+
 	return &Config{
-		DBConnString: dbConnStr,
+		DBConnString:  dbConnStr,
+		AdminToken:    os.Getenv("ADMIN_TOKEN"),
+		DailyLocation: dailyLocation,
+		PublicURL:     publicURL,
+		BlocklistFile: os.Getenv("BLOCKLIST_FILE"),
+		PlayerSecret:  os.Getenv("PLAYER_SECRET"),
 	}, nil
 }
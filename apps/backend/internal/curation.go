@@ -0,0 +1,84 @@
+package models
+
+import (
+	"strings"
+)
+
+const (
+	MaxTagLength        = 32
+	MaxCollectionLength = 100
+)
+
+// NormalizeTag lowercases a tag and collapses its whitespace, so "Music  Week"
+// and "music week" are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+func validateTags(verr *ValidationError, tags []string) {
+	for i, tag := range tags {
+		switch name := NormalizeTag(tag); {
+		case name == "":
+			verr.add("tag %d: name is required", i+1)
+		case len(name) > MaxTagLength:
+			verr.add("tag %d: name must be at most %d characters", i+1, MaxTagLength)
+		}
+	}
+}
+
+type TagRequest struct {
+	Name string `json:"name" validate:"required,max=32"`
+}
+
+func (r TagRequest) Validate() error {
+	verr := &ValidationError{}
+	validateTags(verr, []string{r.Name})
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
+
+type GameTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func (r GameTagsRequest) Validate() error {
+	verr := &ValidationError{}
+	validateTags(verr, r.Tags)
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// CollectionRequest creates or replaces a collection; games are played in the
+// order given.
+type CollectionRequest struct {
+	Title       string  `json:"title" validate:"required"`
+	Description string  `json:"description"`
+	GameIDs     []int64 `json:"game_ids" validate:"max=100"`
+}
+
+func (r CollectionRequest) Validate() error {
+	verr := &ValidationError{}
+
+	if strings.TrimSpace(r.Title) == "" {
+		verr.add("title is required")
+	}
+	if len(r.GameIDs) > MaxCollectionLength {
+		verr.add("a collection holds at most %d games", MaxCollectionLength)
+	}
+	seen := make(map[int64]bool, len(r.GameIDs))
+	for i, id := range r.GameIDs {
+		if seen[id] {
+			verr.add("game %d: game %d is already in the collection", i+1, id)
+		}
+		seen[id] = true
+	}
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
@@ -5,7 +5,9 @@
 package db
 
 import (
+	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -54,6 +56,221 @@ func (ns NullDifficultyLevel) Value() (driver.Value, error) {
 	return string(ns.DifficultyLevel), nil
 }
 
+type HintKind string
+
+const (
+	HintKindTile    HintKind = "tile"
+	HintKindInitial HintKind = "initial"
+	HintKindLink    HintKind = "link"
+)
+
+func (e *HintKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = HintKind(s)
+	case string:
+		*e = HintKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for HintKind: %T", src)
+	}
+	return nil
+}
+
+type NullHintKind struct {
+	HintKind HintKind
+	Valid    bool // Valid is true if HintKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullHintKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.HintKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.HintKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullHintKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.HintKind), nil
+}
+
+type HintPenalty string
+
+const (
+	HintPenaltyMistake HintPenalty = "mistake"
+	HintPenaltyScore   HintPenalty = "score"
+)
+
+func (e *HintPenalty) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = HintPenalty(s)
+	case string:
+		*e = HintPenalty(s)
+	default:
+		return fmt.Errorf("unsupported scan type for HintPenalty: %T", src)
+	}
+	return nil
+}
+
+type NullHintPenalty struct {
+	HintPenalty HintPenalty
+	Valid       bool // Valid is true if HintPenalty is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullHintPenalty) Scan(value interface{}) error {
+	if value == nil {
+		ns.HintPenalty, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.HintPenalty.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullHintPenalty) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.HintPenalty), nil
+}
+
+type ModerationStatus string
+
+const (
+	ModerationStatusPending   ModerationStatus = "pending"
+	ModerationStatusPublished ModerationStatus = "published"
+	ModerationStatusRejected  ModerationStatus = "rejected"
+	ModerationStatusFlagged   ModerationStatus = "flagged"
+)
+
+func (e *ModerationStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ModerationStatus(s)
+	case string:
+		*e = ModerationStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ModerationStatus: %T", src)
+	}
+	return nil
+}
+
+type NullModerationStatus struct {
+	ModerationStatus ModerationStatus
+	Valid            bool // Valid is true if ModerationStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullModerationStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ModerationStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ModerationStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullModerationStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ModerationStatus), nil
+}
+
+type RatingFeedback string
+
+const (
+	RatingFeedbackTooEasy RatingFeedback = "too_easy"
+	RatingFeedbackTooHard RatingFeedback = "too_hard"
+	RatingFeedbackUnfair  RatingFeedback = "unfair"
+)
+
+func (e *RatingFeedback) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RatingFeedback(s)
+	case string:
+		*e = RatingFeedback(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RatingFeedback: %T", src)
+	}
+	return nil
+}
+
+type NullRatingFeedback struct {
+	RatingFeedback RatingFeedback
+	Valid          bool // Valid is true if RatingFeedback is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRatingFeedback) Scan(value interface{}) error {
+	if value == nil {
+		ns.RatingFeedback, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RatingFeedback.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRatingFeedback) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RatingFeedback), nil
+}
+
+type SessionStatus string
+
+const (
+	SessionStatusInProgress SessionStatus = "in_progress"
+	SessionStatusWon        SessionStatus = "won"
+	SessionStatusLost       SessionStatus = "lost"
+)
+
+func (e *SessionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SessionStatus(s)
+	case string:
+		*e = SessionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SessionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullSessionStatus struct {
+	SessionStatus SessionStatus
+	Valid         bool // Valid is true if SessionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSessionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.SessionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SessionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSessionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SessionStatus), nil
+}
+
 type TimeLimit string
 
 const (
@@ -98,28 +315,190 @@ func (ns NullTimeLimit) Value() (driver.Value, error) {
 	return string(ns.TimeLimit), nil
 }
 
-type Game struct {
-	ID         int64
-	Author     string
-	Difficulty DifficultyLevel
-	TimeLimit  TimeLimit
+type Collection struct {
+	ID          int64
+	Title       string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type CollectionGame struct {
+	CollectionID int64
+	GameID       int64
+	Position     int32
+}
+
+type DailyPuzzle struct {
+	PuzzleDate time.Time
+	GameID     int64
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
 
-type Group struct {
+type Game struct {
+	ID               int64
+	Author           string
+	Difficulty       DifficultyLevel
+	TimeLimit        TimeLimit
+	GroupCount       int32
+	GroupSize        int32
+	ModerationStatus ModerationStatus
+	ModerationReason string
+	ModeratedAt      sql.NullTime
+	HintPenalty      HintPenalty
+	HintTileCost     int32
+	HintInitialCost  int32
+	HintLinkCost     int32
+	TileKey          []byte
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type GameOutcome struct {
+	GameID          int64
+	Finished        int64
+	Won             int64
+	AverageMistakes float64
+}
+
+type GameRating struct {
+	GameID       int64
+	Ratings      int64
+	AverageStars float64
+	TooEasy      int64
+	TooHard      int64
+	Unfair       int64
+}
+
+type GameReport struct {
 	ID        int64
 	GameID    int64
-	Link      string
-	LinkTerms string
+	PlayerID  string
+	Issued    bool
+	Reason    string
 	CreatedAt time.Time
-	UpdatedAt time.Time
 }
 
-type Tile struct {
+type GameTag struct {
+	GameID int64
+	TagID  int64
+}
+
+type Group struct {
+	ID           int64
+	GameID       int64
+	Link         string
+	LinkTerms    string
+	Tier         int32
+	SearchVector sql.NullString
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type GroupSolvePosition struct {
+	GameID   int64
+	GroupID  int64
+	Position int64
+	Times    int64
+}
+
+type Guess struct {
+	ID        int64
+	SessionID int64
+	TileIds   []int64
+	Correct   bool
+	GroupID   sql.NullInt64
+	CreatedAt time.Time
+}
+
+type Hint struct {
 	ID        int64
+	SessionID int64
 	GroupID   int64
-	Title     string
+	Kind      HintKind
+	TileID    sql.NullInt64
+	CreatedAt time.Time
+}
+
+type LeaderboardEntry struct {
+	SessionID    int64
+	GameID       int64
+	PlayerID     string
+	Mistakes     int32
+	SolveSeconds float64
+	FinishedAt   sql.NullTime
+	Rank         int64
+}
+
+type PlayerMistake struct {
+	PlayerID string
+	Mistakes int32
+	Solved   int32
+}
+
+type PlayerStat struct {
+	PlayerID      string
+	Played        int32
+	Won           int32
+	CurrentStreak int32
+	MaxStreak     int32
+	LastDailyDate sql.NullTime
+	UpdatedAt     time.Time
+}
+
+type Rating struct {
+	ID        int64
+	GameID    int64
+	PlayerID  string
+	Stars     int32
+	Feedback  NullRatingFeedback
+	Comment   string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+type Session struct {
+	ID             int64
+	GameID         int64
+	PlayerID       string
+	DailyDate      sql.NullTime
+	Status         SessionStatus
+	Mistakes       int32
+	GroupsSolved   int32
+	HintsUsed      int32
+	HintPoints     int32
+	Score          sql.NullInt32
+	ScoreBreakdown json.RawMessage
+	StartedAt      time.Time
+	FinishedAt     sql.NullTime
+	ShareCode      sql.NullString
+	TileKey        []byte
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type Tag struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
+
+type Tile struct {
+	ID           int64
+	GroupID      int64
+	Title        string
+	DecoyGroupID sql.NullInt64
+	DecoyReason  sql.NullString
+	SearchVector sql.NullString
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type User struct {
+	ID           int64
+	Username     string
+	PasswordHash string
+	PlayerID     string
+	CreatedAt    time.Time
+}
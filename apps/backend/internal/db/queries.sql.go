@@ -7,111 +7,2531 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/lib/pq"
 )
 
+const addCollectionGames = `-- name: AddCollectionGames :exec
+INSERT INTO collection_games (
+    collection_id,
+    game_id,
+    position
+)
+SELECT
+    $1,
+    g.game_id,
+    g.position
+FROM
+    unnest($2::bigint[]) WITH ORDINALITY AS g(game_id, position)
+`
+
+type AddCollectionGamesParams struct {
+	CollectionID int64
+	GameIds      []int64
+}
+
+func (q *Queries) AddCollectionGames(ctx context.Context, arg AddCollectionGamesParams) error {
+	_, err := q.db.ExecContext(ctx, addCollectionGames, arg.CollectionID, pq.Array(arg.GameIds))
+	return err
+}
+
+const addGameTags = `-- name: AddGameTags :exec
+INSERT INTO game_tags (
+    game_id,
+    tag_id
+)
+SELECT
+    $1,
+    id
+FROM
+    tags
+WHERE
+    name = ANY($2::text[])
+ON CONFLICT DO NOTHING
+`
+
+type AddGameTagsParams struct {
+	GameID int64
+	Names  []string
+}
+
+func (q *Queries) AddGameTags(ctx context.Context, arg AddGameTagsParams) error {
+	_, err := q.db.ExecContext(ctx, addGameTags, arg.GameID, pq.Array(arg.Names))
+	return err
+}
+
+const advanceSession = `-- name: AdvanceSession :one
+UPDATE sessions
+SET
+    mistakes = mistakes + $1,
+    groups_solved = groups_solved + $2,
+    updated_at = NOW()
+WHERE
+    id = $3
+    AND status = 'in_progress'
+RETURNING id, game_id, player_id, daily_date, status, mistakes, groups_solved, hints_used, hint_points, score, score_breakdown, started_at, finished_at, share_code, tile_key, created_at, updated_at
+`
+
+type AdvanceSessionParams struct {
+	Mistakes     int32
+	GroupsSolved int32
+	ID           int64
+}
+
+// Counts are added to rather than set, so that concurrent guesses all count
+func (q *Queries) AdvanceSession(ctx context.Context, arg AdvanceSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, advanceSession, arg.Mistakes, arg.GroupsSolved, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.PlayerID,
+		&i.DailyDate,
+		&i.Status,
+		&i.Mistakes,
+		&i.GroupsSolved,
+		&i.HintsUsed,
+		&i.HintPoints,
+		&i.Score,
+		&i.ScoreBreakdown,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.ShareCode,
+		&i.TileKey,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const clearCollectionGames = `-- name: ClearCollectionGames :exec
+DELETE FROM collection_games
+WHERE collection_id = $1
+`
+
+func (q *Queries) ClearCollectionGames(ctx context.Context, collectionID int64) error {
+	_, err := q.db.ExecContext(ctx, clearCollectionGames, collectionID)
+	return err
+}
+
+const clearGameReports = `-- name: ClearGameReports :exec
+DELETE FROM game_reports
+WHERE game_id = $1
+`
+
+func (q *Queries) ClearGameReports(ctx context.Context, gameID int64) error {
+	_, err := q.db.ExecContext(ctx, clearGameReports, gameID)
+	return err
+}
+
+const clearGameTags = `-- name: ClearGameTags :exec
+DELETE FROM game_tags
+WHERE game_id = $1
+`
+
+func (q *Queries) ClearGameTags(ctx context.Context, gameID int64) error {
+	_, err := q.db.ExecContext(ctx, clearGameTags, gameID)
+	return err
+}
+
+const countGamesByDifficulty = `-- name: CountGamesByDifficulty :many
+SELECT
+    difficulty,
+    COUNT(*) AS games
+FROM
+    games
+GROUP BY
+    difficulty
+ORDER BY
+    difficulty
+`
+
+type CountGamesByDifficultyRow struct {
+	Difficulty DifficultyLevel
+	Games      int64
+}
+
+func (q *Queries) CountGamesByDifficulty(ctx context.Context) ([]CountGamesByDifficultyRow, error) {
+	rows, err := q.db.QueryContext(ctx, countGamesByDifficulty)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountGamesByDifficultyRow
+	for rows.Next() {
+		var i CountGamesByDifficultyRow
+		if err := rows.Scan(&i.Difficulty, &i.Games); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countLeaderboard = `-- name: CountLeaderboard :one
+SELECT
+    COUNT(*)
+FROM
+    leaderboard_entries
+WHERE
+    game_id = $1
+`
+
+func (q *Queries) CountLeaderboard(ctx context.Context, gameID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countLeaderboard, gameID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createCollection = `-- name: CreateCollection :one
+INSERT INTO collections (
+    title,
+    description
+) VALUES (
+    $1,
+    $2
+)
+RETURNING id, title, description, created_at, updated_at
+`
+
+type CreateCollectionParams struct {
+	Title       string
+	Description string
+}
+
+func (q *Queries) CreateCollection(ctx context.Context, arg CreateCollectionParams) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, createCollection, arg.Title, arg.Description)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const createGame = `-- name: CreateGame :one
 INSERT INTO games (
     author,
     difficulty,
-    time_limit
+    time_limit,
+    group_count,
+    group_size,
+    moderation_status,
+    moderation_reason,
+    hint_penalty,
+    hint_tile_cost,
+    hint_initial_cost,
+    hint_link_cost
 ) VALUES (
     $1,
     $2::difficulty_level,
-    $3::time_limit
+    $3::time_limit,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
+)
+RETURNING id
+`
+
+type CreateGameParams struct {
+	Author           string
+	Column2          DifficultyLevel
+	Column3          TimeLimit
+	GroupCount       int32
+	GroupSize        int32
+	ModerationStatus ModerationStatus
+	ModerationReason string
+	HintPenalty      HintPenalty
+	HintTileCost     int32
+	HintInitialCost  int32
+	HintLinkCost     int32
+}
+
+func (q *Queries) CreateGame(ctx context.Context, arg CreateGameParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createGame,
+		arg.Author,
+		arg.Column2,
+		arg.Column3,
+		arg.GroupCount,
+		arg.GroupSize,
+		arg.ModerationStatus,
+		arg.ModerationReason,
+		arg.HintPenalty,
+		arg.HintTileCost,
+		arg.HintInitialCost,
+		arg.HintLinkCost,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createGameReport = `-- name: CreateGameReport :exec
+INSERT INTO game_reports (
+    game_id,
+    player_id,
+    issued,
+    reason
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (game_id, player_id) DO UPDATE
+SET
+    reason = EXCLUDED.reason,
+    created_at = NOW()
+`
+
+type CreateGameReportParams struct {
+	GameID   int64
+	PlayerID string
+	Issued   bool
+	Reason   string
+}
+
+func (q *Queries) CreateGameReport(ctx context.Context, arg CreateGameReportParams) error {
+	_, err := q.db.ExecContext(ctx, createGameReport,
+		arg.GameID,
+		arg.PlayerID,
+		arg.Issued,
+		arg.Reason,
+	)
+	return err
+}
+
+const createGroup = `-- name: CreateGroup :one
+INSERT INTO groups (
+    game_id,
+    link,
+    link_terms,
+    tier
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
 )
 RETURNING id
 `
 
-type CreateGameParams struct {
-	Author  string
-	Column2 DifficultyLevel
-	Column3 TimeLimit
+type CreateGroupParams struct {
+	GameID    int64
+	Link      string
+	LinkTerms string
+	Tier      int32
+}
+
+func (q *Queries) CreateGroup(ctx context.Context, arg CreateGroupParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createGroup,
+		arg.GameID,
+		arg.Link,
+		arg.LinkTerms,
+		arg.Tier,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createGuess = `-- name: CreateGuess :one
+INSERT INTO guesses (
+    session_id,
+    tile_ids,
+    correct,
+    group_id
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING id, session_id, tile_ids, correct, group_id, created_at
+`
+
+type CreateGuessParams struct {
+	SessionID int64
+	TileIds   []int64
+	Correct   bool
+	GroupID   sql.NullInt64
+}
+
+func (q *Queries) CreateGuess(ctx context.Context, arg CreateGuessParams) (Guess, error) {
+	row := q.db.QueryRowContext(ctx, createGuess,
+		arg.SessionID,
+		pq.Array(arg.TileIds),
+		arg.Correct,
+		arg.GroupID,
+	)
+	var i Guess
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		pq.Array(&i.TileIds),
+		&i.Correct,
+		&i.GroupID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createHint = `-- name: CreateHint :one
+INSERT INTO hints (
+    session_id,
+    group_id,
+    kind,
+    tile_id
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING id, session_id, group_id, kind, tile_id, created_at
+`
+
+type CreateHintParams struct {
+	SessionID int64
+	GroupID   int64
+	Kind      HintKind
+	TileID    sql.NullInt64
+}
+
+func (q *Queries) CreateHint(ctx context.Context, arg CreateHintParams) (Hint, error) {
+	row := q.db.QueryRowContext(ctx, createHint,
+		arg.SessionID,
+		arg.GroupID,
+		arg.Kind,
+		arg.TileID,
+	)
+	var i Hint
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.GroupID,
+		&i.Kind,
+		&i.TileID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createTag = `-- name: CreateTag :one
+INSERT INTO tags (
+    name
+) VALUES (
+    $1
+)
+RETURNING id, name, created_at
+`
+
+func (q *Queries) CreateTag(ctx context.Context, name string) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, createTag, name)
+	var i Tag
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const createTilesForGroup = `-- name: CreateTilesForGroup :exec
+INSERT INTO tiles (
+    group_id,
+    title,
+    decoy_group_id,
+    decoy_reason
+) VALUES ($1, $2, $3, $4)
+`
+
+type CreateTilesForGroupParams struct {
+	GroupID      int64
+	Title        string
+	DecoyGroupID sql.NullInt64
+	DecoyReason  sql.NullString
+}
+
+func (q *Queries) CreateTilesForGroup(ctx context.Context, arg CreateTilesForGroupParams) error {
+	_, err := q.db.ExecContext(ctx, createTilesForGroup,
+		arg.GroupID,
+		arg.Title,
+		arg.DecoyGroupID,
+		arg.DecoyReason,
+	)
+	return err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (
+    username,
+    password_hash,
+    player_id
+) VALUES (
+    $1,
+    $2,
+    $3
+)
+RETURNING id, username, password_hash, player_id, created_at
+`
+
+type CreateUserParams struct {
+	Username     string
+	PasswordHash string
+	PlayerID     string
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser, arg.Username, arg.PasswordHash, arg.PlayerID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.PlayerID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteCollection = `-- name: DeleteCollection :execrows
+DELETE FROM collections
+WHERE id = $1
+`
+
+func (q *Queries) DeleteCollection(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCollection, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteDailyPuzzle = `-- name: DeleteDailyPuzzle :execrows
+DELETE FROM daily_puzzles
+WHERE puzzle_date = $1
+`
+
+func (q *Queries) DeleteDailyPuzzle(ctx context.Context, puzzleDate time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDailyPuzzle, puzzleDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteGame = `-- name: DeleteGame :execrows
+DELETE FROM games
+WHERE id = $1
+`
+
+func (q *Queries) DeleteGame(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteGame, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteGuess = `-- name: DeleteGuess :exec
+DELETE FROM guesses
+WHERE id = $1
+`
+
+func (q *Queries) DeleteGuess(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteGuess, id)
+	return err
+}
+
+const deleteHint = `-- name: DeleteHint :exec
+DELETE FROM hints
+WHERE id = $1
+`
+
+func (q *Queries) DeleteHint(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteHint, id)
+	return err
+}
+
+const deletePlayerStats = `-- name: DeletePlayerStats :exec
+DELETE FROM player_stats
+WHERE player_id = $1
+`
+
+func (q *Queries) DeletePlayerStats(ctx context.Context, playerID string) error {
+	_, err := q.db.ExecContext(ctx, deletePlayerStats, playerID)
+	return err
+}
+
+const deleteTag = `-- name: DeleteTag :execrows
+DELETE FROM tags
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTag(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTag, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const ensureTags = `-- name: EnsureTags :exec
+INSERT INTO tags (
+    name
+)
+SELECT
+    unnest($1::text[])
+ON CONFLICT (name) DO NOTHING
+`
+
+func (q *Queries) EnsureTags(ctx context.Context, names []string) error {
+	_, err := q.db.ExecContext(ctx, ensureTags, pq.Array(names))
+	return err
+}
+
+const finishSession = `-- name: FinishSession :one
+UPDATE sessions
+SET
+    status = $2,
+    finished_at = $3,
+    score = $4,
+    score_breakdown = $5,
+    updated_at = NOW()
+WHERE
+    id = $1
+    AND status = 'in_progress'
+RETURNING id, game_id, player_id, daily_date, status, mistakes, groups_solved, hints_used, hint_points, score, score_breakdown, started_at, finished_at, share_code, tile_key, created_at, updated_at
+`
+
+type FinishSessionParams struct {
+	ID             int64
+	Status         SessionStatus
+	FinishedAt     sql.NullTime
+	Score          sql.NullInt32
+	ScoreBreakdown json.RawMessage
+}
+
+// Only one request gets to finish a session
+func (q *Queries) FinishSession(ctx context.Context, arg FinishSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, finishSession,
+		arg.ID,
+		arg.Status,
+		arg.FinishedAt,
+		arg.Score,
+		arg.ScoreBreakdown,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.PlayerID,
+		&i.DailyDate,
+		&i.Status,
+		&i.Mistakes,
+		&i.GroupsSolved,
+		&i.HintsUsed,
+		&i.HintPoints,
+		&i.Score,
+		&i.ScoreBreakdown,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.ShareCode,
+		&i.TileKey,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getAllGames = `-- name: GetAllGames :many
+SELECT
+    id,
+    author,
+    difficulty,
+    moderation_status,
+    created_at
+FROM
+    games
+`
+
+type GetAllGamesRow struct {
+	ID               int64
+	Author           string
+	Difficulty       DifficultyLevel
+	ModerationStatus ModerationStatus
+	CreatedAt        time.Time
+}
+
+func (q *Queries) GetAllGames(ctx context.Context) ([]GetAllGamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllGames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllGamesRow
+	for rows.Next() {
+		var i GetAllGamesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Author,
+			&i.Difficulty,
+			&i.ModerationStatus,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCollection = `-- name: GetCollection :one
+SELECT
+    id, title, description, created_at, updated_at
+FROM
+    collections
+WHERE
+    id = $1
+`
+
+func (q *Queries) GetCollection(ctx context.Context, id int64) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, getCollection, id)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getContentCounts = `-- name: GetContentCounts :one
+SELECT
+    (SELECT COUNT(*) FROM games) AS games,
+    (SELECT COUNT(*) FROM groups) AS groups,
+    (SELECT COUNT(*) FROM tiles) AS tiles
+`
+
+type GetContentCountsRow struct {
+	Games  int64
+	Groups int64
+	Tiles  int64
+}
+
+func (q *Queries) GetContentCounts(ctx context.Context) (GetContentCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getContentCounts)
+	var i GetContentCountsRow
+	err := row.Scan(&i.Games, &i.Groups, &i.Tiles)
+	return i, err
+}
+
+const getDailyPuzzle = `-- name: GetDailyPuzzle :one
+SELECT
+    puzzle_date, game_id, created_at, updated_at
+FROM
+    daily_puzzles
+WHERE
+    puzzle_date = $1
+`
+
+func (q *Queries) GetDailyPuzzle(ctx context.Context, puzzleDate time.Time) (DailyPuzzle, error) {
+	row := q.db.QueryRowContext(ctx, getDailyPuzzle, puzzleDate)
+	var i DailyPuzzle
+	err := row.Scan(
+		&i.PuzzleDate,
+		&i.GameID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getGame = `-- name: GetGame :one
+SELECT id, author, difficulty, time_limit, group_count, group_size, moderation_status, moderation_reason, moderated_at, hint_penalty, hint_tile_cost, hint_initial_cost, hint_link_cost, tile_key, created_at, updated_at FROM games
+WHERE id = $1
+`
+
+func (q *Queries) GetGame(ctx context.Context, id int64) (Game, error) {
+	row := q.db.QueryRowContext(ctx, getGame, id)
+	var i Game
+	err := row.Scan(
+		&i.ID,
+		&i.Author,
+		&i.Difficulty,
+		&i.TimeLimit,
+		&i.GroupCount,
+		&i.GroupSize,
+		&i.ModerationStatus,
+		&i.ModerationReason,
+		&i.ModeratedAt,
+		&i.HintPenalty,
+		&i.HintTileCost,
+		&i.HintInitialCost,
+		&i.HintLinkCost,
+		&i.TileKey,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getGameOutcomes = `-- name: GetGameOutcomes :one
+SELECT
+    game_id, finished, won, average_mistakes
+FROM
+    game_outcomes
+WHERE
+    game_id = $1
+`
+
+func (q *Queries) GetGameOutcomes(ctx context.Context, gameID int64) (GameOutcome, error) {
+	row := q.db.QueryRowContext(ctx, getGameOutcomes, gameID)
+	var i GameOutcome
+	err := row.Scan(
+		&i.GameID,
+		&i.Finished,
+		&i.Won,
+		&i.AverageMistakes,
+	)
+	return i, err
+}
+
+const getGameTilesByIDs = `-- name: GetGameTilesByIDs :many
+SELECT tiles.id, tiles.group_id, tiles.title, tiles.decoy_group_id, tiles.decoy_reason, tiles.search_vector, tiles.created_at, tiles.updated_at FROM tiles
+JOIN groups ON groups.id = tiles.group_id
+WHERE groups.game_id = $1
+  AND tiles.id = ANY($2::bigint[])
+ORDER BY tiles.id
+`
+
+type GetGameTilesByIDsParams struct {
+	GameID int64
+	Ids    []int64
+}
+
+// Tiles from other games are left out, so a selection can't borrow them.
+func (q *Queries) GetGameTilesByIDs(ctx context.Context, arg GetGameTilesByIDsParams) ([]Tile, error) {
+	rows, err := q.db.QueryContext(ctx, getGameTilesByIDs, arg.GameID, pq.Array(arg.Ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tile
+	for rows.Next() {
+		var i Tile
+		if err := rows.Scan(
+			&i.ID,
+			&i.GroupID,
+			&i.Title,
+			&i.DecoyGroupID,
+			&i.DecoyReason,
+			&i.SearchVector,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroup = `-- name: GetGroup :one
+SELECT
+    id, game_id, link, link_terms, tier, search_vector, created_at, updated_at
+FROM
+    groups
+WHERE
+    id = $1
+`
+
+func (q *Queries) GetGroup(ctx context.Context, id int64) (Group, error) {
+	row := q.db.QueryRowContext(ctx, getGroup, id)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.Link,
+		&i.LinkTerms,
+		&i.Tier,
+		&i.SearchVector,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getGroupsForGame = `-- name: GetGroupsForGame :many
+SELECT
+    id 
+FROM 
+    groups
+WHERE
+    game_id = $1
+`
+
+func (q *Queries) GetGroupsForGame(ctx context.Context, gameID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getGroupsForGame, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLeaderboard = `-- name: GetLeaderboard :many
+SELECT
+    session_id, game_id, player_id, mistakes, solve_seconds, finished_at, rank
+FROM
+    leaderboard_entries
+WHERE
+    game_id = $1
+ORDER BY
+    rank,
+    session_id
+LIMIT $3
+OFFSET $2
+`
+
+type GetLeaderboardParams struct {
+	GameID     int64
+	PageOffset int32
+	PageLimit  int32
+}
+
+func (q *Queries) GetLeaderboard(ctx context.Context, arg GetLeaderboardParams) ([]LeaderboardEntry, error) {
+	rows, err := q.db.QueryContext(ctx, getLeaderboard, arg.GameID, arg.PageOffset, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LeaderboardEntry
+	for rows.Next() {
+		var i LeaderboardEntry
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.GameID,
+			&i.PlayerID,
+			&i.Mistakes,
+			&i.SolveSeconds,
+			&i.FinishedAt,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLeaderboardEntryForPlayer = `-- name: GetLeaderboardEntryForPlayer :one
+SELECT
+    session_id, game_id, player_id, mistakes, solve_seconds, finished_at, rank
+FROM
+    leaderboard_entries
+WHERE
+    game_id = $1
+    AND player_id = $2
+`
+
+type GetLeaderboardEntryForPlayerParams struct {
+	GameID   int64
+	PlayerID string
+}
+
+func (q *Queries) GetLeaderboardEntryForPlayer(ctx context.Context, arg GetLeaderboardEntryForPlayerParams) (LeaderboardEntry, error) {
+	row := q.db.QueryRowContext(ctx, getLeaderboardEntryForPlayer, arg.GameID, arg.PlayerID)
+	var i LeaderboardEntry
+	err := row.Scan(
+		&i.SessionID,
+		&i.GameID,
+		&i.PlayerID,
+		&i.Mistakes,
+		&i.SolveSeconds,
+		&i.FinishedAt,
+		&i.Rank,
+	)
+	return i, err
+}
+
+const getPlayerStats = `-- name: GetPlayerStats :one
+SELECT
+    player_id, played, won, current_streak, max_streak, last_daily_date, updated_at
+FROM
+    player_stats
+WHERE
+    player_id = $1
+`
+
+func (q *Queries) GetPlayerStats(ctx context.Context, playerID string) (PlayerStat, error) {
+	row := q.db.QueryRowContext(ctx, getPlayerStats, playerID)
+	var i PlayerStat
+	err := row.Scan(
+		&i.PlayerID,
+		&i.Played,
+		&i.Won,
+		&i.CurrentStreak,
+		&i.MaxStreak,
+		&i.LastDailyDate,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRating = `-- name: GetRating :one
+SELECT
+    id, game_id, player_id, stars, feedback, comment, created_at, updated_at
+FROM
+    ratings
+WHERE
+    game_id = $1
+    AND player_id = $2
+`
+
+type GetRatingParams struct {
+	GameID   int64
+	PlayerID string
+}
+
+func (q *Queries) GetRating(ctx context.Context, arg GetRatingParams) (Rating, error) {
+	row := q.db.QueryRowContext(ctx, getRating, arg.GameID, arg.PlayerID)
+	var i Rating
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.PlayerID,
+		&i.Stars,
+		&i.Feedback,
+		&i.Comment,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getReleasedGames = `-- name: GetReleasedGames :many
+SELECT
+    games.id,
+    games.author,
+    games.difficulty,
+    games.created_at,
+    ARRAY(
+        SELECT t.name
+        FROM game_tags gt
+            JOIN tags t ON t.id = gt.tag_id
+        WHERE gt.game_id = games.id
+        ORDER BY t.name
+    )::text[] AS tags,
+    COALESCE(r.ratings, 0)::BIGINT AS ratings,
+    COALESCE(r.average_stars, 0)::DOUBLE PRECISION AS average_stars,
+    COALESCE(r.too_easy, 0)::BIGINT AS too_easy,
+    COALESCE(r.too_hard, 0)::BIGINT AS too_hard,
+    COALESCE(r.unfair, 0)::BIGINT AS unfair
+FROM
+    games
+    LEFT JOIN game_ratings r ON r.game_id = games.id
+WHERE games.moderation_status = 'published'
+AND NOT EXISTS (
+    SELECT 1
+    FROM daily_puzzles
+    WHERE game_id = games.id
+    HAVING MIN(puzzle_date) > $1::date
+)
+AND (
+    $2::text = ''
+    OR EXISTS (
+        SELECT 1
+        FROM game_tags gt
+            JOIN tags t ON t.id = gt.tag_id
+        WHERE gt.game_id = games.id
+            AND t.name = $2::text
+    )
+)
+ORDER BY
+    CASE WHEN $3::text = 'rating' THEN r.average_stars END DESC NULLS LAST,
+    CASE WHEN $3::text = 'rating' THEN r.ratings END DESC NULLS LAST,
+    games.id
+`
+
+type GetReleasedGamesParams struct {
+	Today time.Time
+	Tag   string
+	Sort  string
+}
+
+type GetReleasedGamesRow struct {
+	ID           int64
+	Author       string
+	Difficulty   DifficultyLevel
+	CreatedAt    time.Time
+	Tags         []string
+	Ratings      int64
+	AverageStars float64
+	TooEasy      int64
+	TooHard      int64
+	Unfair       int64
+}
+
+// An empty tag matches every game. Sorting by "rating" puts the best rated
+// first and unrated games last; otherwise games are listed oldest first.
+func (q *Queries) GetReleasedGames(ctx context.Context, arg GetReleasedGamesParams) ([]GetReleasedGamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReleasedGames, arg.Today, arg.Tag, arg.Sort)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReleasedGamesRow
+	for rows.Next() {
+		var i GetReleasedGamesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Author,
+			&i.Difficulty,
+			&i.CreatedAt,
+			pq.Array(&i.Tags),
+			&i.Ratings,
+			&i.AverageStars,
+			&i.TooEasy,
+			&i.TooHard,
+			&i.Unfair,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSession = `-- name: GetSession :one
+SELECT
+    id, game_id, player_id, daily_date, status, mistakes, groups_solved, hints_used, hint_points, score, score_breakdown, started_at, finished_at, share_code, tile_key, created_at, updated_at
+FROM
+    sessions
+WHERE
+    id = $1
+`
+
+func (q *Queries) GetSession(ctx context.Context, id int64) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.PlayerID,
+		&i.DailyDate,
+		&i.Status,
+		&i.Mistakes,
+		&i.GroupsSolved,
+		&i.HintsUsed,
+		&i.HintPoints,
+		&i.Score,
+		&i.ScoreBreakdown,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.ShareCode,
+		&i.TileKey,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSessionByShareCode = `-- name: GetSessionByShareCode :one
+SELECT
+    id, game_id, player_id, daily_date, status, mistakes, groups_solved, hints_used, hint_points, score, score_breakdown, started_at, finished_at, share_code, tile_key, created_at, updated_at
+FROM
+    sessions
+WHERE
+    share_code = $1
+`
+
+func (q *Queries) GetSessionByShareCode(ctx context.Context, shareCode sql.NullString) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSessionByShareCode, shareCode)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.PlayerID,
+		&i.DailyDate,
+		&i.Status,
+		&i.Mistakes,
+		&i.GroupsSolved,
+		&i.HintsUsed,
+		&i.HintPoints,
+		&i.Score,
+		&i.ScoreBreakdown,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.ShareCode,
+		&i.TileKey,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSessionForPlayer = `-- name: GetSessionForPlayer :one
+SELECT
+    id, game_id, player_id, daily_date, status, mistakes, groups_solved, hints_used, hint_points, score, score_breakdown, started_at, finished_at, share_code, tile_key, created_at, updated_at
+FROM
+    sessions
+WHERE
+    game_id = $1
+    AND player_id = $2
+`
+
+type GetSessionForPlayerParams struct {
+	GameID   int64
+	PlayerID string
+}
+
+func (q *Queries) GetSessionForPlayer(ctx context.Context, arg GetSessionForPlayerParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSessionForPlayer, arg.GameID, arg.PlayerID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.PlayerID,
+		&i.DailyDate,
+		&i.Status,
+		&i.Mistakes,
+		&i.GroupsSolved,
+		&i.HintsUsed,
+		&i.HintPoints,
+		&i.Score,
+		&i.ScoreBreakdown,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.ShareCode,
+		&i.TileKey,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTilesForGroup = `-- name: GetTilesForGroup :many
+SELECT
+    id,
+    title 
+FROM 
+    tiles 
+WHERE
+    group_id = $1
+ORDER BY
+    id
+`
+
+type GetTilesForGroupRow struct {
+	ID    int64
+	Title string
+}
+
+func (q *Queries) GetTilesForGroup(ctx context.Context, groupID int64) ([]GetTilesForGroupRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTilesForGroup, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTilesForGroupRow
+	for rows.Next() {
+		var i GetTilesForGroupRow
+		if err := rows.Scan(&i.ID, &i.Title); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+SELECT
+    id, username, password_hash, player_id, created_at
+FROM
+    users
+WHERE
+    username = $1
+`
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByUsername, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.PlayerID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const incrementPlayerMistakes = `-- name: IncrementPlayerMistakes :exec
+INSERT INTO player_mistakes (
+    player_id,
+    mistakes,
+    solved
+) VALUES (
+    $1,
+    $2,
+    1
+)
+ON CONFLICT (player_id, mistakes) DO UPDATE
+SET
+    solved = player_mistakes.solved + 1
+`
+
+type IncrementPlayerMistakesParams struct {
+	PlayerID string
+	Mistakes int32
+}
+
+func (q *Queries) IncrementPlayerMistakes(ctx context.Context, arg IncrementPlayerMistakesParams) error {
+	_, err := q.db.ExecContext(ctx, incrementPlayerMistakes, arg.PlayerID, arg.Mistakes)
+	return err
+}
+
+const isGameEmbargoed = `-- name: IsGameEmbargoed :one
+SELECT EXISTS (
+    SELECT 1
+    FROM daily_puzzles
+    WHERE game_id = $1
+    HAVING MIN(puzzle_date) > $2::date
+) AS embargoed
+`
+
+type IsGameEmbargoedParams struct {
+	GameID int64
+	Today  time.Time
+}
+
+func (q *Queries) IsGameEmbargoed(ctx context.Context, arg IsGameEmbargoedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isGameEmbargoed, arg.GameID, arg.Today)
+	var embargoed bool
+	err := row.Scan(&embargoed)
+	return embargoed, err
+}
+
+const listCollectionGames = `-- name: ListCollectionGames :many
+SELECT
+    g.id,
+    g.author,
+    g.difficulty,
+    cg.position,
+    COALESCE(s.status::text, '')::text AS status
+FROM
+    collection_games cg
+    JOIN games g ON g.id = cg.game_id
+    LEFT JOIN sessions s ON s.game_id = g.id AND s.player_id = $1::text
+WHERE
+    cg.collection_id = $2
+    AND g.moderation_status = 'published'
+    AND NOT EXISTS (
+        SELECT 1
+        FROM daily_puzzles
+        WHERE game_id = g.id
+        HAVING MIN(puzzle_date) > $3::date
+    )
+ORDER BY
+    cg.position
+`
+
+type ListCollectionGamesParams struct {
+	PlayerID     string
+	CollectionID int64
+	Today        time.Time
+}
+
+type ListCollectionGamesRow struct {
+	ID         int64
+	Author     string
+	Difficulty DifficultyLevel
+	Position   int32
+	Status     string
+}
+
+// Progress comes from the player's sessions; an empty player ID has none.
+func (q *Queries) ListCollectionGames(ctx context.Context, arg ListCollectionGamesParams) ([]ListCollectionGamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCollectionGames, arg.PlayerID, arg.CollectionID, arg.Today)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCollectionGamesRow
+	for rows.Next() {
+		var i ListCollectionGamesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Author,
+			&i.Difficulty,
+			&i.Position,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCollections = `-- name: ListCollections :many
+SELECT
+    c.id, c.title, c.description, c.created_at, c.updated_at,
+    COUNT(cg.game_id) AS games
+FROM
+    collections c
+    LEFT JOIN collection_games cg ON cg.collection_id = c.id
+GROUP BY
+    c.id
+ORDER BY
+    c.id
+`
+
+type ListCollectionsRow struct {
+	ID          int64
+	Title       string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Games       int64
+}
+
+func (q *Queries) ListCollections(ctx context.Context) ([]ListCollectionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCollections)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCollectionsRow
+	for rows.Next() {
+		var i ListCollectionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Games,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDailyPuzzles = `-- name: ListDailyPuzzles :many
+SELECT
+    puzzle_date, game_id, created_at, updated_at
+FROM
+    daily_puzzles
+WHERE
+    puzzle_date BETWEEN $1::date AND $2::date
+ORDER BY
+    puzzle_date
+`
+
+type ListDailyPuzzlesParams struct {
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+func (q *Queries) ListDailyPuzzles(ctx context.Context, arg ListDailyPuzzlesParams) ([]DailyPuzzle, error) {
+	rows, err := q.db.QueryContext(ctx, listDailyPuzzles, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DailyPuzzle
+	for rows.Next() {
+		var i DailyPuzzle
+		if err := rows.Scan(
+			&i.PuzzleDate,
+			&i.GameID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDecoysForGame = `-- name: ListDecoysForGame :many
+SELECT
+    t.id,
+    t.title,
+    g.tier,
+    dg.tier AS decoy_tier,
+    COALESCE(t.decoy_reason, '')::text AS decoy_reason
+FROM
+    tiles t
+    JOIN groups g ON g.id = t.group_id
+    JOIN groups dg ON dg.id = t.decoy_group_id
+WHERE
+    g.game_id = $1
+ORDER BY
+    g.tier,
+    t.id
+`
+
+type ListDecoysForGameRow struct {
+	ID          int64
+	Title       string
+	Tier        int32
+	DecoyTier   int32
+	DecoyReason string
+}
+
+func (q *Queries) ListDecoysForGame(ctx context.Context, gameID int64) ([]ListDecoysForGameRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDecoysForGame, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDecoysForGameRow
+	for rows.Next() {
+		var i ListDecoysForGameRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Tier,
+			&i.DecoyTier,
+			&i.DecoyReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFinishedSessionsForPlayer = `-- name: ListFinishedSessionsForPlayer :many
+SELECT
+    id, game_id, player_id, daily_date, status, mistakes, groups_solved, hints_used, hint_points, score, score_breakdown, started_at, finished_at, share_code, tile_key, created_at, updated_at
+FROM
+    sessions
+WHERE
+    player_id = $1
+    AND status <> 'in_progress'
+ORDER BY
+    finished_at,
+    id
+`
+
+func (q *Queries) ListFinishedSessionsForPlayer(ctx context.Context, playerID string) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, listFinishedSessionsForPlayer, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.GameID,
+			&i.PlayerID,
+			&i.DailyDate,
+			&i.Status,
+			&i.Mistakes,
+			&i.GroupsSolved,
+			&i.HintsUsed,
+			&i.HintPoints,
+			&i.Score,
+			&i.ScoreBreakdown,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.ShareCode,
+			&i.TileKey,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGameReports = `-- name: ListGameReports :many
+SELECT
+    reason,
+    created_at
+FROM
+    game_reports
+WHERE
+    game_id = $1
+ORDER BY
+    created_at
+`
+
+type ListGameReportsRow struct {
+	Reason    string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListGameReports(ctx context.Context, gameID int64) ([]ListGameReportsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listGameReports, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGameReportsRow
+	for rows.Next() {
+		var i ListGameReportsRow
+		if err := rows.Scan(&i.Reason, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGamesForModeration = `-- name: ListGamesForModeration :many
+SELECT
+    g.id,
+    g.author,
+    g.difficulty,
+    g.moderation_status,
+    g.moderation_reason,
+    g.moderated_at,
+    g.created_at,
+    COUNT(r.id) AS reports
+FROM
+    games g
+    LEFT JOIN game_reports r ON r.game_id = g.id
+WHERE
+    g.moderation_status = $1
+GROUP BY
+    g.id
+ORDER BY
+    g.created_at,
+    g.id
+`
+
+type ListGamesForModerationRow struct {
+	ID               int64
+	Author           string
+	Difficulty       DifficultyLevel
+	ModerationStatus ModerationStatus
+	ModerationReason string
+	ModeratedAt      sql.NullTime
+	CreatedAt        time.Time
+	Reports          int64
+}
+
+func (q *Queries) ListGamesForModeration(ctx context.Context, moderationStatus ModerationStatus) ([]ListGamesForModerationRow, error) {
+	rows, err := q.db.QueryContext(ctx, listGamesForModeration, moderationStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGamesForModerationRow
+	for rows.Next() {
+		var i ListGamesForModerationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Author,
+			&i.Difficulty,
+			&i.ModerationStatus,
+			&i.ModerationReason,
+			&i.ModeratedAt,
+			&i.CreatedAt,
+			&i.Reports,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroupSolvePositions = `-- name: ListGroupSolvePositions :many
+SELECT
+    p.group_id,
+    gr.link,
+    gr.tier,
+    p.position,
+    p.times
+FROM
+    group_solve_positions p
+    JOIN groups gr ON gr.id = p.group_id
+WHERE
+    p.game_id = $1
+ORDER BY
+    gr.tier,
+    p.position
+`
+
+type ListGroupSolvePositionsRow struct {
+	GroupID  int64
+	Link     string
+	Tier     int32
+	Position int64
+	Times    int64
+}
+
+func (q *Queries) ListGroupSolvePositions(ctx context.Context, gameID int64) ([]ListGroupSolvePositionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listGroupSolvePositions, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGroupSolvePositionsRow
+	for rows.Next() {
+		var i ListGroupSolvePositionsRow
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.Link,
+			&i.Tier,
+			&i.Position,
+			&i.Times,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroupsForGame = `-- name: ListGroupsForGame :many
+SELECT
+    id, game_id, link, link_terms, tier, search_vector, created_at, updated_at
+FROM
+    groups
+WHERE
+    game_id = $1
+ORDER BY
+    tier
+`
+
+func (q *Queries) ListGroupsForGame(ctx context.Context, gameID int64) ([]Group, error) {
+	rows, err := q.db.QueryContext(ctx, listGroupsForGame, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Group
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(
+			&i.ID,
+			&i.GameID,
+			&i.Link,
+			&i.LinkTerms,
+			&i.Tier,
+			&i.SearchVector,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGuessesForSession = `-- name: ListGuessesForSession :many
+SELECT
+    id, session_id, tile_ids, correct, group_id, created_at
+FROM
+    guesses
+WHERE
+    session_id = $1
+ORDER BY
+    id
+`
+
+func (q *Queries) ListGuessesForSession(ctx context.Context, sessionID int64) ([]Guess, error) {
+	rows, err := q.db.QueryContext(ctx, listGuessesForSession, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Guess
+	for rows.Next() {
+		var i Guess
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			pq.Array(&i.TileIds),
+			&i.Correct,
+			&i.GroupID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHintsForSession = `-- name: ListHintsForSession :many
+SELECT
+    id, session_id, group_id, kind, tile_id, created_at
+FROM
+    hints
+WHERE
+    session_id = $1
+ORDER BY
+    id
+`
+
+func (q *Queries) ListHintsForSession(ctx context.Context, sessionID int64) ([]Hint, error) {
+	rows, err := q.db.QueryContext(ctx, listHintsForSession, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Hint
+	for rows.Next() {
+		var i Hint
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.GroupID,
+			&i.Kind,
+			&i.TileID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIncorrectSets = `-- name: ListIncorrectSets :many
+SELECT
+    sorted.tile_ids::BIGINT[] AS tile_ids,
+    COUNT(*) AS times
+FROM (
+    SELECT
+        ARRAY(SELECT unnest(gu.tile_ids) ORDER BY 1) AS tile_ids
+    FROM
+        guesses gu
+        JOIN sessions s ON s.id = gu.session_id
+    WHERE
+        s.game_id = $1
+        AND NOT gu.correct
+) sorted
+GROUP BY
+    sorted.tile_ids
+ORDER BY
+    times DESC,
+    sorted.tile_ids
+LIMIT $2
+`
+
+type ListIncorrectSetsParams struct {
+	GameID   int64
+	RowLimit int32
+}
+
+type ListIncorrectSetsRow struct {
+	TileIds []int64
+	Times   int64
+}
+
+func (q *Queries) ListIncorrectSets(ctx context.Context, arg ListIncorrectSetsParams) ([]ListIncorrectSetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listIncorrectSets, arg.GameID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListIncorrectSetsRow
+	for rows.Next() {
+		var i ListIncorrectSetsRow
+		if err := rows.Scan(pq.Array(&i.TileIds), &i.Times); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlayerMistakes = `-- name: ListPlayerMistakes :many
+SELECT
+    mistakes,
+    solved
+FROM
+    player_mistakes
+WHERE
+    player_id = $1
+ORDER BY
+    mistakes
+`
+
+type ListPlayerMistakesRow struct {
+	Mistakes int32
+	Solved   int32
+}
+
+func (q *Queries) ListPlayerMistakes(ctx context.Context, playerID string) ([]ListPlayerMistakesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPlayerMistakes, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPlayerMistakesRow
+	for rows.Next() {
+		var i ListPlayerMistakesRow
+		if err := rows.Scan(&i.Mistakes, &i.Solved); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT
+    t.id,
+    t.name,
+    COUNT(gt.game_id) AS games
+FROM
+    tags t
+    LEFT JOIN game_tags gt ON gt.tag_id = t.id
+GROUP BY
+    t.id
+ORDER BY
+    t.name
+`
+
+type ListTagsRow struct {
+	ID    int64
+	Name  string
+	Games int64
+}
+
+func (q *Queries) ListTags(ctx context.Context) ([]ListTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsRow
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Games); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagsForGame = `-- name: ListTagsForGame :many
+SELECT
+    t.name
+FROM
+    game_tags gt
+    JOIN tags t ON t.id = gt.tag_id
+WHERE
+    gt.game_id = $1
+ORDER BY
+    t.name
+`
+
+func (q *Queries) ListTagsForGame(ctx context.Context, gameID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listTagsForGame, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTilesForGame = `-- name: ListTilesForGame :many
+SELECT
+    t.id,
+    t.group_id,
+    t.title
+FROM
+    tiles t
+    JOIN groups g ON g.id = t.group_id
+WHERE
+    g.game_id = $1
+ORDER BY
+    t.id
+`
+
+type ListTilesForGameRow struct {
+	ID      int64
+	GroupID int64
+	Title   string
+}
+
+func (q *Queries) ListTilesForGame(ctx context.Context, gameID int64) ([]ListTilesForGameRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTilesForGame, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTilesForGameRow
+	for rows.Next() {
+		var i ListTilesForGameRow
+		if err := rows.Scan(&i.ID, &i.GroupID, &i.Title); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWrongPairings = `-- name: ListWrongPairings :many
+SELECT
+    ta.id AS tile_id,
+    ta.title AS tile_title,
+    tb.id AS paired_tile_id,
+    tb.title AS paired_tile_title,
+    COUNT(*) AS times,
+    COALESCE(
+        CASE
+            WHEN ta.decoy_group_id = tb.group_id THEN ta.decoy_reason
+            WHEN tb.decoy_group_id = ta.group_id THEN tb.decoy_reason
+        END,
+        ''
+    )::text AS decoy_reason
+FROM
+    guesses gu
+    JOIN sessions s ON s.id = gu.session_id
+    JOIN tiles ta ON ta.id = ANY(gu.tile_ids)
+    JOIN tiles tb ON tb.id = ANY(gu.tile_ids) AND tb.id > ta.id
+WHERE
+    s.game_id = $1
+    AND NOT gu.correct
+    AND ta.group_id <> tb.group_id
+GROUP BY
+    ta.id,
+    tb.id
+ORDER BY
+    times DESC,
+    ta.id,
+    tb.id
+LIMIT $2
+`
+
+type ListWrongPairingsParams struct {
+	GameID   int64
+	RowLimit int32
+}
+
+type ListWrongPairingsRow struct {
+	TileID          int64
+	TileTitle       string
+	PairedTileID    int64
+	PairedTileTitle string
+	Times           int64
+	DecoyReason     string
+}
+
+func (q *Queries) ListWrongPairings(ctx context.Context, arg ListWrongPairingsParams) ([]ListWrongPairingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listWrongPairings, arg.GameID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWrongPairingsRow
+	for rows.Next() {
+		var i ListWrongPairingsRow
+		if err := rows.Scan(
+			&i.TileID,
+			&i.TileTitle,
+			&i.PairedTileID,
+			&i.PairedTileTitle,
+			&i.Times,
+			&i.DecoyReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUser = `-- name: LockUser :exec
+SELECT id FROM users WHERE id = $1 FOR UPDATE
+`
+
+// Merges into an account wait for each other, so stats are rebuilt once
+func (q *Queries) LockUser(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, lockUser, id)
+	return err
 }
 
-func (q *Queries) CreateGame(ctx context.Context, arg CreateGameParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, createGame, arg.Author, arg.Column2, arg.Column3)
-	var id int64
-	err := row.Scan(&id)
-	return id, err
+const moveDailyPuzzle = `-- name: MoveDailyPuzzle :one
+UPDATE daily_puzzles
+SET
+    puzzle_date = $1,
+    updated_at = NOW()
+WHERE
+    puzzle_date = $2
+RETURNING puzzle_date, game_id, created_at, updated_at
+`
+
+type MoveDailyPuzzleParams struct {
+	ToDate   time.Time
+	FromDate time.Time
 }
 
-const createGroup = `-- name: CreateGroup :one
-INSERT INTO groups (
-    game_id,
-    link,
-    link_terms
+func (q *Queries) MoveDailyPuzzle(ctx context.Context, arg MoveDailyPuzzleParams) (DailyPuzzle, error) {
+	row := q.db.QueryRowContext(ctx, moveDailyPuzzle, arg.ToDate, arg.FromDate)
+	var i DailyPuzzle
+	err := row.Scan(
+		&i.PuzzleDate,
+		&i.GameID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const moveSessions = `-- name: MoveSessions :execrows
+UPDATE sessions
+SET
+    player_id = $1,
+    updated_at = NOW()
+WHERE
+    sessions.player_id = $2
+    AND sessions.game_id NOT IN (
+        SELECT
+            owned.game_id
+        FROM
+            sessions owned
+        WHERE
+            owned.player_id = $1
+    )
+`
+
+type MoveSessionsParams struct {
+	ToPlayer   string
+	FromPlayer string
+}
+
+// Sessions of games the target player has already played stay where they are
+func (q *Queries) MoveSessions(ctx context.Context, arg MoveSessionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveSessions, arg.ToPlayer, arg.FromPlayer)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recordPlayerResult = `-- name: RecordPlayerResult :exec
+INSERT INTO player_stats (
+    player_id,
+    played,
+    won,
+    current_streak,
+    max_streak,
+    last_daily_date
 ) VALUES (
     $1,
-    $2,
-    $3
+    1,
+    CASE WHEN $2::boolean THEN 1 ELSE 0 END,
+    CASE WHEN $2::boolean AND $3::date IS NOT NULL THEN 1 ELSE 0 END,
+    CASE WHEN $2::boolean AND $3::date IS NOT NULL THEN 1 ELSE 0 END,
+    $3::date
 )
-RETURNING id
+ON CONFLICT (player_id) DO UPDATE
+SET
+    played = player_stats.played + 1,
+    won = player_stats.won + EXCLUDED.won,
+    current_streak = CASE
+        WHEN EXCLUDED.last_daily_date IS NULL THEN player_stats.current_streak
+        WHEN EXCLUDED.won = 0 THEN 0
+        WHEN player_stats.last_daily_date = EXCLUDED.last_daily_date - 1 THEN player_stats.current_streak + 1
+        ELSE 1
+    END,
+    max_streak = GREATEST(player_stats.max_streak, CASE
+        WHEN EXCLUDED.last_daily_date IS NULL THEN player_stats.current_streak
+        WHEN EXCLUDED.won = 0 THEN 0
+        WHEN player_stats.last_daily_date = EXCLUDED.last_daily_date - 1 THEN player_stats.current_streak + 1
+        ELSE 1
+    END),
+    last_daily_date = COALESCE(EXCLUDED.last_daily_date, player_stats.last_daily_date),
+    updated_at = NOW()
 `
 
-type CreateGroupParams struct {
-	GameID    int64
-	Link      string
-	LinkTerms string
+type RecordPlayerResultParams struct {
+	PlayerID  string
+	Won       bool
+	DailyDate sql.NullTime
 }
 
-func (q *Queries) CreateGroup(ctx context.Context, arg CreateGroupParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, createGroup, arg.GameID, arg.Link, arg.LinkTerms)
-	var id int64
-	err := row.Scan(&id)
-	return id, err
+// Totals are added to rather than set, so that sessions finishing at the same
+// time all count. Only daily puzzles played on their day affect the streak: a
+// win on the day after the last daily extends it, any other win starts a new
+// one and a loss resets it.
+func (q *Queries) RecordPlayerResult(ctx context.Context, arg RecordPlayerResultParams) error {
+	_, err := q.db.ExecContext(ctx, recordPlayerResult, arg.PlayerID, arg.Won, arg.DailyDate)
+	return err
 }
 
-const createTilesForGroup = `-- name: CreateTilesForGroup :exec
-INSERT INTO tiles (
-    group_id,
-    title
-) VALUES ($1, $2)
+const refreshGameOutcomes = `-- name: RefreshGameOutcomes :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY game_outcomes
 `
 
-type CreateTilesForGroupParams struct {
-	GroupID int64
-	Title   string
+func (q *Queries) RefreshGameOutcomes(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, refreshGameOutcomes)
+	return err
 }
 
-func (q *Queries) CreateTilesForGroup(ctx context.Context, arg CreateTilesForGroupParams) error {
-	_, err := q.db.ExecContext(ctx, createTilesForGroup, arg.GroupID, arg.Title)
+const refreshGroupSolvePositions = `-- name: RefreshGroupSolvePositions :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY group_solve_positions
+`
+
+func (q *Queries) RefreshGroupSolvePositions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, refreshGroupSolvePositions)
 	return err
 }
 
-const getAllGames = `-- name: GetAllGames :many
+const renameTag = `-- name: RenameTag :one
+UPDATE tags
+SET
+    name = $2
+WHERE
+    id = $1
+RETURNING id, name, created_at
+`
+
+type RenameTagParams struct {
+	ID   int64
+	Name string
+}
+
+func (q *Queries) RenameTag(ctx context.Context, arg RenameTagParams) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, renameTag, arg.ID, arg.Name)
+	var i Tag
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const returnGameToReview = `-- name: ReturnGameToReview :exec
+UPDATE games
+SET
+    moderation_status = 'pending',
+    updated_at = NOW()
+WHERE
+    games.id = $1
+    AND games.moderation_status = 'published'
+    AND (
+        SELECT
+            COUNT(*)
+        FROM
+            game_reports r
+        WHERE
+            r.game_id = $1
+            AND r.issued
+    ) >= $2::bigint
+`
+
+type ReturnGameToReviewParams struct {
+	ID         int64
+	MinReports int64
+}
+
+// Only once enough players with issued IDs have reported the game
+func (q *Queries) ReturnGameToReview(ctx context.Context, arg ReturnGameToReviewParams) error {
+	_, err := q.db.ExecContext(ctx, returnGameToReview, arg.ID, arg.MinReports)
+	return err
+}
+
+const searchGames = `-- name: SearchGames :many
+WITH search AS (
+    SELECT websearch_to_tsquery('english', $4::text) AS query
+),
+finished AS (
+    SELECT DISTINCT
+        s.game_id
+    FROM
+        sessions s
+    WHERE
+        s.player_id = $5::text
+        AND s.status <> 'in_progress'
+),
+group_hits AS (
+    SELECT
+        g.game_id,
+        MAX(ts_rank(g.search_vector, search.query)) AS rank,
+        string_agg(
+            ts_headline('english', g.link, search.query, 'StartSel=**, StopSel=**, HighlightAll=true'),
+            ' · ' ORDER BY g.tier
+        ) AS snippet
+    FROM
+        groups g,
+        search
+    WHERE
+        g.search_vector @@ search.query
+    GROUP BY
+        g.game_id
+),
+tile_hits AS (
+    SELECT
+        g.game_id,
+        MAX(ts_rank(t.search_vector, search.query)) AS rank,
+        string_agg(
+            ts_headline('english', t.title, search.query, 'StartSel=**, StopSel=**, HighlightAll=true'),
+            ' · ' ORDER BY t.title
+        ) AS snippet
+    FROM
+        tiles t
+        JOIN groups g ON g.id = t.group_id,
+        search
+    WHERE
+        t.search_vector @@ search.query
+    GROUP BY
+        g.game_id
+)
 SELECT
-    id,
-    author,
-    difficulty,
-    created_at
+    ga.id,
+    ga.author,
+    ga.difficulty,
+    ga.created_at,
+    (COALESCE(gh.rank, 0) + COALESCE(th.rank, 0))::REAL AS relevance,
+    COALESCE(th.snippet, '')::text AS tile_snippet,
+    COALESCE(gh.snippet, '')::text AS link_snippet
 FROM
-    games
+    games ga
+    LEFT JOIN finished f ON f.game_id = ga.id
+    LEFT JOIN group_hits gh ON gh.game_id = ga.id
+        AND f.game_id IS NOT NULL
+    LEFT JOIN tile_hits th ON th.game_id = ga.id
+WHERE
+    (gh.game_id IS NOT NULL OR th.game_id IS NOT NULL)
+    AND ga.moderation_status = 'published'
+    AND NOT EXISTS (
+        SELECT 1
+        FROM daily_puzzles
+        WHERE game_id = ga.id
+        HAVING MIN(puzzle_date) > $1::date
+    )
+ORDER BY
+    relevance DESC,
+    ga.id DESC
+LIMIT $3
+OFFSET $2
 `
 
-type GetAllGamesRow struct {
-	ID         int64
-	Author     string
-	Difficulty DifficultyLevel
-	CreatedAt  time.Time
+type SearchGamesParams struct {
+	Today      time.Time
+	PageOffset int32
+	PageLimit  int32
+	Query      string
+	PlayerID   string
 }
 
-func (q *Queries) GetAllGames(ctx context.Context) ([]GetAllGamesRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllGames)
+type SearchGamesRow struct {
+	ID          int64
+	Author      string
+	Difficulty  DifficultyLevel
+	CreatedAt   time.Time
+	Relevance   float32
+	TileSnippet string
+	LinkSnippet string
+}
+
+// Links only match, rank and are snippeted for games the player has finished,
+// and tile snippets are in title order rather than group order, so searching
+// can't be used to read the answers to a puzzle.
+func (q *Queries) SearchGames(ctx context.Context, arg SearchGamesParams) ([]SearchGamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchGames,
+		arg.Today,
+		arg.PageOffset,
+		arg.PageLimit,
+		arg.Query,
+		arg.PlayerID,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAllGamesRow
+	var items []SearchGamesRow
 	for rows.Next() {
-		var i GetAllGamesRow
+		var i SearchGamesRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Author,
 			&i.Difficulty,
 			&i.CreatedAt,
+			&i.Relevance,
+			&i.TileSnippet,
+			&i.LinkSnippet,
 		); err != nil {
 			return nil, err
 		}
@@ -126,168 +2546,305 @@ func (q *Queries) GetAllGames(ctx context.Context) ([]GetAllGamesRow, error) {
 	return items, nil
 }
 
-const getGame = `-- name: GetGame :one
-SELECT id, author, difficulty, time_limit, created_at, updated_at FROM games
-WHERE id = $1
+const setModerationStatus = `-- name: SetModerationStatus :one
+UPDATE games
+SET
+    moderation_status = $2,
+    moderation_reason = $3,
+    moderated_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING id, author, difficulty, time_limit, group_count, group_size, moderation_status, moderation_reason, moderated_at, hint_penalty, hint_tile_cost, hint_initial_cost, hint_link_cost, tile_key, created_at, updated_at
 `
 
-func (q *Queries) GetGame(ctx context.Context, id int64) (Game, error) {
-	row := q.db.QueryRowContext(ctx, getGame, id)
+type SetModerationStatusParams struct {
+	ID               int64
+	ModerationStatus ModerationStatus
+	ModerationReason string
+}
+
+func (q *Queries) SetModerationStatus(ctx context.Context, arg SetModerationStatusParams) (Game, error) {
+	row := q.db.QueryRowContext(ctx, setModerationStatus, arg.ID, arg.ModerationStatus, arg.ModerationReason)
 	var i Game
 	err := row.Scan(
 		&i.ID,
 		&i.Author,
 		&i.Difficulty,
 		&i.TimeLimit,
+		&i.GroupCount,
+		&i.GroupSize,
+		&i.ModerationStatus,
+		&i.ModerationReason,
+		&i.ModeratedAt,
+		&i.HintPenalty,
+		&i.HintTileCost,
+		&i.HintInitialCost,
+		&i.HintLinkCost,
+		&i.TileKey,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getGroup = `-- name: GetGroup :one
-SELECT
-    id, game_id, link, link_terms, created_at, updated_at
-FROM
-    groups
+const setSessionShareCode = `-- name: SetSessionShareCode :one
+UPDATE sessions
+SET
+    share_code = COALESCE(share_code, $1)
 WHERE
-    id = $1
+    id = $2
+RETURNING share_code
 `
 
-func (q *Queries) GetGroup(ctx context.Context, id int64) (Group, error) {
-	row := q.db.QueryRowContext(ctx, getGroup, id)
-	var i Group
+type SetSessionShareCodeParams struct {
+	ShareCode sql.NullString
+	ID        int64
+}
+
+func (q *Queries) SetSessionShareCode(ctx context.Context, arg SetSessionShareCodeParams) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, setSessionShareCode, arg.ShareCode, arg.ID)
+	var share_code sql.NullString
+	err := row.Scan(&share_code)
+	return share_code, err
+}
+
+const startSession = `-- name: StartSession :one
+INSERT INTO sessions (
+    game_id,
+    player_id,
+    daily_date
+) VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (player_id, game_id) DO UPDATE
+SET
+    updated_at = NOW()
+RETURNING id, game_id, player_id, daily_date, status, mistakes, groups_solved, hints_used, hint_points, score, score_breakdown, started_at, finished_at, share_code, tile_key, created_at, updated_at
+`
+
+type StartSessionParams struct {
+	GameID    int64
+	PlayerID  string
+	DailyDate sql.NullTime
+}
+
+func (q *Queries) StartSession(ctx context.Context, arg StartSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, startSession, arg.GameID, arg.PlayerID, arg.DailyDate)
+	var i Session
 	err := row.Scan(
 		&i.ID,
 		&i.GameID,
-		&i.Link,
-		&i.LinkTerms,
+		&i.PlayerID,
+		&i.DailyDate,
+		&i.Status,
+		&i.Mistakes,
+		&i.GroupsSolved,
+		&i.HintsUsed,
+		&i.HintPoints,
+		&i.Score,
+		&i.ScoreBreakdown,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.ShareCode,
+		&i.TileKey,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getGroupsForGame = `-- name: GetGroupsForGame :many
-SELECT
-    id 
-FROM 
-    groups
+const updateCollection = `-- name: UpdateCollection :one
+UPDATE collections
+SET
+    title = $2,
+    description = $3,
+    updated_at = NOW()
 WHERE
-    game_id = $1
+    id = $1
+RETURNING id, title, description, created_at, updated_at
 `
 
-func (q *Queries) GetGroupsForGame(ctx context.Context, gameID int64) ([]int64, error) {
-	rows, err := q.db.QueryContext(ctx, getGroupsForGame, gameID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []int64
-	for rows.Next() {
-		var id int64
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
-		}
-		items = append(items, id)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type UpdateCollectionParams struct {
+	ID          int64
+	Title       string
+	Description string
+}
+
+func (q *Queries) UpdateCollection(ctx context.Context, arg UpdateCollectionParams) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, updateCollection, arg.ID, arg.Title, arg.Description)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
-const getTilesByIDs = `-- name: GetTilesByIDs :many
-SELECT id, group_id, title, created_at, updated_at FROM tiles
-WHERE id = ANY($1::bigint[])
+const upsertDailyPuzzle = `-- name: UpsertDailyPuzzle :one
+INSERT INTO daily_puzzles (
+    puzzle_date,
+    game_id
+) VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (puzzle_date) DO UPDATE
+SET
+    game_id = EXCLUDED.game_id,
+    updated_at = NOW()
+RETURNING puzzle_date, game_id, created_at, updated_at
 `
 
-func (q *Queries) GetTilesByIDs(ctx context.Context, dollar_1 []int64) ([]Tile, error) {
-	rows, err := q.db.QueryContext(ctx, getTilesByIDs, pq.Array(dollar_1))
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Tile
-	for rows.Next() {
-		var i Tile
-		if err := rows.Scan(
-			&i.ID,
-			&i.GroupID,
-			&i.Title,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type UpsertDailyPuzzleParams struct {
+	PuzzleDate time.Time
+	GameID     int64
 }
 
-const getTilesForGroup = `-- name: GetTilesForGroup :many
-SELECT
-    id,
-    title 
-FROM 
-    tiles 
+func (q *Queries) UpsertDailyPuzzle(ctx context.Context, arg UpsertDailyPuzzleParams) (DailyPuzzle, error) {
+	row := q.db.QueryRowContext(ctx, upsertDailyPuzzle, arg.PuzzleDate, arg.GameID)
+	var i DailyPuzzle
+	err := row.Scan(
+		&i.PuzzleDate,
+		&i.GameID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertRating = `-- name: UpsertRating :one
+INSERT INTO ratings (
+    game_id,
+    player_id,
+    stars,
+    feedback,
+    comment
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (game_id, player_id) DO UPDATE
+SET
+    stars = EXCLUDED.stars,
+    feedback = EXCLUDED.feedback,
+    comment = EXCLUDED.comment,
+    updated_at = NOW()
+RETURNING id, game_id, player_id, stars, feedback, comment, created_at, updated_at
+`
+
+type UpsertRatingParams struct {
+	GameID   int64
+	PlayerID string
+	Stars    int32
+	Feedback NullRatingFeedback
+	Comment  string
+}
+
+func (q *Queries) UpsertRating(ctx context.Context, arg UpsertRatingParams) (Rating, error) {
+	row := q.db.QueryRowContext(ctx, upsertRating,
+		arg.GameID,
+		arg.PlayerID,
+		arg.Stars,
+		arg.Feedback,
+		arg.Comment,
+	)
+	var i Rating
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.PlayerID,
+		&i.Stars,
+		&i.Feedback,
+		&i.Comment,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const useHint = `-- name: UseHint :one
+UPDATE sessions
+SET
+    hints_used = hints_used + 1,
+    mistakes = mistakes + $1,
+    hint_points = hint_points + $2,
+    updated_at = NOW()
 WHERE
-    group_id = $1
+    id = $3
+    AND status = 'in_progress'
+    AND mistakes + $1 < $4::int
+RETURNING id, game_id, player_id, daily_date, status, mistakes, groups_solved, hints_used, hint_points, score, score_breakdown, started_at, finished_at, share_code, tile_key, created_at, updated_at
 `
 
-type GetTilesForGroupRow struct {
-	ID    int64
-	Title string
+type UseHintParams struct {
+	Mistakes    int32
+	Points      int32
+	ID          int64
+	MaxMistakes int32
 }
 
-func (q *Queries) GetTilesForGroup(ctx context.Context, groupID int64) ([]GetTilesForGroupRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTilesForGroup, groupID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetTilesForGroupRow
-	for rows.Next() {
-		var i GetTilesForGroupRow
-		if err := rows.Scan(&i.ID, &i.Title); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+// A hint is never paid for with the last mistake, however many guesses land
+// at the same time
+func (q *Queries) UseHint(ctx context.Context, arg UseHintParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, useHint,
+		arg.Mistakes,
+		arg.Points,
+		arg.ID,
+		arg.MaxMistakes,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.GameID,
+		&i.PlayerID,
+		&i.DailyDate,
+		&i.Status,
+		&i.Mistakes,
+		&i.GroupsSolved,
+		&i.HintsUsed,
+		&i.HintPoints,
+		&i.Score,
+		&i.ScoreBreakdown,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.ShareCode,
+		&i.TileKey,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
 const validateTilesInSameGroup = `-- name: ValidateTilesInSameGroup :one
 WITH tile_count AS (
     SELECT group_id, COUNT(*) as tile_count
     FROM tiles
-    WHERE id = ANY($1::bigint[])
+    WHERE id = ANY($2::bigint[])
     GROUP BY group_id
 )
 SELECT EXISTS (
     SELECT 1 
     FROM tile_count 
-    WHERE tile_count = 4
+    WHERE tile_count = $1::int
 ) AS is_valid
 `
 
-func (q *Queries) ValidateTilesInSameGroup(ctx context.Context, dollar_1 []int64) (bool, error) {
-	row := q.db.QueryRowContext(ctx, validateTilesInSameGroup, pq.Array(dollar_1))
+type ValidateTilesInSameGroupParams struct {
+	GroupSize int32
+	TileIds   []int64
+}
+
+func (q *Queries) ValidateTilesInSameGroup(ctx context.Context, arg ValidateTilesInSameGroupParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, validateTilesInSameGroup, arg.GroupSize, pq.Array(arg.TileIds))
 	var is_valid bool
 	err := row.Scan(&is_valid)
 	return is_valid, err
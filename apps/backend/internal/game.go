@@ -1,21 +1,215 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	Difficulties  = []string{"easy", "medium", "hard", "impossible"}
+	TimeLimits    = []string{"unlimited", "15", "10", "5"}
+	HintPenalties = []string{HintPenaltyMistake, HintPenaltyScore}
+)
+
+// Boards default to four groups of four tiles, and may be anywhere between
+// MinBoardDimension and MaxBoardDimension in either direction.
+const (
+	DefaultGroupCount = 4
+	DefaultGroupSize  = 4
+	MinBoardDimension = 2
+	MaxBoardDimension = 8
+)
+
+// Hints cost players either extra mistakes or points off their score. A game
+// sets a cost for each kind of hint; without one, hints cost
+// DefaultHintCosts. A hint may cost at most MaxHintMistakes mistakes, so it
+// can never end a session by itself, or MaxHintPoints points.
+const (
+	HintPenaltyMistake = "mistake"
+	HintPenaltyScore   = "score"
+	MaxHintMistakes    = 3
+	MaxHintPoints      = 1000
+)
+
+var DefaultHintCosts = HintCosts{Penalty: HintPenaltyScore, Tile: 50, Initial: 100, Link: 200}
+
+// HintCosts is what each kind of hint costs, counted in Penalty. Hints on a
+// group escalate from revealing one of its tiles, to the initial of its link,
+// to the whole link.
+type HintCosts struct {
+	Penalty string `json:"penalty"`
+	Tile    int    `json:"tile"`
+	Initial int    `json:"initial"`
+	Link    int    `json:"link"`
+}
+
 type Tile struct {
 	ID    int64  `json:"id"`
 	Title string `json:"title" validate:"required"`
+	// Decoy marks the tile as a red herring. It is only ever sent to players
+	// once their session has ended.
+	Decoy *Decoy `json:"decoy,omitempty"`
+}
+
+// Decoy names the other group, by tier, that a tile appears to fit.
+type Decoy struct {
+	Tier   int    `json:"tier" validate:"required,min=1"`
+	Reason string `json:"reason,omitempty"`
 }
 
 type Group struct {
 	Link      string   `json:"link" validate:"required"`
-	LinkTerms []string `json:"link_terms" validate:required,min=1"`
-	Tiles     []Tile   `json:"tiles" validate:"required,len=4"`
+	LinkTerms []string `json:"link_terms" validate:"required,min=1"`
+	// Tier orders groups by difficulty, from 1 (yellow, easiest) up to the
+	// number of groups. Each tier is used exactly once per game.
+	Tier  int    `json:"tier" validate:"required,min=1"`
+	Tiles []Tile `json:"tiles" validate:"required"`
 }
 
 type CreateGameRequest struct {
-	Author     string  `json:"author" validate:"required"`
-	Difficulty string  `json:"difficulty" validate:"required,oneof=Easy Medium Hard Impossible"`
-	TimeLimit  string  `json:"time_limit" validate:"required,oneof=Unlimited 15 10 5"`
-	Groups     []Group `json:"groups" validate:"required,min=1"`
+	Author     string `json:"author" validate:"required"`
+	Difficulty string `json:"difficulty" validate:"required,oneof=Easy Medium Hard Impossible"`
+	TimeLimit  string `json:"time_limit" validate:"required,oneof=Unlimited 15 10 5"`
+	// GroupCount and GroupSize declare the board shape; zero means the default.
+	GroupCount int      `json:"group_count,omitempty" validate:"omitempty,min=2,max=8"`
+	GroupSize  int      `json:"group_size,omitempty" validate:"omitempty,min=2,max=8"`
+	Groups     []Group  `json:"groups" validate:"required,min=1"`
+	Tags       []string `json:"tags,omitempty"`
+	// Hints overrides DefaultHintCosts.
+	Hints *HintCosts `json:"hints,omitempty"`
+}
+
+// Dimensions returns the declared board shape with defaults applied.
+func (r CreateGameRequest) Dimensions() (groupCount, groupSize int) {
+	groupCount, groupSize = r.GroupCount, r.GroupSize
+	if groupCount == 0 {
+		groupCount = DefaultGroupCount
+	}
+	if groupSize == 0 {
+		groupSize = DefaultGroupSize
+	}
+	return groupCount, groupSize
+}
+
+// HintCosts returns the declared hint costs, or the defaults.
+func (r CreateGameRequest) HintCosts() HintCosts {
+	if r.Hints == nil {
+		return DefaultHintCosts
+	}
+	return *r.Hints
+}
+
+// ValidationError lists every problem found with a request, so callers can
+// report them all at once rather than one per round trip.
+type ValidationError struct {
+	Problems []string `json:"problems"`
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid game: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+// Validate applies the rules described by the struct tags above. Difficulty
+// and time limit are matched case-insensitively against the database enums.
+func (r CreateGameRequest) Validate() error {
+	verr := &ValidationError{}
+
+	if strings.TrimSpace(r.Author) == "" {
+		verr.add("author is required")
+	}
+	if !oneOf(r.Difficulty, Difficulties) {
+		verr.add("difficulty must be one of %s", strings.Join(Difficulties, ", "))
+	}
+	if !oneOf(r.TimeLimit, TimeLimits) {
+		verr.add("time_limit must be one of %s", strings.Join(TimeLimits, ", "))
+	}
+
+	groupCount, groupSize := r.Dimensions()
+	if groupCount < MinBoardDimension || groupCount > MaxBoardDimension {
+		verr.add("group_count must be between %d and %d", MinBoardDimension, MaxBoardDimension)
+	}
+	if groupSize < MinBoardDimension || groupSize > MaxBoardDimension {
+		verr.add("group_size must be between %d and %d", MinBoardDimension, MaxBoardDimension)
+	}
+	if len(r.Groups) != groupCount {
+		verr.add("must have exactly %d groups, got %d", groupCount, len(r.Groups))
+	}
+
+	tiers := make(map[int]int, len(r.Groups))
+	for i, group := range r.Groups {
+		switch first, seen := tiers[group.Tier]; {
+		case group.Tier < 1 || group.Tier > len(r.Groups):
+			verr.add("group %d: tier must be between 1 and %d", i+1, len(r.Groups))
+		case seen:
+			verr.add("group %d: tier %d is already used by group %d", i+1, group.Tier, first)
+		default:
+			tiers[group.Tier] = i + 1
+		}
+		if strings.TrimSpace(group.Link) == "" {
+			verr.add("group %d: link is required", i+1)
+		}
+		if len(group.LinkTerms) == 0 {
+			verr.add("group %d: at least one link term is required", i+1)
+		}
+		if len(group.Tiles) != groupSize {
+			verr.add("group %d: must have exactly %d tiles, got %d", i+1, groupSize, len(group.Tiles))
+		}
+		for j, tile := range group.Tiles {
+			if strings.TrimSpace(tile.Title) == "" {
+				verr.add("group %d tile %d: title is required", i+1, j+1)
+			}
+			if tile.Decoy == nil {
+				continue
+			}
+			switch {
+			case tile.Decoy.Tier < 1 || tile.Decoy.Tier > len(r.Groups):
+				verr.add("group %d tile %d: decoy tier must be between 1 and %d", i+1, j+1, len(r.Groups))
+			case tile.Decoy.Tier == group.Tier:
+				verr.add("group %d tile %d: decoy tier must be another group's tier", i+1, j+1)
+			}
+		}
+	}
+
+	validateTags(verr, r.Tags)
+	if r.Hints != nil {
+		validateHintCosts(verr, *r.Hints)
+	}
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func validateHintCosts(verr *ValidationError, costs HintCosts) {
+	limit := MaxHintPoints
+	switch {
+	case strings.EqualFold(costs.Penalty, HintPenaltyMistake):
+		limit = MaxHintMistakes
+	case !strings.EqualFold(costs.Penalty, HintPenaltyScore):
+		verr.add("hints: penalty must be one of %s", strings.Join(HintPenalties, ", "))
+	}
+	for _, cost := range []struct {
+		kind  string
+		value int
+	}{{"tile", costs.Tile}, {"initial", costs.Initial}, {"link", costs.Link}} {
+		if cost.value < 0 || cost.value > limit {
+			verr.add("hints: %s cost must be between 0 and %d", cost.kind, limit)
+		}
+	}
+}
+
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return true
+		}
+	}
+	return false
 }
 
 type GameResponse struct {
@@ -25,12 +219,32 @@ type GameResponse struct {
 	CreatedAt  string `json:"created_at"`
 }
 
+// BoardTile is a tile as players see it. The token stands in for the tile's
+// ID, which would give groups away, and is all a guess needs.
+type BoardTile struct {
+	Token string `json:"token"`
+	Title string `json:"title"`
+}
+
+type GameTilesResponse struct {
+	GameID     int64       `json:"game_id"`
+	GroupCount int         `json:"group_count"`
+	GroupSize  int         `json:"group_size"`
+	Tiles      []BoardTile `json:"tiles"`
+}
+
+type DailyPuzzleResponse struct {
+	Date string `json:"date"`
+	GameTilesResponse
+}
+
 type CheckTilesRequest struct {
-	GameID  int64   `json:"game_id" validate:"required,gt=0"`
-	TileIDs []int64 `json:"tile_ids" validate:"required,len=4"`
+	GameID int64    `json:"game_id" validate:"required,gt=0"`
+	Tiles  []string `json:"tiles" validate:"required"`
 }
 
 type CheckTilesResponse struct {
 	Correct  bool   `json:"correct"`
 	LinkText string `json:"link_text,omitempty"`
+	Tier     int    `json:"tier,omitempty"`
 }
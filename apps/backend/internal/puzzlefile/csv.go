@@ -0,0 +1,128 @@
+package puzzlefile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var csvHeader = []string{"puzzle", "author", "difficulty", "time_limit", "link", "link_terms"}
+
+const linkTermSeparator = ";"
+
+func decodeCSV(r io.Reader) ([]Puzzle, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if len(header) <= len(csvHeader) {
+		return nil, fmt.Errorf("header must be %s followed by tile columns", strings.Join(csvHeader, ","))
+	}
+	for i, name := range csvHeader {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), name) {
+			return nil, fmt.Errorf("header column %d must be %q, got %q", i+1, name, header[i])
+		}
+	}
+
+	var puzzles []Puzzle
+	lastKey := ""
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < len(csvHeader) {
+			return nil, fmt.Errorf("line %d: expected at least %d columns, got %d", line, len(csvHeader), len(record))
+		}
+
+		key := strings.TrimSpace(record[0])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: puzzle key is required", line)
+		}
+		if len(puzzles) == 0 || key != lastKey {
+			puzzles = append(puzzles, Puzzle{
+				Author:     record[1],
+				Difficulty: record[2],
+				TimeLimit:  record[3],
+			})
+			lastKey = key
+		}
+
+		group := Group{Link: record[4]}
+		for _, term := range strings.Split(record[5], linkTermSeparator) {
+			if term = strings.TrimSpace(term); term != "" {
+				group.LinkTerms = append(group.LinkTerms, term)
+			}
+		}
+		for _, title := range record[len(csvHeader):] {
+			if title != "" {
+				group.Tiles = append(group.Tiles, title)
+			}
+		}
+
+		current := &puzzles[len(puzzles)-1]
+		current.Groups = append(current.Groups, group)
+	}
+
+	// CSV has no columns for the board shape, so it is taken from the rows.
+	for i := range puzzles {
+		puzzles[i].GroupCount = len(puzzles[i].Groups)
+		puzzles[i].GroupSize = len(puzzles[i].Groups[0].Tiles)
+	}
+
+	return puzzles, nil
+}
+
+// encodeCSV writes groups in tier order, as CSV has no tier column.
+func encodeCSV(w io.Writer, puzzles []Puzzle) error {
+	maxTiles := 0
+	for _, p := range puzzles {
+		for _, g := range p.Groups {
+			maxTiles = max(maxTiles, len(g.Tiles))
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	header := append([]string{}, csvHeader...)
+	for i := 0; i < maxTiles; i++ {
+		header = append(header, "tile")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for i, p := range puzzles {
+		groups := slices.Clone(p.Groups)
+		slices.SortStableFunc(groups, func(a, b Group) int { return a.Tier - b.Tier })
+		for _, g := range groups {
+			record := []string{
+				strconv.Itoa(i + 1),
+				p.Author,
+				p.Difficulty,
+				p.TimeLimit,
+				g.Link,
+				strings.Join(g.LinkTerms, linkTermSeparator),
+			}
+			record = append(record, g.Tiles...)
+			for len(record) < len(header) {
+				record = append(record, "")
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,52 @@
+// Package puzzlefile reads and writes puzzles in a portable file format, so
+// they can be drafted offline and moved between instances.
+//
+// The canonical encoding is versioned JSON. A document holds any number of
+// puzzles:
+//
+//	{
+//	  "format": "puzzle",
+//	  "version": 1,
+//	  "puzzles": [
+//	    {
+//	      "author": "Luke",
+//	      "difficulty": "easy",
+//	      "time_limit": "unlimited",
+//	      "group_count": 4,
+//	      "group_size": 4,
+//	      "groups": [
+//	        {
+//	          "link": "Rivers",
+//	          "link_terms": ["river", "rivers"],
+//	          "tier": 1,
+//	          "tiles": ["Thames", "Severn", "Trent", "Wye"],
+//	          "decoys": [
+//	            {"tile": "Severn", "tier": 3, "reason": "sounds like seven"}
+//	          ]
+//	        }
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// "format" must be "puzzle" and "version" must be no newer than
+// CurrentVersion. Fields within a puzzle follow the same rules as
+// models.CreateGameRequest: "group_count" and "group_size" default to 4, and
+// "tier" may be left out, in which case groups are tiered in the order they
+// are listed. "decoys" is optional and marks tiles, by title, as red herrings
+// that seem to belong to another tier.
+//
+// YAML documents use exactly the same keys and structure.
+//
+// CSV is a flat alternative for spreadsheets. The first row is a header and
+// every following row describes one group:
+//
+//	puzzle,author,difficulty,time_limit,link,link_terms,tile,tile,tile,tile
+//	1,Luke,easy,unlimited,Rivers,river;rivers,Thames,Severn,Trent,Wye
+//
+// Consecutive rows with the same "puzzle" key belong to the same puzzle; the
+// author, difficulty and time limit are taken from its first row, and its
+// board shape from the number of rows and tiles. Groups are listed from
+// easiest to hardest tier. Link terms are separated by semicolons and every
+// column from "tile" onwards is a tile. Decoys can't be expressed in CSV.
+package puzzlefile
@@ -0,0 +1,219 @@
+package puzzlefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatName     = "puzzle"
+	CurrentVersion = 1
+)
+
+type Encoding string
+
+const (
+	JSON Encoding = "json"
+	YAML Encoding = "yaml"
+	CSV  Encoding = "csv"
+)
+
+type Document struct {
+	Format  string   `json:"format" yaml:"format"`
+	Version int      `json:"version" yaml:"version"`
+	Puzzles []Puzzle `json:"puzzles" yaml:"puzzles"`
+}
+
+type Puzzle struct {
+	Author     string  `json:"author" yaml:"author"`
+	Difficulty string  `json:"difficulty" yaml:"difficulty"`
+	TimeLimit  string  `json:"time_limit" yaml:"time_limit"`
+	GroupCount int     `json:"group_count,omitempty" yaml:"group_count,omitempty"`
+	GroupSize  int     `json:"group_size,omitempty" yaml:"group_size,omitempty"`
+	Groups     []Group `json:"groups" yaml:"groups"`
+	// Hints leaves the default hint costs in place when absent.
+	Hints *models.HintCosts `json:"hints,omitempty" yaml:"hints,omitempty"`
+}
+
+type Group struct {
+	Link      string   `json:"link" yaml:"link"`
+	LinkTerms []string `json:"link_terms" yaml:"link_terms"`
+	Tier      int      `json:"tier,omitempty" yaml:"tier,omitempty"`
+	Tiles     []string `json:"tiles" yaml:"tiles"`
+	Decoys    []Decoy  `json:"decoys,omitempty" yaml:"decoys,omitempty"`
+}
+
+// Decoy annotates one of a group's tiles as seeming to fit another tier.
+type Decoy struct {
+	Tile   string `json:"tile" yaml:"tile"`
+	Tier   int    `json:"tier" yaml:"tier"`
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// NewDocument wraps puzzles in a document stamped with the current version.
+func NewDocument(puzzles ...Puzzle) *Document {
+	return &Document{
+		Format:  FormatName,
+		Version: CurrentVersion,
+		Puzzles: puzzles,
+	}
+}
+
+// ParseEncoding accepts an encoding name or a MIME type.
+func ParseEncoding(name string) (Encoding, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.Index(name, ";"); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+
+	switch name {
+	case "", "json", "application/json":
+		return JSON, nil
+	case "yaml", "yml", "application/yaml", "application/x-yaml", "text/yaml":
+		return YAML, nil
+	case "csv", "text/csv":
+		return CSV, nil
+	default:
+		return "", fmt.Errorf("unsupported puzzle file format %q", name)
+	}
+}
+
+// EncodingForPath picks an encoding from a file extension.
+func EncodingForPath(path string) (Encoding, error) {
+	return ParseEncoding(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// ContentType is the MIME type to serve an encoding with.
+func (e Encoding) ContentType() string {
+	switch e {
+	case YAML:
+		return "application/yaml"
+	case CSV:
+		return "text/csv"
+	default:
+		return "application/json"
+	}
+}
+
+func Decode(r io.Reader, enc Encoding) (*Document, error) {
+	var doc Document
+	switch enc {
+	case JSON:
+		if err := json.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("invalid JSON puzzle file: %w", err)
+		}
+	case YAML:
+		if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("invalid YAML puzzle file: %w", err)
+		}
+	case CSV:
+		puzzles, err := decodeCSV(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV puzzle file: %w", err)
+		}
+		return NewDocument(puzzles...), nil
+	default:
+		return nil, fmt.Errorf("unsupported puzzle file format %q", enc)
+	}
+
+	if doc.Format != FormatName {
+		return nil, fmt.Errorf("not a puzzle file: format is %q", doc.Format)
+	}
+	if doc.Version < 1 || doc.Version > CurrentVersion {
+		return nil, fmt.Errorf("unsupported puzzle file version %d", doc.Version)
+	}
+
+	return &doc, nil
+}
+
+func Encode(w io.Writer, enc Encoding, doc *Document) error {
+	switch enc {
+	case JSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(doc)
+	case YAML:
+		e := yaml.NewEncoder(w)
+		e.SetIndent(2)
+		if err := e.Encode(doc); err != nil {
+			return err
+		}
+		return e.Close()
+	case CSV:
+		return encodeCSV(w, doc.Puzzles)
+	default:
+		return fmt.Errorf("unsupported puzzle file format %q", enc)
+	}
+}
+
+// Request converts a puzzle into the shape accepted by GameService.CreateGame.
+// Groups without a tier take it from their position in the puzzle.
+func (p Puzzle) Request() models.CreateGameRequest {
+	req := models.CreateGameRequest{
+		Author:     p.Author,
+		Difficulty: p.Difficulty,
+		TimeLimit:  p.TimeLimit,
+		GroupCount: p.GroupCount,
+		GroupSize:  p.GroupSize,
+		Hints:      p.Hints,
+	}
+	for i, g := range p.Groups {
+		group := models.Group{
+			Link:      g.Link,
+			LinkTerms: g.LinkTerms,
+			Tier:      g.Tier,
+		}
+		if group.Tier == 0 {
+			group.Tier = i + 1
+		}
+		for _, title := range g.Tiles {
+			tile := models.Tile{Title: title}
+			for _, d := range g.Decoys {
+				if d.Tile == title {
+					tile.Decoy = &models.Decoy{Tier: d.Tier, Reason: d.Reason}
+				}
+			}
+			group.Tiles = append(group.Tiles, tile)
+		}
+		req.Groups = append(req.Groups, group)
+	}
+	return req
+}
+
+// FromRequest is the inverse of Puzzle.Request. Tile IDs are dropped, as
+// they are meaningless outside the database they came from.
+func FromRequest(req models.CreateGameRequest) Puzzle {
+	p := Puzzle{
+		Author:     req.Author,
+		Difficulty: req.Difficulty,
+		TimeLimit:  req.TimeLimit,
+		GroupCount: req.GroupCount,
+		GroupSize:  req.GroupSize,
+		Hints:      req.Hints,
+	}
+	for _, g := range req.Groups {
+		group := Group{
+			Link:      g.Link,
+			LinkTerms: g.LinkTerms,
+			Tier:      g.Tier,
+		}
+		for _, tile := range g.Tiles {
+			group.Tiles = append(group.Tiles, tile.Title)
+			if tile.Decoy != nil {
+				group.Decoys = append(group.Decoys, Decoy{
+					Tile:   tile.Title,
+					Tier:   tile.Decoy.Tier,
+					Reason: tile.Decoy.Reason,
+				})
+			}
+		}
+		p.Groups = append(p.Groups, group)
+	}
+	return p
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+var RatingFeedback = []string{"too_easy", "too_hard", "unfair"}
+
+const (
+	MinStars         = 1
+	MaxStars         = 5
+	MaxCommentLength = 1000
+)
+
+type RatingRequest struct {
+	Stars int `json:"stars" validate:"required,min=1,max=5"`
+	// Feedback optionally says how the difficulty was pitched.
+	Feedback string `json:"feedback,omitempty" validate:"omitempty,oneof=too_easy too_hard unfair"`
+	Comment  string `json:"comment,omitempty" validate:"max=1000"`
+}
+
+func (r RatingRequest) Validate() error {
+	verr := &ValidationError{}
+
+	if r.Stars < MinStars || r.Stars > MaxStars {
+		verr.add("stars must be between %d and %d", MinStars, MaxStars)
+	}
+	if r.Feedback != "" && !oneOf(r.Feedback, RatingFeedback) {
+		verr.add("feedback must be one of %s", strings.Join(RatingFeedback, ", "))
+	}
+	if utf8.RuneCountInString(r.Comment) > MaxCommentLength {
+		verr.add("comment must be at most %d characters", MaxCommentLength)
+	}
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUsernameTaken = errors.New("that username is taken")
+	ErrInvalidLogin  = errors.New("wrong username or password")
+)
+
+// Account is a registered player as they see themselves. MergedSessions
+// counts the anonymous sessions that signing up or logging in brought along.
+type Account struct {
+	Username       string    `json:"username"`
+	CreatedAt      time.Time `json:"created_at"`
+	MergedSessions int       `json:"merged_sessions"`
+	// PlayerID is what the account plays under.
+	PlayerID string `json:"-"`
+}
+
+type AccountService struct {
+	store GameStore
+	stats *StatsService
+}
+
+func NewAccountService(store GameStore, stats *StatsService) *AccountService {
+	return &AccountService{
+		store: store,
+		stats: stats,
+	}
+}
+
+// Register creates an account and merges in the history of anonymousID, the
+// player who signed up, if they played before.
+func (s *AccountService) Register(ctx context.Context, req models.AccountRequest, anonymousID string) (Account, error) {
+	if err := req.Validate(); err != nil {
+		return Account{}, err
+	}
+
+	username := normalizeUsername(req.Username)
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return Account{}, err
+	}
+	user, err := s.store.CreateUser(ctx, db.CreateUserParams{
+		Username:     username,
+		PasswordHash: string(hash),
+		PlayerID:     newPlayerID(accountPlayerPrefix),
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return Account{}, fmt.Errorf("%w: %s", ErrUsernameTaken, username)
+	}
+	if err != nil {
+		log.Printf("unable to create account %s: %v", username, err)
+		return Account{}, err
+	}
+
+	return s.merge(ctx, user, anonymousID)
+}
+
+// Login checks the account's password and merges in the history of
+// anonymousID, the player logging in, if they played before.
+func (s *AccountService) Login(ctx context.Context, req models.AccountRequest, anonymousID string) (Account, error) {
+	username := normalizeUsername(req.Username)
+	user, err := s.store.GetUserByUsername(ctx, username)
+	if err == sql.ErrNoRows {
+		// Take as long as a wrong password would, so the time taken doesn't
+		// give away which usernames exist
+		bcrypt.CompareHashAndPassword(unusedHash(), []byte(req.Password))
+		return Account{}, ErrInvalidLogin
+	}
+	if err != nil {
+		log.Printf("unable to fetch account %s: %v", username, err)
+		return Account{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		return Account{}, ErrInvalidLogin
+	}
+
+	return s.merge(ctx, user, anonymousID)
+}
+
+// merge hands the anonymous player's sessions to the account and rebuilds
+// the account's stats from its sessions, so streaks run across both. It runs
+// in one transaction, which other merges into the account wait for. Games
+// both have played keep the account's session. The anonymous player's own
+// stats go, so they aren't counted twice.
+func (s *AccountService) merge(ctx context.Context, user db.User, anonymousID string) (Account, error) {
+	account := Account{
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+		PlayerID:  user.PlayerID,
+	}
+	if !IsAnonymousPlayer(anonymousID) {
+		return account, nil
+	}
+
+	err := s.store.InTx(ctx, func(store GameStore) error {
+		if err := store.LockUser(ctx, user.ID); err != nil {
+			log.Printf("unable to lock account %s: %v", user.Username, err)
+			return err
+		}
+		moved, err := store.MoveSessions(ctx, db.MoveSessionsParams{FromPlayer: anonymousID, ToPlayer: user.PlayerID})
+		if err != nil {
+			log.Printf("unable to move sessions of %s to account %s: %v", anonymousID, user.Username, err)
+			return err
+		}
+		account.MergedSessions = int(moved)
+
+		for _, playerID := range []string{anonymousID, user.PlayerID} {
+			if err := store.DeletePlayerStats(ctx, playerID); err != nil {
+				log.Printf("unable to reset stats for player %s: %v", playerID, err)
+				return err
+			}
+		}
+		sessions, err := store.ListFinishedSessionsForPlayer(ctx, user.PlayerID)
+		if err != nil {
+			log.Printf("unable to fetch sessions for account %s: %v", user.Username, err)
+			return err
+		}
+		stats := NewStatsService(store, s.stats.location)
+		for _, session := range sessions {
+			if err := stats.RecordSession(ctx, session); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Account{}, err
+	}
+	return account, nil
+}
+
+// normalizeUsername makes usernames case-insensitive.
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// unusedHash is a hash no password is checked against for real.
+var unusedHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+	return hash
+})
@@ -0,0 +1,170 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestAccounts(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		stats := service.NewStatsService(store, time.UTC)
+		accounts := service.NewAccountService(store, stats)
+		first := testutil.Seed(t, games, store, testutil.NewGame())
+		second := testutil.Seed(t, games, store, testutil.NewGame())
+
+		play := func(playerID string, game testutil.SeededGame, win bool) int64 {
+			t.Helper()
+			session, err := sessions.StartSession(ctx, playerID, game.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range service.MaxMistakes {
+				tiles := game.Groups[i].Titles
+				if !win {
+					tiles = []string{game.Groups[0].Titles[i], game.Groups[1].Titles[0], game.Groups[2].Titles[0], game.Groups[3].Titles[0]}
+				}
+				if _, err := sessions.SubmitGuess(ctx, playerID, session.ID, testutil.Tokens(t, session.Tiles, tiles...)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return session.ID
+		}
+
+		bad := []models.AccountRequest{
+			{Username: "al", Password: "long enough"},
+			{Username: "alice!", Password: "long enough"},
+			{Username: "alice", Password: "short"},
+		}
+		for _, req := range bad {
+			var verr *models.ValidationError
+			if _, err := accounts.Register(ctx, req, ""); !errors.As(err, &verr) {
+				t.Errorf("registering %+v: got %v, want a ValidationError", req, err)
+			}
+		}
+
+		// Signing up brings the anonymous player's history along
+		anonymous := service.NewAnonymousPlayer()
+		firstSession := play(anonymous, first, true)
+		account, err := accounts.Register(ctx, models.AccountRequest{Username: "Alice", Password: "correct horse"}, anonymous)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.Username != "alice" || account.MergedSessions != 1 {
+			t.Errorf("got account %+v, want alice with 1 merged session", account)
+		}
+		if _, err := sessions.GetSession(ctx, account.PlayerID, firstSession); err != nil {
+			t.Errorf("merged session: %v", err)
+		}
+		if _, err := accounts.Register(ctx, models.AccountRequest{Username: "ALICE", Password: "another one"}, ""); !errors.Is(err, service.ErrUsernameTaken) {
+			t.Errorf("registering alice again: got %v, want ErrUsernameTaken", err)
+		}
+
+		// Logging in elsewhere brings that history along too, except for games
+		// the account has already played
+		elsewhere := service.NewAnonymousPlayer()
+		kept := play(elsewhere, first, false)
+		play(elsewhere, second, false)
+		if _, err := accounts.Login(ctx, models.AccountRequest{Username: "alice", Password: "wrong horse"}, elsewhere); !errors.Is(err, service.ErrInvalidLogin) {
+			t.Errorf("wrong password: got %v, want ErrInvalidLogin", err)
+		}
+		if _, err := accounts.Login(ctx, models.AccountRequest{Username: "bob", Password: "correct horse"}, elsewhere); !errors.Is(err, service.ErrInvalidLogin) {
+			t.Errorf("unknown user: got %v, want ErrInvalidLogin", err)
+		}
+		account, err = accounts.Login(ctx, models.AccountRequest{Username: " Alice ", Password: "correct horse"}, elsewhere)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.MergedSessions != 1 {
+			t.Errorf("merged %d sessions, want 1", account.MergedSessions)
+		}
+		if _, err := sessions.GetSession(ctx, elsewhere, kept); err != nil {
+			t.Errorf("session of a game the account played: %v", err)
+		}
+
+		got, err := stats.PlayerStats(ctx, account.PlayerID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Played != 2 || got.Won != 1 || got.MistakeDistribution[0] != 1 {
+			t.Errorf("got stats %+v, want the win and the loss", got)
+		}
+
+		// The anonymous player's stats went with the merge
+		if got, err := stats.PlayerStats(ctx, elsewhere); err != nil || got.Played != 0 {
+			t.Errorf("anonymous stats after merging: got %+v, %v, want none", got, err)
+		}
+
+		// Only anonymous players are merged
+		other, err := accounts.Register(ctx, models.AccountRequest{Username: "bob", Password: "correct horse"}, account.PlayerID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.MergedSessions != 0 {
+			t.Errorf("bob took %d of alice's sessions", other.MergedSessions)
+		}
+	})
+}
+
+func TestConcurrentMerges(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		stats := service.NewStatsService(store, time.UTC)
+		accounts := service.NewAccountService(store, stats)
+		login := models.AccountRequest{Username: "alice", Password: "correct horse"}
+		if _, err := accounts.Register(ctx, login, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		// Logging in on several devices at once counts each game once
+		const devices, played = 5, 4
+		players := make([]string, devices)
+		for i := range players {
+			players[i] = service.NewAnonymousPlayer()
+			for range played {
+				game := testutil.Seed(t, games, store, testutil.NewGame())
+				session, err := sessions.StartSession(ctx, players[i], game.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				solveAll(t, sessions, players[i], session, game, 0)
+			}
+		}
+		var wg sync.WaitGroup
+		accountIDs := make([]string, devices)
+		errs := make([]error, devices)
+		for i, player := range players {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				account, err := accounts.Login(ctx, login, player)
+				accountIDs[i], errs[i] = account.PlayerID, err
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := stats.PlayerStats(ctx, accountIDs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Played != devices*played || got.Won != devices*played {
+			t.Errorf("got stats %+v, want %d games won", got, devices*played)
+		}
+	})
+}
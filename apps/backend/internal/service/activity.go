@@ -0,0 +1,139 @@
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// ActivityBacklog is how many recent activities the feed keeps, for
+	// clients resuming after a dropped connection.
+	ActivityBacklog = 256
+
+	activityEventBuffer = 32
+)
+
+// Kinds of activity on the feed.
+const (
+	// ActivityPublished is a game newly approved by a moderator, or for a
+	// future daily puzzle, going live on its day.
+	ActivityPublished = "published"
+	// ActivitySolved is a player winning a session.
+	ActivitySolved = "solved"
+)
+
+// Activity is something that just happened, for the live feed. Players are
+// shown by their PlayerHandle.
+type Activity struct {
+	ID     int64  `json:"id"`
+	Type   string `json:"type"`
+	GameID int64  `json:"game_id"`
+	Author string `json:"author,omitempty"`
+	Player string `json:"player,omitempty"`
+	// Mistakes is only set on solved activity, where it may well be 0.
+	Mistakes *int      `json:"mistakes,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// ActivityFeed passes activity from the services to everyone subscribed,
+// within this process. It remembers the last ActivityBacklog activities,
+// numbered from 1 in the order they were published, so a subscriber can
+// pick up where it left off.
+type ActivityFeed struct {
+	mu          sync.Mutex
+	closed      bool
+	lastID      int64
+	recent      []Activity
+	subscribers map[*ActivitySubscription]bool
+}
+
+// ActivitySubscription receives activity as it is published.
+type ActivitySubscription struct {
+	// Events is closed when the subscription is closed, falls too far
+	// behind, or the feed closes.
+	Events <-chan Activity
+
+	events chan Activity
+	feed   *ActivityFeed
+}
+
+func NewActivityFeed(backlog int) *ActivityFeed {
+	return &ActivityFeed{
+		recent:      make([]Activity, backlog),
+		subscribers: make(map[*ActivitySubscription]bool),
+	}
+}
+
+// Publish numbers the activity and sends it to every subscriber. It never
+// blocks: a subscriber too far behind is dropped, and can resume from the
+// backlog.
+func (f *ActivityFeed) Publish(activity Activity) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastID++
+	activity.ID = f.lastID
+	if activity.At.IsZero() {
+		activity.At = time.Now()
+	}
+	f.recent[(activity.ID-1)%int64(len(f.recent))] = activity
+
+	for sub := range f.subscribers {
+		select {
+		case sub.events <- activity:
+		default:
+			log.Printf("dropping activity subscriber: too far behind")
+			f.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscribe returns a subscription to activity from now on, along with the
+// remembered activity after lastID. Activity older than the backlog is
+// gone; a lastID the feed hasn't reached, say from before a restart, gets
+// no backlog.
+func (f *ActivityFeed) Subscribe(lastID int64) (*ActivitySubscription, []Activity) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	events := make(chan Activity, activityEventBuffer)
+	sub := &ActivitySubscription{Events: events, events: events, feed: f}
+	if f.closed {
+		close(events)
+		return sub, nil
+	}
+	f.subscribers[sub] = true
+
+	var backlog []Activity
+	for id := max(lastID, f.lastID-int64(len(f.recent)), 0) + 1; id <= f.lastID; id++ {
+		backlog = append(backlog, f.recent[(id-1)%int64(len(f.recent))])
+	}
+	return sub, backlog
+}
+
+// Close ends every subscription, so streams finish when the server shuts
+// down.
+func (f *ActivityFeed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	for sub := range f.subscribers {
+		f.unsubscribe(sub)
+	}
+}
+
+func (s *ActivitySubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.unsubscribe(s)
+}
+
+func (f *ActivityFeed) unsubscribe(sub *ActivitySubscription) {
+	if !f.subscribers[sub] {
+		return
+	}
+	delete(f.subscribers, sub)
+	close(sub.events)
+}
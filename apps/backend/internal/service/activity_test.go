@@ -0,0 +1,186 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestActivityFeed(t *testing.T) {
+	feed := service.NewActivityFeed(4)
+	for gameID := range int64(6) {
+		feed.Publish(service.Activity{Type: service.ActivityPublished, GameID: gameID})
+	}
+
+	tests := []struct {
+		name   string
+		lastID int64
+		want   []int64
+	}{
+		{"fresh", 0, []int64{3, 4, 5, 6}},
+		{"resuming", 4, []int64{5, 6}},
+		{"up to date", 6, nil},
+		{"older than the backlog", 1, []int64{3, 4, 5, 6}},
+		{"ahead of the feed", 100, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, backlog := feed.Subscribe(tt.lastID)
+			defer sub.Close()
+
+			var got []int64
+			for _, activity := range backlog {
+				got = append(got, activity.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got backlog %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got backlog %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	// A subscriber that stops reading is dropped rather than holding up the feed
+	slow, _ := feed.Subscribe(0)
+	for range 100 {
+		feed.Publish(service.Activity{Type: service.ActivityPublished})
+	}
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	if received == 0 || received == 100 {
+		t.Errorf("slow subscriber got %d of 100", received)
+	}
+	slow.Close()
+
+	live, _ := feed.Subscribe(0)
+	feed.Close()
+	if _, ok := <-live.Events; ok {
+		t.Error("subscription still open after the feed closed")
+	}
+}
+
+func TestSessionAnnouncesWins(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewMemoryStore()
+	feed := service.NewActivityFeed(service.ActivityBacklog)
+	games := service.NewGameService(store, time.UTC, service.NewBlocklist(nil), feed)
+	sessions := newSessionService(store, games)
+	game := testutil.Seed(t, games, store, testutil.NewGame())
+
+	sub, _ := feed.Subscribe(0)
+	defer sub.Close()
+
+	session, err := sessions.StartSession(ctx, "alice", game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := testutil.Tokens(t, session.Tiles, game.Groups[0].Titles[0], game.Groups[1].Titles[0], game.Groups[2].Titles[0], game.Groups[3].Titles[0])
+	if _, err := sessions.SubmitGuess(ctx, "alice", session.ID, wrong); err != nil {
+		t.Fatal(err)
+	}
+	for _, group := range game.Groups {
+		if _, err := sessions.SubmitGuess(ctx, "alice", session.ID, testutil.Tokens(t, session.Tiles, group.Titles...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case activity := <-sub.Events:
+		if activity.Type != service.ActivitySolved || activity.GameID != game.ID || activity.Mistakes == nil || *activity.Mistakes != 1 {
+			t.Errorf("got %+v", activity)
+		}
+		if activity.Player != service.PlayerHandle("alice") {
+			t.Errorf("activity shows player %q, want alice's handle", activity.Player)
+		}
+	default:
+		t.Fatal("no activity for the win")
+	}
+
+	// A flawless win still says how many mistakes it took
+	session, err = sessions.StartSession(ctx, "bob", game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solveAll(t, sessions, "bob", session, game, 0)
+	select {
+	case activity := <-sub.Events:
+		encoded, err := json.Marshal(activity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(encoded), `"mistakes":0`) {
+			t.Errorf("got %s, want mistakes 0", encoded)
+		}
+	default:
+		t.Fatal("no activity for the flawless win")
+	}
+}
+
+func TestApproveAnnouncesReleasedGames(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		feed := service.NewActivityFeed(service.ActivityBacklog)
+		games := service.NewGameService(store, time.UTC, service.NewBlocklist(nil), feed)
+		moderation := service.NewModerationService(store, games)
+		tomorrow := games.Today().AddDate(0, 0, 1)
+
+		sub, _ := feed.Subscribe(0)
+		defer sub.Close()
+		announced := func() []int64 {
+			var ids []int64
+			for {
+				select {
+				case activity := <-sub.Events:
+					ids = append(ids, activity.GameID)
+				default:
+					return ids
+				}
+			}
+		}
+
+		pending, err := games.CreateGame(ctx, testutil.NewGame().Request())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := moderation.Approve(ctx, pending); err != nil {
+			t.Fatal(err)
+		}
+		if got := announced(); len(got) != 1 || got[0] != pending {
+			t.Errorf("approving: announced %v, want game %d", got, pending)
+		}
+
+		// A future daily taken down and approved again waits for its day
+		daily := testutil.Seed(t, games, store, testutil.NewGame())
+		if _, err := games.ScheduleDaily(ctx, tomorrow, daily.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := moderation.Flag(ctx, daily.ID, "typo"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := moderation.Approve(ctx, daily.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := games.AnnounceDaily(ctx, games.Today()); err != nil {
+			t.Fatal(err)
+		}
+		if got := announced(); len(got) != 0 {
+			t.Errorf("before its day: announced %v, want nothing", got)
+		}
+		if err := games.AnnounceDaily(ctx, tomorrow); err != nil {
+			t.Fatal(err)
+		}
+		if got := announced(); len(got) != 1 || got[0] != daily.ID {
+			t.Errorf("on its day: announced %v, want game %d", got, daily.ID)
+		}
+	})
+}
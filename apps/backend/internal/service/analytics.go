@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+const (
+	wrongPairingsLimit = 10
+	incorrectSetsLimit = 10
+	// AnalyticsRefreshInterval is how stale the materialized outcome and
+	// solve order figures are allowed to get.
+	AnalyticsRefreshInterval = 5 * time.Minute
+)
+
+type AnalyticsService struct {
+	store GameStore
+}
+
+func NewAnalyticsService(store GameStore) *AnalyticsService {
+	return &AnalyticsService{
+		store: store,
+	}
+}
+
+type GameAnalytics struct {
+	GameID int64 `json:"game_id"`
+	// Finished counts won and lost sessions; abandoned ones are left out.
+	Finished        int64   `json:"finished"`
+	SolveRate       float64 `json:"solve_rate"`
+	AverageMistakes float64 `json:"average_mistakes"`
+	// SolveOrder is ordered by tier, and says how often each group was the
+	// first, second, ... group to be solved.
+	SolveOrder    []GroupSolveOrder `json:"solve_order"`
+	IncorrectSets []IncorrectSet    `json:"incorrect_sets"`
+	// WrongPairings are the pairs of tiles from different groups most often
+	// guessed together.
+	WrongPairings []WrongPairing `json:"wrong_pairings"`
+}
+
+type GroupSolveOrder struct {
+	GroupID int64  `json:"group_id"`
+	Link    string `json:"link"`
+	Tier    int    `json:"tier"`
+	// Positions[i] is the number of times the group was solved (i+1)th.
+	Positions []int64 `json:"positions"`
+}
+
+type IncorrectSet struct {
+	TileIDs []int64  `json:"tile_ids"`
+	Titles  []string `json:"titles"`
+	Times   int64    `json:"times"`
+}
+
+type WrongPairing struct {
+	TileID          int64  `json:"tile_id"`
+	TileTitle       string `json:"tile_title"`
+	PairedTileID    int64  `json:"paired_tile_id"`
+	PairedTileTitle string `json:"paired_tile_title"`
+	Times           int64  `json:"times"`
+	// DecoyReason is the author's explanation when one of the tiles was
+	// planted as a red herring for the other's group.
+	DecoyReason string `json:"decoy_reason,omitempty"`
+}
+
+func (s *AnalyticsService) GameAnalytics(ctx context.Context, gameID int64) (GameAnalytics, error) {
+	if _, err := s.store.GetGame(ctx, gameID); err != nil {
+		if err == sql.ErrNoRows {
+			return GameAnalytics{}, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
+		}
+		return GameAnalytics{}, err
+	}
+
+	analytics := GameAnalytics{GameID: gameID}
+
+	outcomes, err := s.store.GetGameOutcomes(ctx, gameID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("unable to fetch outcomes for game %d: %v", gameID, err)
+		return GameAnalytics{}, err
+	}
+	analytics.Finished = outcomes.Finished
+	analytics.AverageMistakes = outcomes.AverageMistakes
+	if outcomes.Finished > 0 {
+		analytics.SolveRate = float64(outcomes.Won) / float64(outcomes.Finished)
+	}
+
+	if analytics.SolveOrder, err = s.solveOrder(ctx, gameID); err != nil {
+		return GameAnalytics{}, err
+	}
+	if analytics.IncorrectSets, err = s.incorrectSets(ctx, gameID); err != nil {
+		return GameAnalytics{}, err
+	}
+	if analytics.WrongPairings, err = s.wrongPairings(ctx, gameID); err != nil {
+		return GameAnalytics{}, err
+	}
+
+	return analytics, nil
+}
+
+func (s *AnalyticsService) solveOrder(ctx context.Context, gameID int64) ([]GroupSolveOrder, error) {
+	groups, err := s.store.ListGroupsForGame(ctx, gameID)
+	if err != nil {
+		log.Printf("unable to fetch groups for game %d: %v", gameID, err)
+		return nil, err
+	}
+	rows, err := s.store.ListGroupSolvePositions(ctx, gameID)
+	if err != nil {
+		log.Printf("unable to fetch solve order for game %d: %v", gameID, err)
+		return nil, err
+	}
+
+	order := make([]GroupSolveOrder, len(groups))
+	byGroup := make(map[int64]*GroupSolveOrder, len(groups))
+	for i, group := range groups {
+		order[i] = GroupSolveOrder{
+			GroupID:   group.ID,
+			Link:      group.Link,
+			Tier:      int(group.Tier),
+			Positions: make([]int64, len(groups)),
+		}
+		byGroup[group.ID] = &order[i]
+	}
+	for _, row := range rows {
+		group, ok := byGroup[row.GroupID]
+		if !ok || row.Position < 1 || int(row.Position) > len(group.Positions) {
+			continue
+		}
+		group.Positions[row.Position-1] = row.Times
+	}
+
+	return order, nil
+}
+
+func (s *AnalyticsService) incorrectSets(ctx context.Context, gameID int64) ([]IncorrectSet, error) {
+	tiles, err := s.store.ListTilesForGame(ctx, gameID)
+	if err != nil {
+		log.Printf("unable to fetch tiles for game %d: %v", gameID, err)
+		return nil, err
+	}
+	titles := make(map[int64]string, len(tiles))
+	for _, tile := range tiles {
+		titles[tile.ID] = tile.Title
+	}
+
+	rows, err := s.store.ListIncorrectSets(ctx, db.ListIncorrectSetsParams{
+		GameID:   gameID,
+		RowLimit: incorrectSetsLimit,
+	})
+	if err != nil {
+		log.Printf("unable to fetch incorrect sets for game %d: %v", gameID, err)
+		return nil, err
+	}
+
+	sets := make([]IncorrectSet, 0, len(rows))
+	for _, row := range rows {
+		set := IncorrectSet{TileIDs: row.TileIds, Times: row.Times}
+		for _, id := range row.TileIds {
+			set.Titles = append(set.Titles, titles[id])
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+func (s *AnalyticsService) wrongPairings(ctx context.Context, gameID int64) ([]WrongPairing, error) {
+	rows, err := s.store.ListWrongPairings(ctx, db.ListWrongPairingsParams{
+		GameID:   gameID,
+		RowLimit: wrongPairingsLimit,
+	})
+	if err != nil {
+		log.Printf("unable to fetch wrong pairings for game %d: %v", gameID, err)
+		return nil, err
+	}
+
+	pairings := make([]WrongPairing, 0, len(rows))
+	for _, row := range rows {
+		pairings = append(pairings, WrongPairing{
+			TileID:          row.TileID,
+			TileTitle:       row.TileTitle,
+			PairedTileID:    row.PairedTileID,
+			PairedTileTitle: row.PairedTileTitle,
+			Times:           row.Times,
+			DecoyReason:     row.DecoyReason,
+		})
+	}
+	return pairings, nil
+}
+
+// Refresh recomputes the materialized views behind the outcome and solve
+// order figures. They are refreshed concurrently, so reads aren't blocked.
+func (s *AnalyticsService) Refresh(ctx context.Context) error {
+	if err := s.store.RefreshGameOutcomes(ctx); err != nil {
+		log.Printf("unable to refresh game outcomes: %v", err)
+		return err
+	}
+	if err := s.store.RefreshGroupSolvePositions(ctx); err != nil {
+		log.Printf("unable to refresh group solve positions: %v", err)
+		return err
+	}
+	return nil
+}
+
+// RefreshEvery refreshes the analytics views on a ticker until ctx is done.
+func (s *AnalyticsService) RefreshEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh(ctx)
+		}
+	}
+}
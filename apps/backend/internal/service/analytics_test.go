@@ -0,0 +1,79 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestGameAnalytics(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		analytics := service.NewAnalyticsService(store)
+		game := testutil.Seed(t, games, store, testutil.NewGame())
+
+		if _, err := analytics.GameAnalytics(ctx, game.ID+1000); !errors.Is(err, service.ErrGameNotFound) {
+			t.Errorf("unknown game: got %v, want ErrGameNotFound", err)
+		}
+
+		// Alice wins after one wrong guess and Bob loses, both starting with
+		// the same wrong guess
+		session, err := sessions.StartSession(ctx, "alice", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		solveAll(t, sessions, "alice", session, game, 1)
+		session, err = sessions.StartSession(ctx, "bob", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lose(t, sessions, "bob", session, game)
+		if _, err := sessions.StartSession(ctx, "carol", game.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := analytics.Refresh(ctx); err != nil {
+			t.Fatal(err)
+		}
+		got, err := analytics.GameAnalytics(ctx, game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Finished != 2 || got.SolveRate != 0.5 || got.AverageMistakes != 2.5 {
+			t.Errorf("got %d finished, solve rate %v, %v mistakes, want 2, 0.5, 2.5", got.Finished, got.SolveRate, got.AverageMistakes)
+		}
+
+		if len(got.SolveOrder) != 4 {
+			t.Fatalf("got solve order %+v, want all four groups", got.SolveOrder)
+		}
+		for i, group := range got.SolveOrder {
+			want := make([]int64, 4)
+			want[i] = 1
+			if group.Tier != i+1 || group.Link != game.Groups[i].Link || !slices.Equal(group.Positions, want) {
+				t.Errorf("got %+v for tier %d, want it solved %dth once", group, i+1, i+1)
+			}
+		}
+
+		first := wrongSet(game, 0)
+		if len(got.IncorrectSets) != service.MaxMistakes || got.IncorrectSets[0].Times != 2 {
+			t.Fatalf("got incorrect sets %+v, want %v twice first", got.IncorrectSets, first)
+		}
+		if titles := got.IncorrectSets[0].Titles; len(titles) != 4 || !slices.Contains(titles, first[0]) {
+			t.Errorf("got most common incorrect set %q, want %q", titles, first)
+		}
+
+		// Every wrong guess paired the first tiles of groups 2 to 4
+		if len(got.WrongPairings) == 0 || got.WrongPairings[0].Times != 5 {
+			t.Fatalf("got wrong pairings %+v, want a pairing made 5 times first", got.WrongPairings)
+		}
+		if pair := got.WrongPairings[0]; !slices.Contains(first[1:], pair.TileTitle) || !slices.Contains(first[1:], pair.PairedTileTitle) {
+			t.Errorf("got most common pairing %+v, want two of %q", pair, first[1:])
+		}
+	})
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+var ErrCollectionNotFound = errors.New("collection not found")
+
+type CollectionService struct {
+	store GameStore
+	games *GameService
+}
+
+func NewCollectionService(store GameStore, games *GameService) *CollectionService {
+	return &CollectionService{
+		store: store,
+		games: games,
+	}
+}
+
+type CollectionSummary struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Games       int64     `json:"games"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Collection struct {
+	ID          int64            `json:"id"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Games       []CollectionGame `json:"games"`
+	// Progress is only filled in when the player identifies themselves.
+	Progress *CollectionProgress `json:"progress,omitempty"`
+}
+
+type CollectionGame struct {
+	ID         int64              `json:"id"`
+	Author     string             `json:"author"`
+	Difficulty db.DifficultyLevel `json:"difficulty_level"`
+	// Status is the player's session status, empty if they haven't started.
+	Status string `json:"status,omitempty"`
+}
+
+type CollectionProgress struct {
+	Started  int `json:"started"`
+	Finished int `json:"finished"`
+	Won      int `json:"won"`
+	Total    int `json:"total"`
+}
+
+func (s *CollectionService) ListCollections(ctx context.Context) ([]CollectionSummary, error) {
+	rows, err := s.store.ListCollections(ctx)
+	if err != nil {
+		log.Printf("unable to fetch collections: %v", err)
+		return nil, err
+	}
+
+	collections := make([]CollectionSummary, 0, len(rows))
+	for _, row := range rows {
+		collections = append(collections, CollectionSummary{
+			ID:          row.ID,
+			Title:       row.Title,
+			Description: row.Description,
+			Games:       row.Games,
+			CreatedAt:   row.CreatedAt,
+		})
+	}
+	return collections, nil
+}
+
+// Collection returns a collection's released games in order. When playerID
+// is given, each game carries the player's status and the collection their
+// overall progress.
+func (s *CollectionService) Collection(ctx context.Context, collectionID int64, playerID string) (Collection, error) {
+	collection, err := s.store.GetCollection(ctx, collectionID)
+	if err == sql.ErrNoRows {
+		return Collection{}, fmt.Errorf("%w: %d", ErrCollectionNotFound, collectionID)
+	}
+	if err != nil {
+		log.Printf("unable to fetch collection %d: %v", collectionID, err)
+		return Collection{}, err
+	}
+
+	rows, err := s.store.ListCollectionGames(ctx, db.ListCollectionGamesParams{
+		CollectionID: collectionID,
+		PlayerID:     playerID,
+		Today:        s.games.Today(),
+	})
+	if err != nil {
+		log.Printf("unable to fetch games for collection %d: %v", collectionID, err)
+		return Collection{}, err
+	}
+
+	result := Collection{
+		ID:          collection.ID,
+		Title:       collection.Title,
+		Description: collection.Description,
+		Games:       make([]CollectionGame, 0, len(rows)),
+	}
+	if playerID != "" {
+		result.Progress = &CollectionProgress{Total: len(rows)}
+	}
+	for _, row := range rows {
+		result.Games = append(result.Games, CollectionGame{
+			ID:         row.ID,
+			Author:     row.Author,
+			Difficulty: row.Difficulty,
+			Status:     row.Status,
+		})
+		if result.Progress == nil || row.Status == "" {
+			continue
+		}
+		result.Progress.Started++
+		if db.SessionStatus(row.Status) != db.SessionStatusInProgress {
+			result.Progress.Finished++
+		}
+		if db.SessionStatus(row.Status) == db.SessionStatusWon {
+			result.Progress.Won++
+		}
+	}
+
+	return result, nil
+}
+
+func (s *CollectionService) CreateCollection(ctx context.Context, req models.CollectionRequest) (int64, error) {
+	if err := s.validate(ctx, req); err != nil {
+		return 0, err
+	}
+
+	collection, err := s.store.CreateCollection(ctx, db.CreateCollectionParams{
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+	})
+	if err != nil {
+		log.Printf("unable to create collection: %v", err)
+		return 0, err
+	}
+
+	if err := s.setGames(ctx, collection.ID, req.GameIDs); err != nil {
+		return 0, err
+	}
+	return collection.ID, nil
+}
+
+// UpdateCollection replaces a collection's details and its list of games.
+func (s *CollectionService) UpdateCollection(ctx context.Context, collectionID int64, req models.CollectionRequest) error {
+	if err := s.validate(ctx, req); err != nil {
+		return err
+	}
+
+	_, err := s.store.UpdateCollection(ctx, db.UpdateCollectionParams{
+		ID:          collectionID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+	})
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", ErrCollectionNotFound, collectionID)
+	}
+	if err != nil {
+		log.Printf("unable to update collection %d: %v", collectionID, err)
+		return err
+	}
+
+	if err := s.store.ClearCollectionGames(ctx, collectionID); err != nil {
+		log.Printf("unable to clear games for collection %d: %v", collectionID, err)
+		return err
+	}
+	return s.setGames(ctx, collectionID, req.GameIDs)
+}
+
+func (s *CollectionService) DeleteCollection(ctx context.Context, collectionID int64) error {
+	rows, err := s.store.DeleteCollection(ctx, collectionID)
+	if err != nil {
+		log.Printf("unable to delete collection %d: %v", collectionID, err)
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %d", ErrCollectionNotFound, collectionID)
+	}
+	return nil
+}
+
+// validate checks the request, and that every game in it exists.
+func (s *CollectionService) validate(ctx context.Context, req models.CollectionRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	verr := &models.ValidationError{}
+	for i, gameID := range req.GameIDs {
+		_, err := s.store.GetGame(ctx, gameID)
+		if err == sql.ErrNoRows {
+			verr.Problems = append(verr.Problems, fmt.Sprintf("game %d: game %d does not exist", i+1, gameID))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func (s *CollectionService) setGames(ctx context.Context, collectionID int64, gameIDs []int64) error {
+	if len(gameIDs) == 0 {
+		return nil
+	}
+	err := s.store.AddCollectionGames(ctx, db.AddCollectionGamesParams{
+		CollectionID: collectionID,
+		GameIds:      gameIDs,
+	})
+	if err != nil {
+		log.Printf("unable to add games to collection %d: %v", collectionID, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestCollections(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		collections := service.NewCollectionService(store, games)
+		first := testutil.Seed(t, games, store, testutil.NewGame())
+		second := testutil.Seed(t, games, store, testutil.NewGame())
+		pending, err := games.CreateGame(ctx, testutil.NewGame().Request())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var verr *models.ValidationError
+		if _, err := collections.CreateCollection(ctx, models.CollectionRequest{Title: "Starters", GameIDs: []int64{first.ID + 1000}}); !errors.As(err, &verr) {
+			t.Errorf("unknown game: got %v, want a ValidationError", err)
+		}
+		id, err := collections.CreateCollection(ctx, models.CollectionRequest{Title: " Starters ", GameIDs: []int64{second.ID, pending, first.ID}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Unpublished games are left out, and progress needs a player
+		collection, err := collections.Collection(ctx, id, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if collection.Title != "Starters" || len(collection.Games) != 2 || collection.Games[0].ID != second.ID || collection.Games[1].ID != first.ID || collection.Progress != nil {
+			t.Errorf("got %+v, want the two published games in order", collection)
+		}
+
+		session, err := sessions.StartSession(ctx, "alice", first.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		solveAll(t, sessions, "alice", session, first, 0)
+		if _, err := sessions.StartSession(ctx, "alice", second.ID); err != nil {
+			t.Fatal(err)
+		}
+		collection, err = collections.Collection(ctx, id, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := service.CollectionProgress{Started: 2, Finished: 1, Won: 1, Total: 2}
+		if collection.Progress == nil || *collection.Progress != want {
+			t.Errorf("got progress %+v, want %+v", collection.Progress, want)
+		}
+		if collection.Games[0].Status != string(db.SessionStatusInProgress) || collection.Games[1].Status != string(db.SessionStatusWon) {
+			t.Errorf("got games %+v, want one in progress and one won", collection.Games)
+		}
+
+		if err := collections.UpdateCollection(ctx, id, models.CollectionRequest{Title: "Favourites", GameIDs: []int64{first.ID}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := collections.UpdateCollection(ctx, id+1000, models.CollectionRequest{Title: "Favourites"}); !errors.Is(err, service.ErrCollectionNotFound) {
+			t.Errorf("updating an unknown collection: got %v, want ErrCollectionNotFound", err)
+		}
+		list, err := collections.ListCollections(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].Title != "Favourites" || list[0].Games != 1 {
+			t.Errorf("got %+v, want the updated collection", list)
+		}
+
+		if err := collections.DeleteCollection(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := collections.Collection(ctx, id, ""); !errors.Is(err, service.ErrCollectionNotFound) {
+			t.Errorf("deleted collection: got %v, want ErrCollectionNotFound", err)
+		}
+		if err := collections.DeleteCollection(ctx, id); !errors.Is(err, service.ErrCollectionNotFound) {
+			t.Errorf("deleting twice: got %v, want ErrCollectionNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+)
+
+var (
+	ErrCoopNotFound  = errors.New("co-op game not found")
+	ErrCoopFull      = errors.New("co-op game is full")
+	ErrNotCoopPlayer = errors.New("join the co-op game before playing")
+)
+
+const (
+	// MaxCoopPlayers is how many players can share one board.
+	MaxCoopPlayers = 16
+	// CoopIdleTimeout closes a co-op game once nobody has been connected for
+	// this long.
+	CoopIdleTimeout = 10 * time.Minute
+
+	coopEventBuffer = 64
+)
+
+type CoopStatus string
+
+const (
+	CoopPlaying CoopStatus = "playing"
+	CoopWon     CoopStatus = "won"
+	CoopLost    CoopStatus = "lost"
+)
+
+// Events a co-op game sends its players. Every event has the next sequence
+// number, so players can apply them in order.
+const (
+	// CoopEventState carries the whole state, when a player connects.
+	CoopEventState = "state"
+	CoopEventJoin  = "join"
+	CoopEventLeave = "leave"
+	// CoopEventSelection is a player's current selection, shown to everyone.
+	CoopEventSelection = "selection"
+	// CoopEventGuess is a guess anyone made, with the state after it.
+	CoopEventGuess = "guess"
+)
+
+type CoopSolvedGroup struct {
+	Link     string             `json:"link"`
+	Tier     int                `json:"tier"`
+	Tiles    []models.BoardTile `json:"tiles"`
+	SolvedBy string             `json:"solved_by"`
+}
+
+// CoopState is the shared board. Tiles holds those not yet solved, known by
+// their tokens on the game's public board. Players are shown by their
+// PlayerHandle.
+type CoopState struct {
+	Code              string              `json:"code"`
+	GameID            int64               `json:"game_id"`
+	Status            CoopStatus          `json:"status"`
+	Seq               int64               `json:"seq"`
+	Mistakes          int                 `json:"mistakes"`
+	MistakesRemaining int                 `json:"mistakes_remaining"`
+	Players           []string            `json:"players"`
+	Tiles             []models.BoardTile  `json:"tiles"`
+	Solved            []CoopSolvedGroup   `json:"solved"`
+	Selections        map[string][]string `json:"selections"`
+}
+
+type CoopEvent struct {
+	Seq    int64                      `json:"seq"`
+	Type   string                     `json:"type"`
+	Player string                     `json:"player,omitempty"`
+	Tiles  []string                   `json:"tiles,omitempty"`
+	Result *models.CheckTilesResponse `json:"result,omitempty"`
+	State  *CoopState                 `json:"state,omitempty"`
+	// You is the receiving player's own handle.
+	You string `json:"you,omitempty"`
+}
+
+type CoopGuessResult struct {
+	models.CheckTilesResponse
+	State CoopState `json:"state"`
+}
+
+// CoopService keeps the co-op games being played. Like races they live only
+// in memory, each run by its own goroutine.
+type CoopService struct {
+	games *GameService
+
+	mu    sync.Mutex
+	rooms map[string]*CoopRoom
+}
+
+func NewCoopService(games *GameService) *CoopService {
+	return &CoopService{
+		games: games,
+		rooms: make(map[string]*CoopRoom),
+	}
+}
+
+// CreateCoop opens a shared board on a released game and returns its state,
+// with the code others join by.
+func (s *CoopService) CreateCoop(ctx context.Context, gameID int64) (CoopState, error) {
+	board, err := s.games.FetchTilesForGame(ctx, gameID)
+	if err != nil {
+		return CoopState{}, err
+	}
+
+	s.mu.Lock()
+	code := newRaceCode()
+	for s.rooms[code] != nil {
+		code = newRaceCode()
+	}
+	room := &CoopRoom{
+		code:       code,
+		board:      board,
+		games:      s.games,
+		closed:     func() { s.remove(code) },
+		requests:   make(chan func()),
+		done:       make(chan struct{}),
+		status:     CoopPlaying,
+		members:    make(map[string]*CoopMember),
+		guessed:    make(map[string]bool),
+		solved:     make(map[string]bool),
+		selections: make(map[string][]string),
+		titles:     make(map[string]string, len(board.Tiles)),
+	}
+	for _, tile := range board.Tiles {
+		room.titles[tile.Token] = tile.Title
+	}
+	s.rooms[code] = room
+	s.mu.Unlock()
+
+	go room.run()
+	return room.State()
+}
+
+// Room finds a co-op game by its join code, ignoring case.
+func (s *CoopService) Room(code string) (*CoopRoom, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return nil, ErrCoopNotFound
+	}
+	return room, nil
+}
+
+func (s *CoopService) remove(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rooms, code)
+}
+
+// CoopRoom is one shared board. Its state is owned by the goroutine in run,
+// which applies requests one at a time in the order they arrive: when two
+// players submit the same group at once, only the first counts and the
+// second is told it was already guessed.
+type CoopRoom struct {
+	code   string
+	board  models.GameTilesResponse
+	titles map[string]string
+	games  *GameService
+	closed func()
+
+	// requests run on the room's goroutine, so they can use its state freely
+	requests chan func()
+	done     chan struct{}
+
+	// Owned by run
+	status     CoopStatus
+	seq        int64
+	mistakes   int
+	members    map[string]*CoopMember
+	players    []string
+	guessed    map[string]bool
+	solved     map[string]bool
+	groups     []CoopSolvedGroup
+	selections map[string][]string
+}
+
+// CoopMember is a player's connection to a co-op game.
+type CoopMember struct {
+	Player string
+	// Events carries the game's events for this player. It is closed when
+	// they leave, connect again elsewhere, fall too far behind, or the game
+	// closes.
+	Events <-chan CoopEvent
+
+	events chan CoopEvent
+	room   *CoopRoom
+}
+
+func (r *CoopRoom) Code() string {
+	return r.code
+}
+
+// do runs apply on the room's goroutine and waits for it.
+func (r *CoopRoom) do(apply func()) error {
+	applied := make(chan struct{})
+	select {
+	case r.requests <- func() { apply(); close(applied) }:
+	case <-r.done:
+		return ErrCoopNotFound
+	}
+	<-applied
+	return nil
+}
+
+func (r *CoopRoom) State() (CoopState, error) {
+	var state CoopState
+	err := r.do(func() { state = r.state() })
+	return state, err
+}
+
+// Join connects the player, who then sees the state and every event after
+// it. Anyone can join, even part way through.
+func (r *CoopRoom) Join(playerID string) (*CoopMember, error) {
+	var member *CoopMember
+	var joinErr error
+	err := r.do(func() { member, joinErr = r.join(playerID) })
+	if err != nil {
+		return nil, err
+	}
+	return member, joinErr
+}
+
+// Leave disconnects the member. Their selection is cleared.
+func (m *CoopMember) Leave() {
+	m.room.do(func() { m.room.leave(m) })
+}
+
+// Select shows the player's selection, given by tile tokens, to everyone.
+func (r *CoopRoom) Select(playerID string, tiles []string) error {
+	var selectErr error
+	err := r.do(func() { selectErr = r.selectTiles(playerID, tiles) })
+	if err != nil {
+		return err
+	}
+	return selectErr
+}
+
+// Guess checks the tiles the same way as a solo check, then applies the
+// result to the shared board.
+func (r *CoopRoom) Guess(ctx context.Context, playerID string, tiles []string) (CoopGuessResult, error) {
+	check, err := r.games.CheckTileSelection(ctx, r.board.GameID, tiles)
+	if err != nil {
+		return CoopGuessResult{}, err
+	}
+
+	var result CoopGuessResult
+	var guessErr error
+	if err := r.do(func() { result, guessErr = r.guess(playerID, tiles, check) }); err != nil {
+		return CoopGuessResult{}, err
+	}
+	return result, guessErr
+}
+
+func (r *CoopRoom) run() {
+	defer r.close()
+
+	idle := time.After(CoopIdleTimeout)
+	for {
+		select {
+		case request := <-r.requests:
+			request()
+		case <-idle:
+			return
+		}
+
+		switch {
+		case r.connected() > 0:
+			idle = nil
+		case idle == nil:
+			idle = time.After(CoopIdleTimeout)
+		}
+	}
+}
+
+func (r *CoopRoom) close() {
+	r.closed()
+	close(r.done)
+	for _, member := range r.members {
+		close(member.events)
+	}
+}
+
+func (r *CoopRoom) join(playerID string) (*CoopMember, error) {
+	if !slices.Contains(r.players, playerID) {
+		if len(r.players) >= MaxCoopPlayers {
+			return nil, ErrCoopFull
+		}
+		r.players = append(r.players, playerID)
+	}
+
+	// A newer connection replaces the old one
+	if old := r.members[playerID]; old != nil {
+		r.disconnect(old)
+	}
+	r.broadcast(CoopEvent{Type: CoopEventJoin, Player: PlayerHandle(playerID)})
+
+	events := make(chan CoopEvent, coopEventBuffer)
+	member := &CoopMember{Player: playerID, Events: events, events: events, room: r}
+	r.members[playerID] = member
+	state := r.state()
+	r.send(member, CoopEvent{Seq: r.seq, Type: CoopEventState, State: &state})
+	return member, nil
+}
+
+func (r *CoopRoom) leave(member *CoopMember) {
+	if r.members[member.Player] != member {
+		return
+	}
+	r.disconnect(member)
+	delete(r.selections, member.Player)
+	r.broadcast(CoopEvent{Type: CoopEventLeave, Player: PlayerHandle(member.Player)})
+}
+
+func (r *CoopRoom) selectTiles(playerID string, tiles []string) error {
+	if r.members[playerID] == nil {
+		return ErrNotCoopPlayer
+	}
+	if r.status != CoopPlaying {
+		return ErrSessionFinished
+	}
+	if len(tiles) > r.board.GroupSize {
+		return fmt.Errorf("%w: select at most %d tiles", ErrInvalidSelection, r.board.GroupSize)
+	}
+	if err := r.playable(tiles); err != nil {
+		return err
+	}
+
+	r.selections[playerID] = slices.Clone(tiles)
+	r.broadcast(CoopEvent{Type: CoopEventSelection, Player: PlayerHandle(playerID), Tiles: tiles})
+	return nil
+}
+
+func (r *CoopRoom) guess(playerID string, tiles []string, check models.CheckTilesResponse) (CoopGuessResult, error) {
+	if r.members[playerID] == nil {
+		return CoopGuessResult{}, ErrNotCoopPlayer
+	}
+	if r.status != CoopPlaying {
+		return CoopGuessResult{}, ErrSessionFinished
+	}
+	// A group solved since the tiles were checked counts only once
+	if err := r.playable(tiles); err != nil {
+		return CoopGuessResult{}, err
+	}
+	key := slices.Clone(tiles)
+	slices.Sort(key)
+	if r.guessed[strings.Join(key, ",")] {
+		return CoopGuessResult{}, ErrAlreadyGuessed
+	}
+	r.guessed[strings.Join(key, ",")] = true
+
+	if check.Correct {
+		group := CoopSolvedGroup{Link: check.LinkText, Tier: check.Tier, SolvedBy: PlayerHandle(playerID)}
+		for _, token := range tiles {
+			r.solved[token] = true
+			group.Tiles = append(group.Tiles, models.BoardTile{Token: token, Title: r.titles[token]})
+		}
+		r.groups = append(r.groups, group)
+	} else {
+		r.mistakes++
+	}
+	switch {
+	case len(r.groups) >= r.board.GroupCount:
+		r.status = CoopWon
+	case r.mistakes >= MaxMistakes:
+		r.status = CoopLost
+	}
+
+	// Selections including solved tiles no longer make sense
+	for player, selection := range r.selections {
+		if slices.ContainsFunc(selection, func(token string) bool { return r.solved[token] }) {
+			delete(r.selections, player)
+		}
+	}
+
+	state := r.state()
+	r.broadcast(CoopEvent{Type: CoopEventGuess, Player: PlayerHandle(playerID), Tiles: tiles, Result: &check, State: &state})
+	return CoopGuessResult{CheckTilesResponse: check, State: state}, nil
+}
+
+// playable rejects tiles that are not on the board or already solved.
+func (r *CoopRoom) playable(tiles []string) error {
+	for _, token := range tiles {
+		switch {
+		case r.titles[token] == "":
+			return &ForeignTilesError{GameID: r.board.GameID, Tiles: []string{token}}
+		case r.solved[token]:
+			return fmt.Errorf("%w: that tile is already solved", ErrInvalidSelection)
+		}
+	}
+	return nil
+}
+
+func (r *CoopRoom) state() CoopState {
+	state := CoopState{
+		Code:              r.code,
+		GameID:            r.board.GameID,
+		Status:            r.status,
+		Seq:               r.seq,
+		Mistakes:          r.mistakes,
+		MistakesRemaining: max(0, MaxMistakes-r.mistakes),
+		Players:           []string{},
+		Tiles:             []models.BoardTile{},
+		Solved:            slices.Clone(r.groups),
+		Selections:        make(map[string][]string, len(r.selections)),
+	}
+	if state.Solved == nil {
+		state.Solved = []CoopSolvedGroup{}
+	}
+	for _, player := range r.players {
+		if r.members[player] != nil {
+			state.Players = append(state.Players, PlayerHandle(player))
+		}
+	}
+	for _, tile := range r.board.Tiles {
+		if !r.solved[tile.Token] {
+			state.Tiles = append(state.Tiles, tile)
+		}
+	}
+	for player, selection := range r.selections {
+		state.Selections[PlayerHandle(player)] = slices.Clone(selection)
+	}
+	return state
+}
+
+// broadcast gives the event the next sequence number and sends it to
+// everyone connected.
+func (r *CoopRoom) broadcast(event CoopEvent) {
+	r.seq++
+	event.Seq = r.seq
+	if event.State != nil {
+		event.State.Seq = r.seq
+	}
+	for _, player := range r.players {
+		if member := r.members[player]; member != nil {
+			r.send(member, event)
+		}
+	}
+}
+
+// send never blocks the room: a member too far behind is disconnected.
+func (r *CoopRoom) send(member *CoopMember, event CoopEvent) {
+	event.You = PlayerHandle(member.Player)
+	select {
+	case member.events <- event:
+	default:
+		log.Printf("disconnecting %s from co-op game %s: too far behind", PlayerHandle(member.Player), r.code)
+		r.disconnect(member)
+		delete(r.selections, member.Player)
+	}
+}
+
+func (r *CoopRoom) disconnect(member *CoopMember) {
+	if r.members[member.Player] != member {
+		return
+	}
+	close(member.events)
+	delete(r.members, member.Player)
+}
+
+func (r *CoopRoom) connected() int {
+	return len(r.members)
+}
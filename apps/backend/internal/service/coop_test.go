@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestCoop(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewMemoryStore()
+	games := newGameService(store)
+	coops := service.NewCoopService(games)
+	game := testutil.Seed(t, games, store, testutil.NewGame())
+
+	created, err := coops.CreateCoop(ctx, game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	room, err := coops.Room(created.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	board := created.Tiles
+
+	if _, err := room.Guess(ctx, "alice", testutil.Tokens(t, board, game.Groups[0].Titles...)); !errors.Is(err, service.ErrNotCoopPlayer) {
+		t.Errorf("guessing before joining: got %v, want ErrNotCoopPlayer", err)
+	}
+
+	alice, err := room.Join("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alice.Leave()
+	bob, err := room.Join("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bob.Leave()
+
+	// Both submit the first group at once: it is solved once, and the other
+	// guess is turned away without costing a mistake
+	first := testutil.Tokens(t, board, game.Groups[0].Titles...)
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, member := range []*service.CoopMember{alice, bob} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = room.Guess(ctx, member.Player, first)
+		}()
+	}
+	wg.Wait()
+	if (errs[0] == nil) == (errs[1] == nil) {
+		t.Fatalf("got errors %v, want exactly one guess to count", errs)
+	}
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, service.ErrAlreadyGuessed) && !errors.Is(err, service.ErrInvalidSelection) {
+			t.Errorf("got %v for the losing guess", err)
+		}
+	}
+
+	if err := room.Select("bob", first[:1]); !errors.Is(err, service.ErrInvalidSelection) {
+		t.Errorf("selecting a solved tile: got %v, want ErrInvalidSelection", err)
+	}
+	second := testutil.Tokens(t, board, game.Groups[1].Titles...)
+	if err := room.Select("bob", second[:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mistakes come out of one pool
+	mixed := testutil.Tokens(t, board, game.Groups[1].Titles[0], game.Groups[2].Titles[0], game.Groups[3].Titles[0], game.Groups[3].Titles[1])
+	result, err := room.Guess(ctx, "alice", mixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Correct || result.State.Mistakes != 1 || result.State.MistakesRemaining != service.MaxMistakes-1 {
+		t.Errorf("got %+v after a wrong guess", result)
+	}
+	if _, err := room.Guess(ctx, "bob", mixed); !errors.Is(err, service.ErrAlreadyGuessed) {
+		t.Errorf("repeating a guess: got %v, want ErrAlreadyGuessed", err)
+	}
+
+	result, err = room.Guess(ctx, "bob", second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Correct || len(result.State.Solved) != 2 || result.State.Solved[1].SolvedBy != service.PlayerHandle("bob") {
+		t.Errorf("got %+v after bob's guess", result.State)
+	}
+	if _, ok := result.State.Selections[service.PlayerHandle("bob")]; ok {
+		t.Error("bob's selection of solved tiles was kept")
+	}
+	if len(result.State.Tiles) != 8 {
+		t.Errorf("got %d tiles left, want 8", len(result.State.Tiles))
+	}
+
+	// Alice saw everything, in order, ending in the same state
+	var last service.CoopEvent
+	for seq := int64(0); last.Seq < result.State.Seq; {
+		last = <-alice.Events
+		if last.Seq <= seq && last.Type != service.CoopEventState {
+			t.Fatalf("event %d (%s) came after %d", last.Seq, last.Type, seq)
+		}
+		seq = last.Seq
+	}
+	if last.Type != service.CoopEventGuess || last.State.Mistakes != 1 || len(last.State.Solved) != 2 || last.You != service.PlayerHandle("alice") {
+		t.Errorf("alice's last event was %+v", last)
+	}
+}
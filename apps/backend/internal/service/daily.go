@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+var (
+	ErrNoDailyPuzzle = errors.New("no puzzle scheduled for that date")
+	ErrDateInPast    = errors.New("the schedule for past dates cannot be changed")
+	ErrDateTaken     = errors.New("a puzzle is already scheduled for that date")
+)
+
+type DailyPuzzle struct {
+	Date   string `json:"date"`
+	GameID int64  `json:"game_id"`
+}
+
+// Today is the current calendar date in the daily puzzle timezone, as a
+// midnight UTC value suitable for DATE columns.
+func (s *GameService) Today() time.Time {
+	return dateOf(time.Now().In(s.location))
+}
+
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func toDailyPuzzle(d db.DailyPuzzle) DailyPuzzle {
+	return DailyPuzzle{
+		Date:   d.PuzzleDate.Format(time.DateOnly),
+		GameID: d.GameID,
+	}
+}
+
+// DailyPuzzle returns the puzzle for a date. Dates after today are reported
+// as unscheduled so the schedule can't be read ahead.
+func (s *GameService) DailyPuzzle(ctx context.Context, date time.Time) (DailyPuzzle, error) {
+	date = dateOf(date)
+	if date.After(s.Today()) {
+		return DailyPuzzle{}, ErrNoDailyPuzzle
+	}
+
+	daily, err := s.store.GetDailyPuzzle(ctx, date)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return DailyPuzzle{}, ErrNoDailyPuzzle
+		}
+		log.Printf("unable to fetch daily puzzle for %s: %v", date.Format(time.DateOnly), err)
+		return DailyPuzzle{}, err
+	}
+
+	return toDailyPuzzle(daily), nil
+}
+
+func (s *GameService) ListSchedule(ctx context.Context, from, to time.Time) ([]DailyPuzzle, error) {
+	rows, err := s.store.ListDailyPuzzles(ctx, db.ListDailyPuzzlesParams{
+		FromDate: dateOf(from),
+		ToDate:   dateOf(to),
+	})
+	if err != nil {
+		log.Printf("unable to list daily puzzles: %v", err)
+		return nil, err
+	}
+
+	result := make([]DailyPuzzle, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, toDailyPuzzle(row))
+	}
+	return result, nil
+}
+
+// ScheduleDaily sets the game for a date, replacing any game already there.
+// Only published games can be scheduled.
+func (s *GameService) ScheduleDaily(ctx context.Context, date time.Time, gameID int64) (DailyPuzzle, error) {
+	date = dateOf(date)
+	if date.Before(s.Today()) {
+		return DailyPuzzle{}, ErrDateInPast
+	}
+
+	game, err := s.store.GetGame(ctx, gameID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return DailyPuzzle{}, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
+		}
+		return DailyPuzzle{}, err
+	}
+	// Nobody could play an unpublished game on its day
+	if game.ModerationStatus != db.ModerationStatusPublished {
+		return DailyPuzzle{}, fmt.Errorf("%w: %d", ErrGameNotReleased, gameID)
+	}
+
+	daily, err := s.store.UpsertDailyPuzzle(ctx, db.UpsertDailyPuzzleParams{
+		PuzzleDate: date,
+		GameID:     gameID,
+	})
+	if err != nil {
+		log.Printf("unable to schedule game %d for %s: %v", gameID, date.Format(time.DateOnly), err)
+		return DailyPuzzle{}, err
+	}
+
+	return toDailyPuzzle(daily), nil
+}
+
+// RescheduleDaily moves the puzzle on one date to another, empty, date.
+func (s *GameService) RescheduleDaily(ctx context.Context, from, to time.Time) (DailyPuzzle, error) {
+	from, to = dateOf(from), dateOf(to)
+	today := s.Today()
+	if from.Before(today) || to.Before(today) {
+		return DailyPuzzle{}, ErrDateInPast
+	}
+
+	daily, err := s.store.MoveDailyPuzzle(ctx, db.MoveDailyPuzzleParams{
+		FromDate: from,
+		ToDate:   to,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case err == sql.ErrNoRows:
+			return DailyPuzzle{}, ErrNoDailyPuzzle
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return DailyPuzzle{}, ErrDateTaken
+		}
+		log.Printf("unable to move daily puzzle from %s: %v", from.Format(time.DateOnly), err)
+		return DailyPuzzle{}, err
+	}
+
+	return toDailyPuzzle(daily), nil
+}
+
+func (s *GameService) UnscheduleDaily(ctx context.Context, date time.Time) error {
+	date = dateOf(date)
+	if date.Before(s.Today()) {
+		return ErrDateInPast
+	}
+
+	rows, err := s.store.DeleteDailyPuzzle(ctx, date)
+	if err != nil {
+		log.Printf("unable to unschedule daily puzzle for %s: %v", date.Format(time.DateOnly), err)
+		return err
+	}
+	if rows == 0 {
+		return ErrNoDailyPuzzle
+	}
+	return nil
+}
+
+// AnnounceDaily announces the puzzle for date on the activity feed if it goes
+// live that day, having been held back as a future daily until then.
+func (s *GameService) AnnounceDaily(ctx context.Context, date time.Time) error {
+	date = dateOf(date)
+	daily, err := s.store.GetDailyPuzzle(ctx, date)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		log.Printf("unable to fetch daily puzzle for %s: %v", date.Format(time.DateOnly), err)
+		return err
+	}
+
+	game, err := s.store.GetGame(ctx, daily.GameID)
+	if err != nil {
+		log.Printf("error fetching game %d: %v", daily.GameID, err)
+		return err
+	}
+	if game.ModerationStatus != db.ModerationStatusPublished {
+		return nil
+	}
+	// Held back the day before means this is its first day
+	embargoed, err := s.store.IsGameEmbargoed(ctx, db.IsGameEmbargoedParams{
+		GameID: game.ID,
+		Today:  date.AddDate(0, 0, -1),
+	})
+	if err != nil {
+		log.Printf("unable to check schedule for game %d: %v", game.ID, err)
+		return err
+	}
+	if embargoed {
+		s.activity.Publish(Activity{Type: ActivityPublished, GameID: game.ID, Author: game.Author})
+	}
+	return nil
+}
+
+// AnnounceDailies calls AnnounceDaily as each day starts in the daily puzzle
+// timezone, until ctx is done.
+func (s *GameService) AnnounceDailies(ctx context.Context) {
+	for {
+		now := time.Now().In(s.location)
+		y, m, d := now.Date()
+		timer := time.NewTimer(time.Date(y, m, d+1, 0, 0, 0, 0, s.location).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.AnnounceDaily(ctx, s.Today())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	models "github.com/lukeberry99/puzzle/internal"
+)
+
+// ContentFilter screens the text of submitted games. Match returns the
+// offending terms found in text, or nothing if it is acceptable.
+type ContentFilter interface {
+	Match(text string) []string
+}
+
+// Blocklist is a ContentFilter that matches whole words and phrases, ignoring
+// case and punctuation.
+type Blocklist struct {
+	terms []string
+}
+
+func NewBlocklist(terms []string) *Blocklist {
+	b := &Blocklist{}
+	for _, term := range terms {
+		if term = normalizeText(term); term != "" {
+			b.terms = append(b.terms, term)
+		}
+	}
+	slices.Sort(b.terms)
+	b.terms = slices.Compact(b.terms)
+	return b
+}
+
+// ReadBlocklist reads one term per line. Blank lines and lines starting with
+// # are skipped.
+func ReadBlocklist(r io.Reader) (*Blocklist, error) {
+	var terms []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewBlocklist(terms), nil
+}
+
+// LoadBlocklist reads a blocklist file. An empty path gives an empty
+// blocklist, which lets everything through.
+func LoadBlocklist(path string) (*Blocklist, error) {
+	if path == "" {
+		return NewBlocklist(nil), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBlocklist(f)
+}
+
+func (b *Blocklist) Match(text string) []string {
+	text = " " + normalizeText(text) + " "
+	var matches []string
+	for _, term := range b.terms {
+		if strings.Contains(text, " "+term+" ") {
+			matches = append(matches, term)
+		}
+	}
+	return matches
+}
+
+func normalizeText(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// screenGame runs the filter over everything a player or author would see:
+// the author's name, the links and their terms, and the tiles.
+func screenGame(filter ContentFilter, req models.CreateGameRequest) []string {
+	texts := []string{req.Author}
+	for _, group := range req.Groups {
+		texts = append(texts, group.Link)
+		texts = append(texts, group.LinkTerms...)
+		for _, tile := range group.Tiles {
+			texts = append(texts, tile.Title)
+		}
+	}
+
+	var matches []string
+	for _, text := range texts {
+		matches = append(matches, filter.Match(text)...)
+	}
+	slices.Sort(matches)
+	return slices.Compact(matches)
+}
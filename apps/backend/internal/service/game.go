@@ -3,38 +3,97 @@ package service
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	models "github.com/lukeberry99/puzzle/internal"
 	"github.com/lukeberry99/puzzle/internal/db"
 )
 
+var (
+	ErrGameNotFound     = errors.New("game not found")
+	ErrGameNotReleased  = errors.New("game has not been published")
+	ErrInvalidSelection = errors.New("invalid tile selection")
+)
+
 type GameService struct {
-	queries *db.Queries
+	store GameStore
+	// location is the timezone daily puzzles are scheduled in.
+	location *time.Location
+	// filter screens new games before they reach the moderation queue.
+	filter ContentFilter
+	// activity is where games and sessions announce what happens to them.
+	activity *ActivityFeed
 }
 
-func NewGameService(queries *db.Queries) *GameService {
+func NewGameService(store GameStore, location *time.Location, filter ContentFilter, activity *ActivityFeed) *GameService {
 	return &GameService{
-		queries: queries,
+		store:    store,
+		location: location,
+		filter:   filter,
+		activity: activity,
 	}
 }
 
+// CreateGame saves a game for moderation. It waits as pending, or as flagged
+// when the content filter matches anything in it.
 func (s *GameService) CreateGame(ctx context.Context, req models.CreateGameRequest) (int64, error) {
-	gameID, err := s.queries.CreateGame(ctx, db.CreateGameParams{
-		Author:  req.Author,
-		Column2: db.DifficultyLevel(req.Difficulty),
-		Column3: db.TimeLimit(req.TimeLimit),
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+
+	status, reason := db.ModerationStatusPending, ""
+	if matches := screenGame(s.filter, req); len(matches) > 0 {
+		status = db.ModerationStatusFlagged
+		reason = "blocklisted: " + strings.Join(matches, ", ")
+	}
+
+	groupCount, groupSize := req.Dimensions()
+	hints := req.HintCosts()
+	gameID, err := s.store.CreateGame(ctx, db.CreateGameParams{
+		Author:           strings.TrimSpace(req.Author),
+		Column2:          db.DifficultyLevel(strings.ToLower(req.Difficulty)),
+		Column3:          db.TimeLimit(strings.ToLower(req.TimeLimit)),
+		GroupCount:       int32(groupCount),
+		GroupSize:        int32(groupSize),
+		ModerationStatus: status,
+		ModerationReason: reason,
+		HintPenalty:      db.HintPenalty(strings.ToLower(hints.Penalty)),
+		HintTileCost:     int32(hints.Tile),
+		HintInitialCost:  int32(hints.Initial),
+		HintLinkCost:     int32(hints.Link),
 	})
 	if err != nil {
 		return 0, err
 	}
 
-	// Create groups and tiles
+	// Create groups first, so tiles can refer to other groups as decoys
+	groupIDs := make(map[int]int64, len(req.Groups))
+	for _, group := range req.Groups {
+		groupID, err := s.store.CreateGroup(ctx, db.CreateGroupParams{
+			GameID:    gameID,
+			Link:      group.Link,
+			LinkTerms: strings.Join(group.LinkTerms, ","),
+			Tier:      int32(group.Tier),
+		})
+		if err != nil {
+			log.Printf("unable to create group for game %d: %v", gameID, err)
+			return 0, err
+		}
+		groupIDs[group.Tier] = groupID
+	}
+
 	for _, group := range req.Groups {
-		if err := s.createGroupWithTiles(ctx, gameID, group); err != nil {
+		if err := s.createTiles(ctx, groupIDs[group.Tier], group.Tiles, groupIDs); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(req.Tags) > 0 {
+		if err := s.tagGame(ctx, gameID, req.Tags); err != nil {
 			return 0, err
 		}
 	}
@@ -42,8 +101,40 @@ func (s *GameService) CreateGame(ctx context.Context, req models.CreateGameReque
 	return gameID, nil
 }
 
-func (s *GameService) createGroupWithTiles(ctx context.Context, gameID int64, group models.Group) error {
-	//TODO: Yo we need to do this shit
+// ValidateGame dry-runs CreateGame: it reports structural problems and
+// ambiguity warnings without saving anything.
+func (s *GameService) ValidateGame(req models.CreateGameRequest) models.ValidateGameResponse {
+	result := models.ValidateGameResponse{
+		Valid:    true,
+		Problems: []string{},
+		Warnings: req.Warnings(),
+	}
+
+	var verr *models.ValidationError
+	if errors.As(req.Validate(), &verr) {
+		result.Valid = false
+		result.Problems = verr.Problems
+	}
+	return result
+}
+
+func (s *GameService) createTiles(ctx context.Context, groupID int64, tiles []models.Tile, groupIDs map[int]int64) error {
+	for _, tile := range tiles {
+		params := db.CreateTilesForGroupParams{
+			GroupID: groupID,
+			Title:   tile.Title,
+		}
+		if tile.Decoy != nil {
+			params.DecoyGroupID = sql.NullInt64{Int64: groupIDs[tile.Decoy.Tier], Valid: true}
+			params.DecoyReason = sql.NullString{String: tile.Decoy.Reason, Valid: tile.Decoy.Reason != ""}
+		}
+
+		if err := s.store.CreateTilesForGroup(ctx, params); err != nil {
+			log.Printf("unable to create tile for group %d: %v", groupID, err)
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -52,10 +143,23 @@ type games struct {
 	Author     string             `json:"author"`
 	Difficulty db.DifficultyLevel `json:"difficulty_level"`
 	CreatedAt  time.Time          `json:"created_at"`
+	Tags       []string           `json:"tags"`
+	Rating     RatingSummary      `json:"rating"`
 }
 
-func (s *GameService) FetchAllGames(ctx context.Context) ([]games, error) {
-	dbGames, err := s.queries.GetAllGames(ctx)
+// GameListOptions narrows and orders FetchAllGames. Tag filters to one tag,
+// and Sort may be "rating" to put the best rated games first.
+type GameListOptions struct {
+	Tag  string
+	Sort string
+}
+
+func (s *GameService) FetchAllGames(ctx context.Context, opts GameListOptions) ([]games, error) {
+	dbGames, err := s.store.GetReleasedGames(ctx, db.GetReleasedGamesParams{
+		Today: s.Today(),
+		Tag:   models.NormalizeTag(opts.Tag),
+		Sort:  opts.Sort,
+	})
 	if err != nil {
 		log.Printf("unable to fetch games: %v", err)
 		return nil, err
@@ -68,99 +172,189 @@ func (s *GameService) FetchAllGames(ctx context.Context) ([]games, error) {
 			Author:     g.Author,
 			Difficulty: g.Difficulty,
 			CreatedAt:  g.CreatedAt,
+			Tags:       g.Tags,
+			Rating: RatingSummary{
+				Ratings:      g.Ratings,
+				AverageStars: g.AverageStars,
+				TooEasy:      g.TooEasy,
+				TooHard:      g.TooHard,
+				Unfair:       g.Unfair,
+			},
 		})
 	}
 	return result, nil
 }
 
-func (s *GameService) FetchTilesForGame(ctx context.Context, gameId int64) ([]db.GetTilesForGroupRow, error) {
-	// First verify the game exists
-	_, err := s.queries.GetGame(ctx, gameId)
+// FetchTilesForGame returns the public board for a game. Tiles are known by
+// tokens under the game's key and ordered by them, so neither gives the
+// groups away.
+func (s *GameService) FetchTilesForGame(ctx context.Context, gameId int64) (models.GameTilesResponse, error) {
+	return s.fetchTiles(ctx, gameId, nil)
+}
+
+// fetchTiles returns a game's board under key, or the public board when key
+// is nil.
+func (s *GameService) fetchTiles(ctx context.Context, gameId int64, key []byte) (models.GameTilesResponse, error) {
+	// First verify the game exists and can be played
+	game, err := s.releasedGame(ctx, gameId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("game %d not found", gameId)
-		}
-		log.Printf("error fetching game %d: %v", gameId, err)
-		return nil, err
+		return models.GameTilesResponse{}, err
+	}
+	if key == nil {
+		key = game.TileKey
 	}
 
-	groups, err := s.queries.GetGroupsForGame(ctx, gameId)
+	board, err := s.board(ctx, gameId, key)
 	if err != nil {
-		log.Printf("unable to fetch groups for game: %d, %v", gameId, err)
-		return nil, err
+		return models.GameTilesResponse{}, err
 	}
-
-	if len(groups) == 0 {
-		log.Printf("no groups found for game: %d", gameId)
-		return []db.GetTilesForGroupRow{}, nil
+	if len(board.tiles) == 0 {
+		log.Printf("no tiles found for game: %d", gameId)
 	}
 
-	var tiles []db.GetTilesForGroupRow
-	for _, group := range groups {
-		groupTiles, err := s.queries.GetTilesForGroup(ctx, group)
-		if err != nil {
-			log.Printf("unable to fetch tiles for group %d: %v", group, err)
-			return nil, err
-		}
-		if len(groupTiles) > 0 {
-			tiles = append(tiles, groupTiles...)
+	return models.GameTilesResponse{
+		GameID:     gameId,
+		GroupCount: int(game.GroupCount),
+		GroupSize:  int(game.GroupSize),
+		Tiles:      board.show(nil),
+	}, nil
+}
+
+// EnsureReleased returns ErrGameNotFound for games that don't exist, for games
+// that haven't been published by a moderator, and for games only scheduled as
+// a future daily puzzle, so none of them can be played or even confirmed to
+// exist early.
+func (s *GameService) EnsureReleased(ctx context.Context, gameID int64) error {
+	_, err := s.releasedGame(ctx, gameID)
+	return err
+}
+
+func (s *GameService) releasedGame(ctx context.Context, gameID int64) (db.Game, error) {
+	game, err := s.store.GetGame(ctx, gameID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.Game{}, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
 		}
+		log.Printf("error fetching game %d: %v", gameID, err)
+		return db.Game{}, err
+	}
+	if game.ModerationStatus != db.ModerationStatusPublished {
+		return db.Game{}, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
 	}
 
-	if len(tiles) == 0 {
-		log.Printf("no tiles found for game: %d", gameId)
-		return []db.GetTilesForGroupRow{}, nil
+	embargoed, err := s.store.IsGameEmbargoed(ctx, db.IsGameEmbargoedParams{
+		GameID: gameID,
+		Today:  s.Today(),
+	})
+	if err != nil {
+		log.Printf("unable to check schedule for game %d: %v", gameID, err)
+		return db.Game{}, err
+	}
+	if embargoed {
+		return db.Game{}, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
+	}
+
+	return game, nil
+}
+
+// CheckTileSelection reports whether the tiles, given by their tokens on the
+// public board, make up one of the game's groups, and if so which link and
+// tier they were.
+func (s *GameService) CheckTileSelection(ctx context.Context, gameID int64, tokens []string) (models.CheckTilesResponse, error) {
+	return s.checkTiles(ctx, gameID, nil, tokens)
+}
+
+// checkTiles is CheckTileSelection for tokens under key, or on the public
+// board when key is nil.
+func (s *GameService) checkTiles(ctx context.Context, gameID int64, key []byte, tokens []string) (models.CheckTilesResponse, error) {
+	// First, verify the game exists and can be played
+	game, err := s.releasedGame(ctx, gameID)
+	if err != nil {
+		return models.CheckTilesResponse{}, err
+	}
+	if len(tokens) != int(game.GroupSize) {
+		return models.CheckTilesResponse{}, fmt.Errorf("%w: select exactly %d tiles", ErrInvalidSelection, game.GroupSize)
 	}
 
-	// Marshal and unmarshal to lowercase the keys
-	jsonBytes, err := json.Marshal(tiles)
+	if key == nil {
+		key = game.TileKey
+	}
+	board, err := s.board(ctx, gameID, key)
 	if err != nil {
-		log.Printf("error marshaling tiles: %v", err)
-		return nil, err
+		return models.CheckTilesResponse{}, err
+	}
+	tileIDs, err := board.resolve(tokens)
+	if err != nil {
+		return models.CheckTilesResponse{}, err
 	}
 
-	var formattedTiles []db.GetTilesForGroupRow
-	if err := json.Unmarshal(jsonBytes, &formattedTiles); err != nil {
-		log.Printf("error unmarshaling tiles: %v", err)
-		return nil, err
+	group, ok, err := s.matchGroup(ctx, gameID, tileIDs, int(game.GroupSize))
+	if err != nil || !ok {
+		return models.CheckTilesResponse{}, err
 	}
 
-	return formattedTiles, nil
+	return models.CheckTilesResponse{
+		Correct:  true,
+		LinkText: group.Link,
+		Tier:     int(group.Tier),
+	}, nil
 }
 
-func (s *GameService) CheckTileSelection(ctx context.Context, gameID int64, tileIDs []int64) (bool, string, error) {
-	// First, verify the game exists
-	_, err := s.queries.GetGame(ctx, gameID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, "", fmt.Errorf("game not found")
+// matchGroup reports the group of the game the tiles make up, if they make
+// one up. Repeated tiles, and tiles from outside the game, are an
+// ErrInvalidSelection.
+func (s *GameService) matchGroup(ctx context.Context, gameID int64, tileIDs []int64, groupSize int) (db.Group, bool, error) {
+	seen := make(map[int64]bool, len(tileIDs))
+	for _, id := range tileIDs {
+		if seen[id] {
+			return db.Group{}, false, fmt.Errorf("%w: tile %d is selected more than once", ErrInvalidSelection, id)
 		}
-		return false, "", err
+		seen[id] = true
 	}
 
 	// Get the group ID for these tiles (they should all be in the same group)
-	tiles, err := s.queries.GetTilesByIDs(ctx, tileIDs)
+	tiles, err := s.store.GetGameTilesByIDs(ctx, db.GetGameTilesByIDsParams{
+		GameID: gameID,
+		Ids:    tileIDs,
+	})
 	if err != nil {
-		return false, "", err
+		log.Printf("unable to fetch tiles for game %d: %v", gameID, err)
+		return db.Group{}, false, err
 	}
 
-	if len(tiles) != 4 {
-		return false, "", nil
+	if len(tiles) != len(tileIDs) {
+		return db.Group{}, false, fmt.Errorf("%w: not every tile is part of game %d", ErrInvalidSelection, gameID)
+	}
+	if len(tiles) != groupSize {
+		return db.Group{}, false, nil
 	}
 
 	// Check if all tiles belong to the same group
 	groupID := tiles[0].GroupID
 	for _, tile := range tiles[1:] {
 		if tile.GroupID != groupID {
-			return false, "", nil
+			return db.Group{}, false, nil
 		}
 	}
 
 	// Get the group to retrieve the link text
-	group, err := s.queries.GetGroup(ctx, groupID)
+	group, err := s.store.GetGroup(ctx, groupID)
 	if err != nil {
-		return false, "", err
+		return db.Group{}, false, err
 	}
 
-	return true, group.Link, nil
+	return group, true, nil
+}
+
+// DeleteGame removes a game; its groups and tiles are removed by cascade.
+func (s *GameService) DeleteGame(ctx context.Context, gameID int64) error {
+	rows, err := s.store.DeleteGame(ctx, gameID)
+	if err != nil {
+		log.Printf("unable to delete game %d: %v", gameID, err)
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
+	}
+	return nil
 }
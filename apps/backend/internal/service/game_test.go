@@ -0,0 +1,375 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.Main(m))
+}
+
+func newGameService(store service.GameStore) *service.GameService {
+	return service.NewGameService(store, time.UTC, service.NewBlocklist([]string{"rude"}), service.NewActivityFeed(service.ActivityBacklog))
+}
+
+func TestCreateGame(t *testing.T) {
+	tests := []struct {
+		name    string
+		game    *testutil.GameBuilder
+		status  db.ModerationStatus
+		problem string
+	}{
+		{
+			name:   "default board",
+			game:   testutil.NewGame(),
+			status: db.ModerationStatusPending,
+		},
+		{
+			name:   "three groups of five",
+			game:   testutil.NewGame().Size(3, 5),
+			status: db.ModerationStatusPending,
+		},
+		{
+			name: "red herring",
+			game: testutil.NewGame().Group(testutil.NewGroup(2).DefaultTiles(4).
+				Tile(0, testutil.NewTile("Decoy").DecoyFor(1, "looks like a link 1"))),
+			status: db.ModerationStatusPending,
+		},
+		{
+			name:   "blocklisted author",
+			game:   testutil.NewGame().Author("Rude Person"),
+			status: db.ModerationStatusFlagged,
+		},
+		{
+			name:    "missing author",
+			game:    testutil.NewGame().Author(" "),
+			problem: "author is required",
+		},
+		{
+			name: "missing group",
+			game: testutil.NewGame().Edit(func(req *models.CreateGameRequest) {
+				req.Groups = req.Groups[1:]
+			}),
+			problem: "must have exactly 4 groups, got 3",
+		},
+		{
+			name:    "duplicate tier",
+			game:    testutil.NewGame().Edit(func(req *models.CreateGameRequest) { req.Groups[1].Tier = 1 }),
+			problem: "group 2: tier 1 is already used by group 1",
+		},
+		{
+			name: "decoy for its own group",
+			game: testutil.NewGame().Group(testutil.NewGroup(1).DefaultTiles(4).
+				Tile(0, testutil.NewTile("Decoy").DecoyFor(1, ""))),
+			problem: "group 1 tile 1: decoy tier must be another group's tier",
+		},
+		{
+			name:   "hints costing mistakes",
+			game:   testutil.NewGame().Hints("Mistake", 1, 1, 2),
+			status: db.ModerationStatusPending,
+		},
+		{
+			name:    "hint costing a whole session",
+			game:    testutil.NewGame().Hints("mistake", 1, 1, 4),
+			problem: "hints: link cost must be between 0 and 3",
+		},
+	}
+
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := tt.game.Request()
+				gameID, err := games.CreateGame(ctx, req)
+
+				if tt.problem != "" {
+					var verr *models.ValidationError
+					if !errors.As(err, &verr) {
+						t.Fatalf("got %v, want a validation error", err)
+					}
+					if !containsProblem(verr.Problems, tt.problem) {
+						t.Errorf("problems %q do not include %q", verr.Problems, tt.problem)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("CreateGame: %v", err)
+				}
+
+				game, err := store.GetGame(ctx, gameID)
+				if err != nil {
+					t.Fatalf("GetGame: %v", err)
+				}
+				if game.ModerationStatus != tt.status {
+					t.Errorf("status %s, want %s", game.ModerationStatus, tt.status)
+				}
+				groups, err := store.ListGroupsForGame(ctx, gameID)
+				if err != nil {
+					t.Fatalf("ListGroupsForGame: %v", err)
+				}
+				if len(groups) != req.GroupCount {
+					t.Errorf("saved %d groups, want %d", len(groups), req.GroupCount)
+				}
+				for _, group := range groups {
+					tiles, err := store.GetTilesForGroup(ctx, group.ID)
+					if err != nil {
+						t.Fatalf("GetTilesForGroup: %v", err)
+					}
+					if len(tiles) != req.GroupSize {
+						t.Errorf("group %d has %d tiles, want %d", group.Tier, len(tiles), req.GroupSize)
+					}
+				}
+			})
+		}
+	})
+}
+
+func TestFetchTilesForGame(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+
+		published := testutil.Seed(t, games, store, testutil.NewGame())
+		small := testutil.Seed(t, games, store, testutil.NewGame().Size(2, 3))
+		pending, err := games.CreateGame(ctx, testutil.NewGame().Request())
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheduled := testutil.Seed(t, games, store, testutil.NewGame())
+		if _, err := store.UpsertDailyPuzzle(ctx, db.UpsertDailyPuzzleParams{
+			PuzzleDate: games.Today().AddDate(0, 0, 1),
+			GameID:     scheduled.ID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name      string
+			gameID    int64
+			count     int
+			size      int
+			wantError error
+		}{
+			{name: "published", gameID: published.ID, count: 4, size: 4},
+			{name: "two groups of three", gameID: small.ID, count: 2, size: 3},
+			{name: "awaiting moderation", gameID: pending, wantError: service.ErrGameNotFound},
+			{name: "future daily puzzle", gameID: scheduled.ID, wantError: service.ErrGameNotFound},
+			{name: "unknown", gameID: published.ID + 1000, wantError: service.ErrGameNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				board, err := games.FetchTilesForGame(ctx, tt.gameID)
+				if tt.wantError != nil {
+					if !errors.Is(err, tt.wantError) {
+						t.Fatalf("got %v, want %v", err, tt.wantError)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("FetchTilesForGame: %v", err)
+				}
+				if board.GroupCount != tt.count || board.GroupSize != tt.size {
+					t.Errorf("board is %dx%d, want %dx%d", board.GroupCount, board.GroupSize, tt.count, tt.size)
+				}
+				if len(board.Tiles) != tt.count*tt.size {
+					t.Errorf("got %d tiles, want %d", len(board.Tiles), tt.count*tt.size)
+				}
+				seen := make(map[string]bool)
+				for _, tile := range board.Tiles {
+					if seen[tile.Token] {
+						t.Errorf("token %s appears twice", tile.Token)
+					}
+					seen[tile.Token] = true
+				}
+			})
+		}
+	})
+}
+
+func TestCheckTileSelection(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		game := testutil.Seed(t, games, store, testutil.NewGame())
+		other := testutil.Seed(t, games, store, testutil.NewGame())
+		board := publicBoard(t, games, game.ID)
+		first := testutil.Tokens(t, board, game.Groups[0].Titles...)
+		second := testutil.Tokens(t, board, game.Groups[1].Titles...)
+		borrowed := testutil.Tokens(t, publicBoard(t, games, other.ID), other.Groups[0].Titles...)
+
+		tests := []struct {
+			name      string
+			gameID    int64
+			tiles     []string
+			want      models.CheckTilesResponse
+			wantError error
+		}{
+			{
+				name:   "whole group",
+				gameID: game.ID,
+				tiles:  first,
+				want:   models.CheckTilesResponse{Correct: true, LinkText: "Link 1", Tier: 1},
+			},
+			{
+				name:   "whole group in another order",
+				gameID: game.ID,
+				tiles:  []string{second[3], second[1], second[0], second[2]},
+				want:   models.CheckTilesResponse{Correct: true, LinkText: "Link 2", Tier: 2},
+			},
+			{
+				name:   "tiles from two groups",
+				gameID: game.ID,
+				tiles:  []string{first[0], first[1], first[2], second[0]},
+				want:   models.CheckTilesResponse{Correct: false},
+			},
+			{
+				name:      "another game's group",
+				gameID:    game.ID,
+				tiles:     borrowed,
+				wantError: service.ErrInvalidSelection,
+			},
+			{
+				name:      "one tile from another game",
+				gameID:    game.ID,
+				tiles:     []string{first[0], first[1], first[2], borrowed[0]},
+				wantError: service.ErrInvalidSelection,
+			},
+			{
+				name:      "made up token",
+				gameID:    game.ID,
+				tiles:     []string{first[0], first[1], first[2], "AAAAAAAAAAAAAAAA"},
+				wantError: service.ErrInvalidSelection,
+			},
+			{
+				name:      "repeated tile",
+				gameID:    game.ID,
+				tiles:     []string{first[0], first[1], first[2], first[2]},
+				wantError: service.ErrInvalidSelection,
+			},
+			{
+				name:      "too few tiles",
+				gameID:    game.ID,
+				tiles:     first[:3],
+				wantError: service.ErrInvalidSelection,
+			},
+			{
+				name:      "unknown game",
+				gameID:    game.ID + 1000,
+				tiles:     first,
+				wantError: service.ErrGameNotFound,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := games.CheckTileSelection(ctx, tt.gameID, tt.tiles)
+				if tt.wantError != nil {
+					if !errors.Is(err, tt.wantError) {
+						t.Fatalf("got %v, want %v", err, tt.wantError)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("CheckTileSelection: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("got %+v, want %+v", got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestCheckTileSelectionForeignTiles(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		game := testutil.Seed(t, games, store, testutil.NewGame())
+		other := testutil.Seed(t, games, store, testutil.NewGame())
+
+		mine := testutil.Tokens(t, publicBoard(t, games, game.ID), game.Groups[0].Titles...)
+		theirs := testutil.Tokens(t, publicBoard(t, games, other.ID), other.Groups[0].Titles...)
+		_, err := games.CheckTileSelection(ctx, game.ID, []string{mine[0], theirs[1], mine[2], theirs[3]})
+
+		var foreign *service.ForeignTilesError
+		if !errors.As(err, &foreign) {
+			t.Fatalf("got %v, want a ForeignTilesError", err)
+		}
+		if foreign.GameID != game.ID || !slices.Equal(foreign.Tiles, []string{theirs[1], theirs[3]}) {
+			t.Errorf("got game %d tiles %v, want game %d tiles %v", foreign.GameID, foreign.Tiles, game.ID, []string{theirs[1], theirs[3]})
+		}
+	})
+}
+
+func publicBoard(t *testing.T, games *service.GameService, gameID int64) []models.BoardTile {
+	t.Helper()
+	board, err := games.FetchTilesForGame(context.Background(), gameID)
+	if err != nil {
+		t.Fatalf("FetchTilesForGame: %v", err)
+	}
+	return board.Tiles
+}
+
+func containsProblem(problems []string, want string) bool {
+	for _, problem := range problems {
+		if strings.Contains(problem, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestScheduleDaily(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		tomorrow := games.Today().AddDate(0, 0, 1)
+
+		published := testutil.Seed(t, games, store, testutil.NewGame())
+		pending, err := games.CreateGame(ctx, testutil.NewGame().Request())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name      string
+			date      time.Time
+			gameID    int64
+			wantError error
+		}{
+			{name: "published", date: tomorrow, gameID: published.ID},
+			{name: "awaiting moderation", date: tomorrow, gameID: pending, wantError: service.ErrGameNotReleased},
+			{name: "unknown", date: tomorrow, gameID: published.ID + 1000, wantError: service.ErrGameNotFound},
+			{name: "past date", date: games.Today().AddDate(0, 0, -1), gameID: published.ID, wantError: service.ErrDateInPast},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				daily, err := games.ScheduleDaily(ctx, tt.date, tt.gameID)
+				if tt.wantError != nil {
+					if !errors.Is(err, tt.wantError) {
+						t.Fatalf("got %v, want %v", err, tt.wantError)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("ScheduleDaily: %v", err)
+				}
+				if daily.GameID != tt.gameID {
+					t.Errorf("scheduled game %d, want %d", daily.GameID, tt.gameID)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+var (
+	ErrNoHintsLeft      = errors.New("every hint has already been given")
+	ErrHintUnaffordable = errors.New("not enough mistakes left to pay for a hint")
+)
+
+// hintKinds is the order hints escalate in on each group.
+var hintKinds = []db.HintKind{db.HintKindTile, db.HintKindInitial, db.HintKindLink}
+
+// SessionHint is a hint as its player sees it. Tier says which group it is
+// about; the rest depends on the kind.
+type SessionHint struct {
+	Kind    string            `json:"kind"`
+	Tier    int               `json:"tier"`
+	Tile    *models.BoardTile `json:"tile,omitempty"`
+	Initial string            `json:"initial,omitempty"`
+	Link    string            `json:"link,omitempty"`
+}
+
+type HintResult struct {
+	Hint    SessionHint  `json:"hint"`
+	Session SessionState `json:"session"`
+}
+
+// TakeHint gives the next hint on the easiest group the player hasn't
+// solved: first one of its tiles, then the initial of its link, then the
+// whole link. Once a group has had every hint, hints move on to the next
+// group. The hint is paid for as the game says, but never with the last
+// mistake.
+//
+// Asking twice at once gives the same hint, paid for once.
+func (s *SessionService) TakeHint(ctx context.Context, playerID string, sessionID int64) (HintResult, error) {
+	session, err := s.session(ctx, playerID, sessionID)
+	if err != nil {
+		return HintResult{}, err
+	}
+	if session.Status != db.SessionStatusInProgress {
+		return HintResult{}, ErrSessionFinished
+	}
+
+	game, err := s.store.GetGame(ctx, session.GameID)
+	if err != nil {
+		return HintResult{}, err
+	}
+	group, kind, err := s.nextHint(ctx, session)
+	if err != nil {
+		return HintResult{}, err
+	}
+
+	var params db.UseHintParams
+	cost := hintCost(game, kind)
+	switch game.HintPenalty {
+	case db.HintPenaltyMistake:
+		if int(session.Mistakes)+cost >= MaxMistakes {
+			return HintResult{}, ErrHintUnaffordable
+		}
+		params.Mistakes = int32(cost)
+	default:
+		params.Points = int32(cost)
+	}
+
+	var tileID sql.NullInt64
+	if kind == db.HintKindTile {
+		board, err := s.games.board(ctx, session.GameID, session.TileKey)
+		if err != nil {
+			return HintResult{}, err
+		}
+		// The board is in token order, so which tile is given away says
+		// nothing about the others
+		for _, tile := range board.tiles {
+			if tile.GroupID == group.ID {
+				tileID = sql.NullInt64{Int64: tile.ID, Valid: true}
+				break
+			}
+		}
+	}
+
+	hint, err := s.store.CreateHint(ctx, db.CreateHintParams{
+		SessionID: session.ID,
+		GroupID:   group.ID,
+		Kind:      kind,
+		TileID:    tileID,
+	})
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &pqErr) && pqErr.Code == "23505":
+		// Another request gave this hint first, and paid for it
+	case err != nil:
+		log.Printf("unable to record hint for session %d: %v", session.ID, err)
+		return HintResult{}, err
+	default:
+		params.ID = session.ID
+		params.MaxMistakes = MaxMistakes
+		_, err = s.store.UseHint(ctx, params)
+		if err == sql.ErrNoRows {
+			// The session finished, or guesses made since it was read left
+			// too few mistakes to pay with, so the hint was never given
+			if err := s.store.DeleteHint(ctx, hint.ID); err != nil {
+				log.Printf("unable to delete hint %d: %v", hint.ID, err)
+			}
+			return HintResult{}, s.refusal(ctx, playerID, sessionID)
+		}
+		if err != nil {
+			log.Printf("unable to charge hint to session %d: %v", sessionID, err)
+			return HintResult{}, err
+		}
+	}
+
+	state, err := s.GetSession(ctx, playerID, sessionID)
+	if err != nil {
+		return HintResult{}, err
+	}
+	for _, hint := range state.Hints {
+		if hint.Kind == string(kind) && hint.Tier == int(group.Tier) {
+			return HintResult{Hint: hint, Session: state}, nil
+		}
+	}
+	// The request that gave it first couldn't pay for it
+	return HintResult{}, s.refusal(ctx, playerID, sessionID)
+}
+
+// refusal says why a hint couldn't be paid for.
+func (s *SessionService) refusal(ctx context.Context, playerID string, sessionID int64) error {
+	session, err := s.session(ctx, playerID, sessionID)
+	switch {
+	case err != nil:
+		return err
+	case session.Status != db.SessionStatusInProgress:
+		return ErrSessionFinished
+	default:
+		return ErrHintUnaffordable
+	}
+}
+
+// nextHint picks the group and kind of the session's next hint.
+func (s *SessionService) nextHint(ctx context.Context, session db.Session) (db.Group, db.HintKind, error) {
+	groups, err := s.store.ListGroupsForGame(ctx, session.GameID)
+	if err != nil {
+		log.Printf("unable to fetch groups for game %d: %v", session.GameID, err)
+		return db.Group{}, "", err
+	}
+	guesses, err := s.store.ListGuessesForSession(ctx, session.ID)
+	if err != nil {
+		log.Printf("unable to fetch guesses for session %d: %v", session.ID, err)
+		return db.Group{}, "", err
+	}
+	hints, err := s.store.ListHintsForSession(ctx, session.ID)
+	if err != nil {
+		log.Printf("unable to fetch hints for session %d: %v", session.ID, err)
+		return db.Group{}, "", err
+	}
+
+	solved := make(map[int64]bool)
+	for _, guess := range guesses {
+		if guess.Correct && guess.GroupID.Valid {
+			solved[guess.GroupID.Int64] = true
+		}
+	}
+	given := make(map[int64]int)
+	for _, hint := range hints {
+		given[hint.GroupID]++
+	}
+
+	// Groups are listed easiest first
+	for _, group := range groups {
+		if !solved[group.ID] && given[group.ID] < len(hintKinds) {
+			return group, hintKinds[given[group.ID]], nil
+		}
+	}
+	return db.Group{}, "", ErrNoHintsLeft
+}
+
+// showHint reveals what the hint gives away.
+func showHint(hint db.Hint, group db.Group, board *tileBoard) SessionHint {
+	shown := SessionHint{Kind: string(hint.Kind), Tier: int(group.Tier)}
+	switch hint.Kind {
+	case db.HintKindTile:
+		if hint.TileID.Valid {
+			shown.Tile = &board.showIDs([]int64{hint.TileID.Int64})[0]
+		}
+	case db.HintKindInitial:
+		link := strings.TrimSpace(group.Link)
+		initial, _ := utf8.DecodeRuneInString(link)
+		if initial != utf8.RuneError {
+			shown.Initial = string(unicode.ToUpper(initial))
+		}
+	case db.HintKindLink:
+		shown.Link = group.Link
+	}
+	return shown
+}
+
+func hintCost(game db.Game, kind db.HintKind) int {
+	switch kind {
+	case db.HintKindTile:
+		return int(game.HintTileCost)
+	case db.HintKindInitial:
+		return int(game.HintInitialCost)
+	default:
+		return int(game.HintLinkCost)
+	}
+}
+
+// hintCosts is the game's hint configuration as it is given to CreateGame.
+func hintCosts(game db.Game) *models.HintCosts {
+	return &models.HintCosts{
+		Penalty: string(game.HintPenalty),
+		Tile:    int(game.HintTileCost),
+		Initial: int(game.HintInitialCost),
+		Link:    int(game.HintLinkCost),
+	}
+}
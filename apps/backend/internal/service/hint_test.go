@@ -0,0 +1,190 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestTakeHint(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		game := testutil.Seed(t, games, store, testutil.NewGame())
+
+		session, err := sessions.StartSession(ctx, "alice", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.HintCosts != models.DefaultHintCosts {
+			t.Errorf("got hint costs %+v, want the defaults", session.HintCosts)
+		}
+
+		// Hints escalate on the easiest group
+		var hints []service.SessionHint
+		for range 3 {
+			result, err := sessions.TakeHint(ctx, "alice", session.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hints = append(hints, result.Hint)
+		}
+		if hints[0].Kind != "tile" || hints[0].Tier != 1 || hints[0].Tile == nil || !slices.Contains(game.Groups[0].Titles, hints[0].Tile.Title) {
+			t.Errorf("got first hint %+v, want a tile from group 1", hints[0])
+		}
+		if !slices.Contains(session.Tiles, *hints[0].Tile) {
+			t.Errorf("hinted tile %+v is not on the session's board", *hints[0].Tile)
+		}
+		if hints[1].Kind != "initial" || hints[1].Initial != "L" {
+			t.Errorf("got second hint %+v, want the initial L", hints[1])
+		}
+		if hints[2].Kind != "link" || hints[2].Link != game.Groups[0].Link {
+			t.Errorf("got third hint %+v, want the link", hints[2])
+		}
+
+		// Solved groups are skipped
+		if _, err := sessions.SubmitGuess(ctx, "alice", session.ID, testutil.Tokens(t, session.Tiles, game.Groups[1].Titles...)); err != nil {
+			t.Fatal(err)
+		}
+		result, err := sessions.TakeHint(ctx, "alice", session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Hint.Kind != "tile" || result.Hint.Tier != 3 {
+			t.Errorf("got hint %+v after solving group 2, want a tile from group 3", result.Hint)
+		}
+		if got := result.Session.HintPoints; got != 50+100+200+50 {
+			t.Errorf("hints cost %d points, want 400", got)
+		}
+		if result.Session.Mistakes != 0 || len(result.Session.Hints) != 4 {
+			t.Errorf("got %d mistakes and %d hints, want 0 and 4", result.Session.Mistakes, len(result.Session.Hints))
+		}
+
+		for _, group := range []int{0, 2, 3} {
+			if _, err := sessions.SubmitGuess(ctx, "alice", session.ID, testutil.Tokens(t, session.Tiles, game.Groups[group].Titles...)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := sessions.TakeHint(ctx, "alice", session.ID); !errors.Is(err, service.ErrSessionFinished) {
+			t.Errorf("hint after finishing: got %v, want ErrSessionFinished", err)
+		}
+
+		share, err := sessions.Share(ctx, "alice", session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(share.Text, "Solved with 0 mistakes and 4 hints") {
+			t.Errorf("share doesn't mention hints:\n%s", share.Text)
+		}
+		rows := strings.Split(share.Text, "\n")
+		if want := []string{"💡", "💡", "💡", "🟩🟩🟩🟩", "💡", "🟨🟨🟨🟨"}; !slices.Equal(rows[2:8], want) {
+			t.Errorf("got share grid %q, want hints between the guesses", rows[2:8])
+		}
+	})
+}
+
+func TestTakeHintCostingMistakes(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewMemoryStore()
+	games := newGameService(store)
+	sessions := newSessionService(store, games)
+	game := testutil.Seed(t, games, store, testutil.NewGame().Size(2, 2).Hints("mistake", 1, 1, 2))
+
+	session, err := sessions.StartSession(ctx, "alice", game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []int{1, 2} {
+		result, err := sessions.TakeHint(ctx, "alice", session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Session.Mistakes != want || result.Session.HintPoints != 0 {
+			t.Errorf("got %d mistakes and %d points, want %d and 0", result.Session.Mistakes, result.Session.HintPoints, want)
+		}
+	}
+	// The link would use up the last two mistakes
+	if _, err := sessions.TakeHint(ctx, "alice", session.ID); !errors.Is(err, service.ErrHintUnaffordable) {
+		t.Errorf("got %v, want ErrHintUnaffordable", err)
+	}
+}
+
+func TestConcurrentHintsAndGuesses(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		game := testutil.Seed(t, games, store, testutil.NewGame().Hints("mistake", 1, 1, 1))
+
+		// With two mistakes made, a wrong guess and a hint at once can't
+		// both be paid for: whichever lands second loses the game or is
+		// refused, and the session never stays in play with no mistakes left
+		for i := range 20 {
+			player := "player-" + strconv.Itoa(i)
+			session, err := sessions.StartSession(ctx, player, game.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j := range 2 {
+				guess(t, sessions, player, session, wrongSet(game, j)...)
+			}
+
+			var wg sync.WaitGroup
+			var hintErr error
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, hintErr = sessions.TakeHint(ctx, player, session.ID)
+			}()
+			go func() {
+				defer wg.Done()
+				sessions.SubmitGuess(ctx, player, session.ID, testutil.Tokens(t, session.Tiles, wrongSet(game, 2)...))
+			}()
+			wg.Wait()
+
+			state, err := sessions.GetSession(ctx, player, session.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.Status == "in_progress" && state.Mistakes >= service.MaxMistakes {
+				t.Fatalf("session %d is still in play with %d mistakes", session.ID, state.Mistakes)
+			}
+			if hintErr != nil && !errors.Is(hintErr, service.ErrHintUnaffordable) && !errors.Is(hintErr, service.ErrSessionFinished) {
+				t.Errorf("hint: got %v, want it given or refused", hintErr)
+			}
+			if hintErr != nil && len(state.Hints) != 0 {
+				t.Errorf("refused hint was still given: %+v", state.Hints)
+			}
+		}
+	})
+}
+
+func TestTakeHintRunsOut(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewMemoryStore()
+	games := newGameService(store)
+	sessions := newSessionService(store, games)
+	game := testutil.Seed(t, games, store, testutil.NewGame().Size(2, 2).Hints("score", 0, 0, 0))
+
+	session, err := sessions.StartSession(ctx, "alice", game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 6 {
+		if _, err := sessions.TakeHint(ctx, "alice", session.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sessions.TakeHint(ctx, "alice", session.ID); !errors.Is(err, service.ErrNoHintsLeft) {
+		t.Errorf("got %v, want ErrNoHintsLeft", err)
+	}
+}
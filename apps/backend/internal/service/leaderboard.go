@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+var ErrNotTimed = errors.New("leaderboards are only kept for timed games")
+
+const (
+	DefaultLeaderboardLimit = 25
+	MaxLeaderboardLimit     = 100
+)
+
+type LeaderboardService struct {
+	store GameStore
+	games *GameService
+}
+
+func NewLeaderboardService(store GameStore, games *GameService) *LeaderboardService {
+	return &LeaderboardService{
+		store: store,
+		games: games,
+	}
+}
+
+// LeaderboardEntry deliberately leaves out the player ID, which doubles as
+// the player's credential.
+type LeaderboardEntry struct {
+	Rank         int64     `json:"rank"`
+	SessionID    int64     `json:"session_id"`
+	Mistakes     int       `json:"mistakes"`
+	SolveSeconds float64   `json:"solve_seconds"`
+	FinishedAt   time.Time `json:"finished_at"`
+	IsMe         bool      `json:"is_me"`
+}
+
+type Leaderboard struct {
+	GameID  int64              `json:"game_id"`
+	Total   int64              `json:"total"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+	Entries []LeaderboardEntry `json:"entries"`
+	// Me is the requesting player's entry, wherever it falls in the ranking.
+	Me *LeaderboardEntry `json:"me"`
+}
+
+// Leaderboard returns one page of a timed game's ranking. playerID may be
+// empty, in which case no "me" entry is looked up.
+func (s *LeaderboardService) Leaderboard(ctx context.Context, gameID int64, playerID string, limit, offset int) (Leaderboard, error) {
+	if err := s.games.EnsureReleased(ctx, gameID); err != nil {
+		return Leaderboard{}, err
+	}
+	game, err := s.store.GetGame(ctx, gameID)
+	if err != nil {
+		return Leaderboard{}, err
+	}
+	if game.TimeLimit == db.TimeLimitUnlimited {
+		return Leaderboard{}, fmt.Errorf("%w: game %d", ErrNotTimed, gameID)
+	}
+
+	if limit <= 0 {
+		limit = DefaultLeaderboardLimit
+	}
+	limit = min(limit, MaxLeaderboardLimit)
+	offset = max(offset, 0)
+
+	total, err := s.store.CountLeaderboard(ctx, gameID)
+	if err != nil {
+		log.Printf("unable to count leaderboard for game %d: %v", gameID, err)
+		return Leaderboard{}, err
+	}
+
+	rows, err := s.store.GetLeaderboard(ctx, db.GetLeaderboardParams{
+		GameID:     gameID,
+		PageLimit:  int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		log.Printf("unable to fetch leaderboard for game %d: %v", gameID, err)
+		return Leaderboard{}, err
+	}
+
+	board := Leaderboard{
+		GameID:  gameID,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		Entries: make([]LeaderboardEntry, 0, len(rows)),
+	}
+	for _, row := range rows {
+		board.Entries = append(board.Entries, toLeaderboardEntry(row, playerID))
+	}
+
+	if playerID != "" {
+		row, err := s.store.GetLeaderboardEntryForPlayer(ctx, db.GetLeaderboardEntryForPlayerParams{
+			GameID:   gameID,
+			PlayerID: playerID,
+		})
+		switch {
+		case err == nil:
+			entry := toLeaderboardEntry(row, playerID)
+			board.Me = &entry
+		case err != sql.ErrNoRows:
+			log.Printf("unable to fetch leaderboard rank for game %d: %v", gameID, err)
+			return Leaderboard{}, err
+		}
+	}
+
+	return board, nil
+}
+
+func toLeaderboardEntry(row db.LeaderboardEntry, playerID string) LeaderboardEntry {
+	return LeaderboardEntry{
+		Rank:         row.Rank,
+		SessionID:    row.SessionID,
+		Mistakes:     int(row.Mistakes),
+		SolveSeconds: row.SolveSeconds,
+		FinishedAt:   row.FinishedAt.Time,
+		IsMe:         playerID != "" && row.PlayerID == playerID,
+	}
+}
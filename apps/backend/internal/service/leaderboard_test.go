@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestLeaderboard(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		leaderboards := service.NewLeaderboardService(store, games)
+		game := testutil.Seed(t, games, store, testutil.NewGame().TimeLimit("5"))
+		untimed := testutil.Seed(t, games, store, testutil.NewGame())
+
+		if _, err := leaderboards.Leaderboard(ctx, untimed.ID, "", 0, 0); !errors.Is(err, service.ErrNotTimed) {
+			t.Errorf("untimed game: got %v, want ErrNotTimed", err)
+		}
+
+		// Bob makes a mistake, Carol loses and Dave takes a hint, so only
+		// Alice and Bob are ranked
+		for player, mistakes := range map[string]int{"alice": 0, "bob": 1} {
+			session, err := sessions.StartSession(ctx, player, game.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			solveAll(t, sessions, player, session, game, mistakes)
+		}
+		session, err := sessions.StartSession(ctx, "carol", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lose(t, sessions, "carol", session, game)
+		session, err = sessions.StartSession(ctx, "dave", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sessions.TakeHint(ctx, "dave", session.ID); err != nil {
+			t.Fatal(err)
+		}
+		solveAll(t, sessions, "dave", session, game, 0)
+
+		board, err := leaderboards.Leaderboard(ctx, game.ID, "bob", 0, -5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if board.Total != 2 || board.Limit != service.DefaultLeaderboardLimit || board.Offset != 0 || len(board.Entries) != 2 {
+			t.Fatalf("got %+v, want both winners on the default page", board)
+		}
+		if board.Entries[0].Rank != 1 || board.Entries[0].IsMe || board.Entries[1].Rank != 2 || !board.Entries[1].IsMe || board.Entries[1].Mistakes != 1 {
+			t.Errorf("got entries %+v, want alice then bob", board.Entries)
+		}
+
+		board, err = leaderboards.Leaderboard(ctx, game.ID, "alice", 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(board.Entries) != 1 || board.Entries[0].Rank != 2 || board.Me == nil || board.Me.Rank != 1 {
+			t.Errorf("got %+v, want bob's entry and alice's rank", board)
+		}
+		board, err = leaderboards.Leaderboard(ctx, game.ID, "carol", service.MaxLeaderboardLimit+1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if board.Limit != service.MaxLeaderboardLimit || board.Me != nil {
+			t.Errorf("got limit %d and me %+v, want the limit capped and no entry for carol", board.Limit, board.Me)
+		}
+	})
+}
@@ -0,0 +1,1762 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+// MemoryStore is a GameStore that keeps everything in memory, for tests. It
+// follows the Postgres queries closely: missing rows are sql.ErrNoRows and
+// broken unique or foreign key constraints are *pq.Error with the same codes.
+//
+// Search matches whole words rather than using Postgres' stemming, and the
+// analytics views are worked out on every read, so they never need a
+// refresh.
+type MemoryStore struct {
+	mu sync.Mutex
+	memoryState
+}
+
+type memoryState struct {
+	nextID int64
+
+	games    map[int64]db.Game
+	groups   map[int64]db.Group
+	tiles    map[int64]db.Tile
+	daily    map[time.Time]db.DailyPuzzle
+	tags     map[int64]db.Tag
+	gameTags map[int64]map[int64]bool
+	reports  map[int64]db.GameReport
+	ratings  map[int64]db.Rating
+
+	collections     map[int64]db.Collection
+	collectionGames map[int64][]db.CollectionGame
+
+	sessions       map[int64]db.Session
+	guesses        map[int64]db.Guess
+	hints          map[int64]db.Hint
+	playerStats    map[string]db.PlayerStat
+	playerMistakes map[string]map[int32]int32
+	users          map[int64]db.User
+}
+
+var _ GameStore = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{memoryState: memoryState{
+		games:           make(map[int64]db.Game),
+		groups:          make(map[int64]db.Group),
+		tiles:           make(map[int64]db.Tile),
+		daily:           make(map[time.Time]db.DailyPuzzle),
+		tags:            make(map[int64]db.Tag),
+		gameTags:        make(map[int64]map[int64]bool),
+		reports:         make(map[int64]db.GameReport),
+		ratings:         make(map[int64]db.Rating),
+		collections:     make(map[int64]db.Collection),
+		collectionGames: make(map[int64][]db.CollectionGame),
+		sessions:        make(map[int64]db.Session),
+		guesses:         make(map[int64]db.Guess),
+		hints:           make(map[int64]db.Hint),
+		playerStats:     make(map[string]db.PlayerStat),
+		playerMistakes:  make(map[string]map[int32]int32),
+		users:           make(map[int64]db.User),
+	}}
+}
+
+// InTx runs fn against a copy of the store, holding the lock throughout so
+// nothing else reads or writes in between, and keeps the copy if fn succeeds.
+// fn must only use the store it is given.
+func (m *MemoryStore) InTx(ctx context.Context, fn func(store GameStore) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := &MemoryStore{memoryState: m.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	m.memoryState = tx.memoryState
+	return nil
+}
+
+func (s memoryState) clone() memoryState {
+	s.games = maps.Clone(s.games)
+	s.groups = maps.Clone(s.groups)
+	s.tiles = maps.Clone(s.tiles)
+	s.daily = maps.Clone(s.daily)
+	s.tags = maps.Clone(s.tags)
+	s.gameTags = cloneEach(s.gameTags, maps.Clone)
+	s.reports = maps.Clone(s.reports)
+	s.ratings = maps.Clone(s.ratings)
+	s.collections = maps.Clone(s.collections)
+	s.collectionGames = cloneEach(s.collectionGames, slices.Clone)
+	s.sessions = maps.Clone(s.sessions)
+	s.guesses = maps.Clone(s.guesses)
+	s.hints = maps.Clone(s.hints)
+	s.playerStats = maps.Clone(s.playerStats)
+	s.playerMistakes = cloneEach(s.playerMistakes, maps.Clone)
+	s.users = maps.Clone(s.users)
+	return s
+}
+
+func cloneEach[K comparable, V any](m map[K]V, clone func(V) V) map[K]V {
+	cloned := make(map[K]V, len(m))
+	for k, v := range m {
+		cloned[k] = clone(v)
+	}
+	return cloned
+}
+
+func (m *MemoryStore) id() int64 {
+	m.nextID++
+	return m.nextID
+}
+
+var (
+	errUniqueViolation     = &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	errForeignKeyViolation = &pq.Error{Code: "23503", Message: "insert or update violates foreign key constraint"}
+	errNegativeLimit       = &pq.Error{Code: "2201W", Message: "LIMIT must not be negative"}
+	errNegativeOffset      = &pq.Error{Code: "2201X", Message: "OFFSET must not be negative"}
+)
+
+// Games
+
+func (m *MemoryStore) CreateGame(ctx context.Context, arg db.CreateGameParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := arg.ModerationStatus
+	if status == "" {
+		status = db.ModerationStatusPending
+	}
+	now := time.Now()
+	game := db.Game{
+		ID:               m.id(),
+		Author:           arg.Author,
+		Difficulty:       arg.Column2,
+		TimeLimit:        arg.Column3,
+		GroupCount:       arg.GroupCount,
+		GroupSize:        arg.GroupSize,
+		ModerationStatus: status,
+		ModerationReason: arg.ModerationReason,
+		HintPenalty:      arg.HintPenalty,
+		HintTileCost:     arg.HintTileCost,
+		HintInitialCost:  arg.HintInitialCost,
+		HintLinkCost:     arg.HintLinkCost,
+		TileKey:          tileKey(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	m.games[game.ID] = game
+	return game.ID, nil
+}
+
+func (m *MemoryStore) GetGame(ctx context.Context, id int64) (db.Game, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	game, ok := m.games[id]
+	if !ok {
+		return db.Game{}, sql.ErrNoRows
+	}
+	return game, nil
+}
+
+// DeleteGame cascades the way the schema does.
+func (m *MemoryStore) DeleteGame(ctx context.Context, id int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[id]; !ok {
+		return 0, nil
+	}
+	delete(m.games, id)
+	delete(m.gameTags, id)
+	for groupID, group := range m.groups {
+		if group.GameID != id {
+			continue
+		}
+		delete(m.groups, groupID)
+		for tileID, tile := range m.tiles {
+			if tile.GroupID == groupID {
+				delete(m.tiles, tileID)
+			}
+		}
+	}
+	for date, daily := range m.daily {
+		if daily.GameID == id {
+			delete(m.daily, date)
+		}
+	}
+	for reportID, report := range m.reports {
+		if report.GameID == id {
+			delete(m.reports, reportID)
+		}
+	}
+	for ratingID, rating := range m.ratings {
+		if rating.GameID == id {
+			delete(m.ratings, ratingID)
+		}
+	}
+	for collectionID, games := range m.collectionGames {
+		m.collectionGames[collectionID] = slices.DeleteFunc(games, func(cg db.CollectionGame) bool { return cg.GameID == id })
+	}
+	for sessionID, session := range m.sessions {
+		if session.GameID != id {
+			continue
+		}
+		delete(m.sessions, sessionID)
+		for guessID, guess := range m.guesses {
+			if guess.SessionID == sessionID {
+				delete(m.guesses, guessID)
+			}
+		}
+	}
+	return 1, nil
+}
+
+func (m *MemoryStore) GetReleasedGames(ctx context.Context, arg db.GetReleasedGamesParams) ([]db.GetReleasedGamesRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.GetReleasedGamesRow
+	for _, game := range m.sortedGames() {
+		if !m.released(game, arg.Today) {
+			continue
+		}
+		tags := m.tagsForGame(game.ID)
+		if arg.Tag != "" && !slices.Contains(tags, arg.Tag) {
+			continue
+		}
+		rating := m.gameRating(game.ID)
+		rows = append(rows, db.GetReleasedGamesRow{
+			ID:           game.ID,
+			Author:       game.Author,
+			Difficulty:   game.Difficulty,
+			CreatedAt:    game.CreatedAt,
+			Tags:         tags,
+			Ratings:      rating.Ratings,
+			AverageStars: rating.AverageStars,
+			TooEasy:      rating.TooEasy,
+			TooHard:      rating.TooHard,
+			Unfair:       rating.Unfair,
+		})
+	}
+
+	// Stars start at 1, so unrated games come last as they do in Postgres
+	if arg.Sort == "rating" {
+		slices.SortStableFunc(rows, func(a, b db.GetReleasedGamesRow) int {
+			return cmp.Or(cmp.Compare(b.AverageStars, a.AverageStars), cmp.Compare(b.Ratings, a.Ratings))
+		})
+	}
+	return rows, nil
+}
+
+func (m *MemoryStore) IsGameEmbargoed(ctx context.Context, arg db.IsGameEmbargoedParams) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.embargoed(arg.GameID, arg.Today), nil
+}
+
+func (m *MemoryStore) SetModerationStatus(ctx context.Context, arg db.SetModerationStatusParams) (db.Game, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	game, ok := m.games[arg.ID]
+	if !ok {
+		return db.Game{}, sql.ErrNoRows
+	}
+	now := time.Now()
+	game.ModerationStatus = arg.ModerationStatus
+	game.ModerationReason = arg.ModerationReason
+	game.ModeratedAt = sql.NullTime{Time: now, Valid: true}
+	game.UpdatedAt = now
+	m.games[game.ID] = game
+	return game, nil
+}
+
+// SearchGames matches groups and tiles that contain every word of the query.
+// Like the Postgres query, links only count for finished games and tiles are
+// snippeted in title order.
+func (m *MemoryStore) SearchGames(ctx context.Context, arg db.SearchGamesParams) ([]db.SearchGamesRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	words := strings.Fields(strings.ToLower(arg.Query))
+	var rows []db.SearchGamesRow
+	for _, game := range m.sortedGames() {
+		if !m.released(game, arg.Today) {
+			continue
+		}
+
+		finished := m.finished(game.ID, arg.PlayerID)
+		var links, titles []string
+		for _, group := range m.sortedGroups(game.ID) {
+			if finished && matchesAll(group.Link+" "+group.LinkTerms, words) {
+				links = append(links, highlight(group.Link, words))
+			}
+			for _, tile := range m.sortedTiles(group.ID) {
+				if matchesAll(tile.Title, words) {
+					titles = append(titles, tile.Title)
+				}
+			}
+		}
+		if len(links) == 0 && len(titles) == 0 {
+			continue
+		}
+		slices.Sort(titles)
+		for i, title := range titles {
+			titles[i] = highlight(title, words)
+		}
+
+		rows = append(rows, db.SearchGamesRow{
+			ID:          game.ID,
+			Author:      game.Author,
+			Difficulty:  game.Difficulty,
+			CreatedAt:   game.CreatedAt,
+			Relevance:   float32(len(links) + len(titles)),
+			TileSnippet: strings.Join(titles, " · "),
+			LinkSnippet: strings.Join(links, " · "),
+		})
+	}
+
+	slices.SortStableFunc(rows, func(a, b db.SearchGamesRow) int {
+		if c := cmp.Compare(b.Relevance, a.Relevance); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return page(rows, int(arg.PageLimit), int(arg.PageOffset))
+}
+
+// Moderation
+
+func (m *MemoryStore) ListGamesForModeration(ctx context.Context, moderationStatus db.ModerationStatus) ([]db.ListGamesForModerationRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.ListGamesForModerationRow
+	for _, game := range m.sortedGames() {
+		if game.ModerationStatus != moderationStatus {
+			continue
+		}
+		row := db.ListGamesForModerationRow{
+			ID:               game.ID,
+			Author:           game.Author,
+			Difficulty:       game.Difficulty,
+			ModerationStatus: game.ModerationStatus,
+			ModerationReason: game.ModerationReason,
+			ModeratedAt:      game.ModeratedAt,
+			CreatedAt:        game.CreatedAt,
+		}
+		for _, report := range m.reports {
+			if report.GameID == game.ID {
+				row.Reports++
+			}
+		}
+		rows = append(rows, row)
+	}
+	slices.SortStableFunc(rows, func(a, b db.ListGamesForModerationRow) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return rows, nil
+}
+
+func (m *MemoryStore) ReturnGameToReview(ctx context.Context, arg db.ReturnGameToReviewParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	game, ok := m.games[arg.ID]
+	if !ok || game.ModerationStatus != db.ModerationStatusPublished {
+		return nil
+	}
+	var reports int64
+	for _, report := range m.reports {
+		if report.GameID == arg.ID && report.Issued {
+			reports++
+		}
+	}
+	if reports >= arg.MinReports {
+		game.ModerationStatus = db.ModerationStatusPending
+		game.UpdatedAt = time.Now()
+		m.games[arg.ID] = game
+	}
+	return nil
+}
+
+func (m *MemoryStore) CreateGameReport(ctx context.Context, arg db.CreateGameReportParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[arg.GameID]; !ok {
+		return errForeignKeyViolation
+	}
+	for id, report := range m.reports {
+		if report.GameID == arg.GameID && report.PlayerID == arg.PlayerID {
+			report.Reason = arg.Reason
+			report.CreatedAt = time.Now()
+			m.reports[id] = report
+			return nil
+		}
+	}
+	report := db.GameReport{
+		ID:        m.id(),
+		GameID:    arg.GameID,
+		PlayerID:  arg.PlayerID,
+		Issued:    arg.Issued,
+		Reason:    arg.Reason,
+		CreatedAt: time.Now(),
+	}
+	m.reports[report.ID] = report
+	return nil
+}
+
+func (m *MemoryStore) ListGameReports(ctx context.Context, gameID int64) ([]db.ListGameReportsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reports []db.GameReport
+	for _, report := range m.reports {
+		if report.GameID == gameID {
+			reports = append(reports, report)
+		}
+	}
+	slices.SortFunc(reports, func(a, b db.GameReport) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	var rows []db.ListGameReportsRow
+	for _, report := range reports {
+		rows = append(rows, db.ListGameReportsRow{Reason: report.Reason, CreatedAt: report.CreatedAt})
+	}
+	return rows, nil
+}
+
+func (m *MemoryStore) ClearGameReports(ctx context.Context, gameID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, report := range m.reports {
+		if report.GameID == gameID {
+			delete(m.reports, id)
+		}
+	}
+	return nil
+}
+
+// Groups
+
+func (m *MemoryStore) CreateGroup(ctx context.Context, arg db.CreateGroupParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[arg.GameID]; !ok {
+		return 0, errForeignKeyViolation
+	}
+	for _, group := range m.groups {
+		if group.GameID == arg.GameID && group.Tier == arg.Tier {
+			return 0, errUniqueViolation
+		}
+	}
+
+	now := time.Now()
+	group := db.Group{
+		ID:        m.id(),
+		GameID:    arg.GameID,
+		Link:      arg.Link,
+		LinkTerms: arg.LinkTerms,
+		Tier:      arg.Tier,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.groups[group.ID] = group
+	return group.ID, nil
+}
+
+func (m *MemoryStore) GetGroup(ctx context.Context, id int64) (db.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group, ok := m.groups[id]
+	if !ok {
+		return db.Group{}, sql.ErrNoRows
+	}
+	return group, nil
+}
+
+func (m *MemoryStore) GetGroupsForGame(ctx context.Context, gameID int64) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ids []int64
+	for _, group := range m.groups {
+		if group.GameID == gameID {
+			ids = append(ids, group.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (m *MemoryStore) ListGroupsForGame(ctx context.Context, gameID int64) ([]db.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.sortedGroups(gameID), nil
+}
+
+// Tiles
+
+func (m *MemoryStore) CreateTilesForGroup(ctx context.Context, arg db.CreateTilesForGroupParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[arg.GroupID]; !ok {
+		return errForeignKeyViolation
+	}
+	if arg.DecoyGroupID.Valid {
+		if _, ok := m.groups[arg.DecoyGroupID.Int64]; !ok {
+			return errForeignKeyViolation
+		}
+	}
+
+	now := time.Now()
+	tile := db.Tile{
+		ID:           m.id(),
+		GroupID:      arg.GroupID,
+		Title:        arg.Title,
+		DecoyGroupID: arg.DecoyGroupID,
+		DecoyReason:  arg.DecoyReason,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	m.tiles[tile.ID] = tile
+	return nil
+}
+
+func (m *MemoryStore) GetTilesForGroup(ctx context.Context, groupID int64) ([]db.GetTilesForGroupRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.GetTilesForGroupRow
+	for _, tile := range m.sortedTiles(groupID) {
+		rows = append(rows, db.GetTilesForGroupRow{ID: tile.ID, Title: tile.Title})
+	}
+	return rows, nil
+}
+
+func (m *MemoryStore) GetGameTilesByIDs(ctx context.Context, arg db.GetGameTilesByIDsParams) ([]db.Tile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var tiles []db.Tile
+	for _, tile := range m.tiles {
+		if slices.Contains(arg.Ids, tile.ID) && m.groups[tile.GroupID].GameID == arg.GameID {
+			tiles = append(tiles, tile)
+		}
+	}
+	slices.SortFunc(tiles, func(a, b db.Tile) int { return cmp.Compare(a.ID, b.ID) })
+	return tiles, nil
+}
+
+func (m *MemoryStore) ListTilesForGame(ctx context.Context, gameID int64) ([]db.ListTilesForGameRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.ListTilesForGameRow
+	for _, tile := range m.gameTiles(gameID) {
+		rows = append(rows, db.ListTilesForGameRow{ID: tile.ID, GroupID: tile.GroupID, Title: tile.Title})
+	}
+	return rows, nil
+}
+
+func (m *MemoryStore) ListDecoysForGame(ctx context.Context, gameID int64) ([]db.ListDecoysForGameRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.ListDecoysForGameRow
+	for _, group := range m.sortedGroups(gameID) {
+		for _, tile := range m.sortedTiles(group.ID) {
+			decoy, ok := m.groups[tile.DecoyGroupID.Int64]
+			if !tile.DecoyGroupID.Valid || !ok {
+				continue
+			}
+			rows = append(rows, db.ListDecoysForGameRow{
+				ID:          tile.ID,
+				Title:       tile.Title,
+				Tier:        group.Tier,
+				DecoyTier:   decoy.Tier,
+				DecoyReason: tile.DecoyReason.String,
+			})
+		}
+	}
+	return rows, nil
+}
+
+// Daily puzzles
+
+func (m *MemoryStore) GetDailyPuzzle(ctx context.Context, puzzleDate time.Time) (db.DailyPuzzle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	daily, ok := m.daily[dateKey(puzzleDate)]
+	if !ok {
+		return db.DailyPuzzle{}, sql.ErrNoRows
+	}
+	return daily, nil
+}
+
+func (m *MemoryStore) ListDailyPuzzles(ctx context.Context, arg db.ListDailyPuzzlesParams) ([]db.DailyPuzzle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from, to := dateKey(arg.FromDate), dateKey(arg.ToDate)
+	var puzzles []db.DailyPuzzle
+	for date, daily := range m.daily {
+		if !date.Before(from) && !date.After(to) {
+			puzzles = append(puzzles, daily)
+		}
+	}
+	slices.SortFunc(puzzles, func(a, b db.DailyPuzzle) int { return a.PuzzleDate.Compare(b.PuzzleDate) })
+	return puzzles, nil
+}
+
+func (m *MemoryStore) UpsertDailyPuzzle(ctx context.Context, arg db.UpsertDailyPuzzleParams) (db.DailyPuzzle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[arg.GameID]; !ok {
+		return db.DailyPuzzle{}, errForeignKeyViolation
+	}
+
+	date, now := dateKey(arg.PuzzleDate), time.Now()
+	daily, ok := m.daily[date]
+	if !ok {
+		daily = db.DailyPuzzle{PuzzleDate: date, CreatedAt: now}
+	}
+	daily.GameID = arg.GameID
+	daily.UpdatedAt = now
+	m.daily[date] = daily
+	return daily, nil
+}
+
+func (m *MemoryStore) MoveDailyPuzzle(ctx context.Context, arg db.MoveDailyPuzzleParams) (db.DailyPuzzle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from, to := dateKey(arg.FromDate), dateKey(arg.ToDate)
+	daily, ok := m.daily[from]
+	if !ok {
+		return db.DailyPuzzle{}, sql.ErrNoRows
+	}
+	if _, taken := m.daily[to]; taken && to != from {
+		return db.DailyPuzzle{}, errUniqueViolation
+	}
+
+	delete(m.daily, from)
+	daily.PuzzleDate = to
+	daily.UpdatedAt = time.Now()
+	m.daily[to] = daily
+	return daily, nil
+}
+
+func (m *MemoryStore) DeleteDailyPuzzle(ctx context.Context, puzzleDate time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	date := dateKey(puzzleDate)
+	if _, ok := m.daily[date]; !ok {
+		return 0, nil
+	}
+	delete(m.daily, date)
+	return 1, nil
+}
+
+// Tags
+
+func (m *MemoryStore) ListTags(ctx context.Context) ([]db.ListTagsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.ListTagsRow
+	for _, tag := range m.tags {
+		row := db.ListTagsRow{ID: tag.ID, Name: tag.Name}
+		for _, tagIDs := range m.gameTags {
+			if tagIDs[tag.ID] {
+				row.Games++
+			}
+		}
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b db.ListTagsRow) int { return strings.Compare(a.Name, b.Name) })
+	return rows, nil
+}
+
+func (m *MemoryStore) CreateTag(ctx context.Context, name string) (db.Tag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tagNamed(name); ok {
+		return db.Tag{}, errUniqueViolation
+	}
+	tag := db.Tag{ID: m.id(), Name: name, CreatedAt: time.Now()}
+	m.tags[tag.ID] = tag
+	return tag, nil
+}
+
+func (m *MemoryStore) RenameTag(ctx context.Context, arg db.RenameTagParams) (db.Tag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tag, ok := m.tags[arg.ID]
+	if !ok {
+		return db.Tag{}, sql.ErrNoRows
+	}
+	if other, ok := m.tagNamed(arg.Name); ok && other.ID != tag.ID {
+		return db.Tag{}, errUniqueViolation
+	}
+	tag.Name = arg.Name
+	m.tags[tag.ID] = tag
+	return tag, nil
+}
+
+func (m *MemoryStore) DeleteTag(ctx context.Context, id int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tags[id]; !ok {
+		return 0, nil
+	}
+	delete(m.tags, id)
+	for _, tagIDs := range m.gameTags {
+		delete(tagIDs, id)
+	}
+	return 1, nil
+}
+
+func (m *MemoryStore) EnsureTags(ctx context.Context, names []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range names {
+		if _, ok := m.tagNamed(name); !ok {
+			tag := db.Tag{ID: m.id(), Name: name, CreatedAt: time.Now()}
+			m.tags[tag.ID] = tag
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) ClearGameTags(ctx context.Context, gameID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.gameTags, gameID)
+	return nil
+}
+
+func (m *MemoryStore) AddGameTags(ctx context.Context, arg db.AddGameTagsParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[arg.GameID]; !ok {
+		return errForeignKeyViolation
+	}
+	for _, name := range arg.Names {
+		tag, ok := m.tagNamed(name)
+		if !ok {
+			continue
+		}
+		if m.gameTags[arg.GameID] == nil {
+			m.gameTags[arg.GameID] = make(map[int64]bool)
+		}
+		m.gameTags[arg.GameID][tag.ID] = true
+	}
+	return nil
+}
+
+func (m *MemoryStore) ListTagsForGame(ctx context.Context, gameID int64) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.tagsForGame(gameID), nil
+}
+
+// Sessions, guesses and hints
+
+func (m *MemoryStore) StartSession(ctx context.Context, arg db.StartSessionParams) (db.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[arg.GameID]; !ok {
+		return db.Session{}, errForeignKeyViolation
+	}
+
+	now := time.Now()
+	for id, session := range m.sessions {
+		if session.GameID == arg.GameID && session.PlayerID == arg.PlayerID {
+			session.UpdatedAt = now
+			m.sessions[id] = session
+			return session, nil
+		}
+	}
+
+	session := db.Session{
+		ID:        m.id(),
+		GameID:    arg.GameID,
+		PlayerID:  arg.PlayerID,
+		DailyDate: arg.DailyDate,
+		Status:    db.SessionStatusInProgress,
+		StartedAt: now,
+		TileKey:   tileKey(),
+		// As Postgres defaults it
+		ScoreBreakdown: json.RawMessage("{}"),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if session.DailyDate.Valid {
+		session.DailyDate.Time = dateKey(session.DailyDate.Time)
+	}
+	m.sessions[session.ID] = session
+	return session, nil
+}
+
+func (m *MemoryStore) GetSession(ctx context.Context, id int64) (db.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return db.Session{}, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+func (m *MemoryStore) GetSessionForPlayer(ctx context.Context, arg db.GetSessionForPlayerParams) (db.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, session := range m.sessions {
+		if session.GameID == arg.GameID && session.PlayerID == arg.PlayerID {
+			return session, nil
+		}
+	}
+	return db.Session{}, sql.ErrNoRows
+}
+
+func (m *MemoryStore) AdvanceSession(ctx context.Context, arg db.AdvanceSessionParams) (db.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[arg.ID]
+	if !ok || session.Status != db.SessionStatusInProgress {
+		return db.Session{}, sql.ErrNoRows
+	}
+	session.Mistakes += arg.Mistakes
+	session.GroupsSolved += arg.GroupsSolved
+	session.UpdatedAt = time.Now()
+	m.sessions[session.ID] = session
+	return session, nil
+}
+
+func (m *MemoryStore) FinishSession(ctx context.Context, arg db.FinishSessionParams) (db.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[arg.ID]
+	if !ok || session.Status != db.SessionStatusInProgress {
+		return db.Session{}, sql.ErrNoRows
+	}
+	session.Status = arg.Status
+	session.FinishedAt = arg.FinishedAt
+	session.Score = arg.Score
+	session.ScoreBreakdown = arg.ScoreBreakdown
+	session.UpdatedAt = time.Now()
+	m.sessions[session.ID] = session
+	return session, nil
+}
+
+func (m *MemoryStore) UseHint(ctx context.Context, arg db.UseHintParams) (db.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[arg.ID]
+	if !ok || session.Status != db.SessionStatusInProgress || session.Mistakes+arg.Mistakes >= arg.MaxMistakes {
+		return db.Session{}, sql.ErrNoRows
+	}
+	session.HintsUsed++
+	session.Mistakes += arg.Mistakes
+	session.HintPoints += arg.Points
+	session.UpdatedAt = time.Now()
+	m.sessions[session.ID] = session
+	return session, nil
+}
+
+func (m *MemoryStore) CreateHint(ctx context.Context, arg db.CreateHintParams) (db.Hint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[arg.SessionID]; !ok {
+		return db.Hint{}, errForeignKeyViolation
+	}
+	if _, ok := m.groups[arg.GroupID]; !ok {
+		return db.Hint{}, errForeignKeyViolation
+	}
+	if _, ok := m.tiles[arg.TileID.Int64]; arg.TileID.Valid && !ok {
+		return db.Hint{}, errForeignKeyViolation
+	}
+	for _, hint := range m.hints {
+		if hint.SessionID == arg.SessionID && hint.GroupID == arg.GroupID && hint.Kind == arg.Kind {
+			return db.Hint{}, errUniqueViolation
+		}
+	}
+	hint := db.Hint{
+		ID:        m.id(),
+		SessionID: arg.SessionID,
+		GroupID:   arg.GroupID,
+		Kind:      arg.Kind,
+		TileID:    arg.TileID,
+		CreatedAt: time.Now(),
+	}
+	m.hints[hint.ID] = hint
+	return hint, nil
+}
+
+func (m *MemoryStore) DeleteHint(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.hints, id)
+	return nil
+}
+
+func (m *MemoryStore) ListHintsForSession(ctx context.Context, sessionID int64) ([]db.Hint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var hints []db.Hint
+	for _, hint := range m.hints {
+		if hint.SessionID == sessionID {
+			hints = append(hints, hint)
+		}
+	}
+	slices.SortFunc(hints, func(a, b db.Hint) int { return cmp.Compare(a.ID, b.ID) })
+	return hints, nil
+}
+
+func (m *MemoryStore) CreateGuess(ctx context.Context, arg db.CreateGuessParams) (db.Guess, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[arg.SessionID]; !ok {
+		return db.Guess{}, errForeignKeyViolation
+	}
+	if arg.GroupID.Valid {
+		for _, guess := range m.guesses {
+			if guess.SessionID == arg.SessionID && guess.GroupID == arg.GroupID {
+				return db.Guess{}, errUniqueViolation
+			}
+		}
+	}
+	guess := db.Guess{
+		ID:        m.id(),
+		SessionID: arg.SessionID,
+		TileIds:   slices.Clone(arg.TileIds),
+		Correct:   arg.Correct,
+		GroupID:   arg.GroupID,
+		CreatedAt: time.Now(),
+	}
+	m.guesses[guess.ID] = guess
+	return guess, nil
+}
+
+func (m *MemoryStore) DeleteGuess(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.guesses, id)
+	return nil
+}
+
+func (m *MemoryStore) ListGuessesForSession(ctx context.Context, sessionID int64) ([]db.Guess, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var guesses []db.Guess
+	for _, guess := range m.guesses {
+		if guess.SessionID == sessionID {
+			guess.TileIds = slices.Clone(guess.TileIds)
+			guesses = append(guesses, guess)
+		}
+	}
+	slices.SortFunc(guesses, func(a, b db.Guess) int { return cmp.Compare(a.ID, b.ID) })
+	return guesses, nil
+}
+
+func (m *MemoryStore) SetSessionShareCode(ctx context.Context, arg db.SetSessionShareCodeParams) (sql.NullString, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[arg.ID]
+	if !ok {
+		return sql.NullString{}, sql.ErrNoRows
+	}
+	if !session.ShareCode.Valid {
+		for _, other := range m.sessions {
+			if other.ShareCode == arg.ShareCode && arg.ShareCode.Valid {
+				return sql.NullString{}, errUniqueViolation
+			}
+		}
+		session.ShareCode = arg.ShareCode
+		m.sessions[session.ID] = session
+	}
+	return session.ShareCode, nil
+}
+
+func (m *MemoryStore) GetSessionByShareCode(ctx context.Context, shareCode sql.NullString) (db.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, session := range m.sessions {
+		if shareCode.Valid && session.ShareCode == shareCode {
+			return session, nil
+		}
+	}
+	return db.Session{}, sql.ErrNoRows
+}
+
+func (m *MemoryStore) ListFinishedSessionsForPlayer(ctx context.Context, playerID string) ([]db.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sessions []db.Session
+	for _, session := range m.sessions {
+		if session.PlayerID == playerID && session.Status != db.SessionStatusInProgress {
+			sessions = append(sessions, session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b db.Session) int {
+		return cmp.Or(a.FinishedAt.Time.Compare(b.FinishedAt.Time), cmp.Compare(a.ID, b.ID))
+	})
+	return sessions, nil
+}
+
+// Ratings
+
+func (m *MemoryStore) UpsertRating(ctx context.Context, arg db.UpsertRatingParams) (db.Rating, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[arg.GameID]; !ok {
+		return db.Rating{}, errForeignKeyViolation
+	}
+	now := time.Now()
+	rating := db.Rating{ID: m.id(), GameID: arg.GameID, PlayerID: arg.PlayerID, CreatedAt: now}
+	for _, existing := range m.ratings {
+		if existing.GameID == arg.GameID && existing.PlayerID == arg.PlayerID {
+			rating = existing
+		}
+	}
+	rating.Stars = arg.Stars
+	rating.Feedback = arg.Feedback
+	rating.Comment = arg.Comment
+	rating.UpdatedAt = now
+	m.ratings[rating.ID] = rating
+	return rating, nil
+}
+
+func (m *MemoryStore) GetRating(ctx context.Context, arg db.GetRatingParams) (db.Rating, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rating := range m.ratings {
+		if rating.GameID == arg.GameID && rating.PlayerID == arg.PlayerID {
+			return rating, nil
+		}
+	}
+	return db.Rating{}, sql.ErrNoRows
+}
+
+// Collections
+
+func (m *MemoryStore) ListCollections(ctx context.Context) ([]db.ListCollectionsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.ListCollectionsRow
+	for _, collection := range m.collections {
+		rows = append(rows, db.ListCollectionsRow{
+			ID:          collection.ID,
+			Title:       collection.Title,
+			Description: collection.Description,
+			CreatedAt:   collection.CreatedAt,
+			UpdatedAt:   collection.UpdatedAt,
+			Games:       int64(len(m.collectionGames[collection.ID])),
+		})
+	}
+	slices.SortFunc(rows, func(a, b db.ListCollectionsRow) int { return cmp.Compare(a.ID, b.ID) })
+	return rows, nil
+}
+
+func (m *MemoryStore) GetCollection(ctx context.Context, id int64) (db.Collection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	collection, ok := m.collections[id]
+	if !ok {
+		return db.Collection{}, sql.ErrNoRows
+	}
+	return collection, nil
+}
+
+func (m *MemoryStore) CreateCollection(ctx context.Context, arg db.CreateCollectionParams) (db.Collection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	collection := db.Collection{
+		ID:          m.id(),
+		Title:       arg.Title,
+		Description: arg.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	m.collections[collection.ID] = collection
+	return collection, nil
+}
+
+func (m *MemoryStore) UpdateCollection(ctx context.Context, arg db.UpdateCollectionParams) (db.Collection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	collection, ok := m.collections[arg.ID]
+	if !ok {
+		return db.Collection{}, sql.ErrNoRows
+	}
+	collection.Title = arg.Title
+	collection.Description = arg.Description
+	collection.UpdatedAt = time.Now()
+	m.collections[collection.ID] = collection
+	return collection, nil
+}
+
+func (m *MemoryStore) DeleteCollection(ctx context.Context, id int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[id]; !ok {
+		return 0, nil
+	}
+	delete(m.collections, id)
+	delete(m.collectionGames, id)
+	return 1, nil
+}
+
+func (m *MemoryStore) ClearCollectionGames(ctx context.Context, collectionID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.collectionGames, collectionID)
+	return nil
+}
+
+// AddCollectionGames appends the games in order, numbering them from 1 like
+// WITH ORDINALITY.
+func (m *MemoryStore) AddCollectionGames(ctx context.Context, arg db.AddCollectionGamesParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[arg.CollectionID]; !ok {
+		return errForeignKeyViolation
+	}
+	games := slices.Clone(m.collectionGames[arg.CollectionID])
+	for i, gameID := range arg.GameIds {
+		if _, ok := m.games[gameID]; !ok {
+			return errForeignKeyViolation
+		}
+		position := int32(i + 1)
+		for _, cg := range games {
+			if cg.GameID == gameID || cg.Position == position {
+				return errUniqueViolation
+			}
+		}
+		games = append(games, db.CollectionGame{CollectionID: arg.CollectionID, GameID: gameID, Position: position})
+	}
+	m.collectionGames[arg.CollectionID] = games
+	return nil
+}
+
+func (m *MemoryStore) ListCollectionGames(ctx context.Context, arg db.ListCollectionGamesParams) ([]db.ListCollectionGamesRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.ListCollectionGamesRow
+	for _, cg := range m.collectionGames[arg.CollectionID] {
+		game, ok := m.games[cg.GameID]
+		if !ok || !m.released(game, arg.Today) {
+			continue
+		}
+		row := db.ListCollectionGamesRow{
+			ID:         game.ID,
+			Author:     game.Author,
+			Difficulty: game.Difficulty,
+			Position:   cg.Position,
+		}
+		for _, session := range m.sessions {
+			if session.GameID == game.ID && session.PlayerID == arg.PlayerID {
+				row.Status = string(session.Status)
+			}
+		}
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b db.ListCollectionGamesRow) int { return cmp.Compare(a.Position, b.Position) })
+	return rows, nil
+}
+
+// Leaderboards
+
+func (m *MemoryStore) GetLeaderboard(ctx context.Context, arg db.GetLeaderboardParams) ([]db.LeaderboardEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := m.leaderboard(arg.GameID)
+	slices.SortFunc(entries, func(a, b db.LeaderboardEntry) int {
+		return cmp.Or(cmp.Compare(a.Rank, b.Rank), cmp.Compare(a.SessionID, b.SessionID))
+	})
+	return page(entries, int(arg.PageLimit), int(arg.PageOffset))
+}
+
+func (m *MemoryStore) CountLeaderboard(ctx context.Context, gameID int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return int64(len(m.leaderboard(gameID))), nil
+}
+
+func (m *MemoryStore) GetLeaderboardEntryForPlayer(ctx context.Context, arg db.GetLeaderboardEntryForPlayerParams) (db.LeaderboardEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, entry := range m.leaderboard(arg.GameID) {
+		if entry.PlayerID == arg.PlayerID {
+			return entry, nil
+		}
+	}
+	return db.LeaderboardEntry{}, sql.ErrNoRows
+}
+
+// Analytics
+
+func (m *MemoryStore) GetGameOutcomes(ctx context.Context, gameID int64) (db.GameOutcome, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[gameID]; !ok {
+		return db.GameOutcome{}, sql.ErrNoRows
+	}
+	outcome := db.GameOutcome{GameID: gameID}
+	var mistakes int64
+	for _, session := range m.sessions {
+		if session.GameID != gameID || session.Status == db.SessionStatusInProgress {
+			continue
+		}
+		outcome.Finished++
+		mistakes += int64(session.Mistakes)
+		if session.Status == db.SessionStatusWon {
+			outcome.Won++
+		}
+	}
+	if outcome.Finished > 0 {
+		outcome.AverageMistakes = float64(mistakes) / float64(outcome.Finished)
+	}
+	return outcome, nil
+}
+
+func (m *MemoryStore) ListGroupSolvePositions(ctx context.Context, gameID int64) ([]db.ListGroupSolvePositionsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	type key struct {
+		groupID  int64
+		position int64
+	}
+	times := make(map[key]int64)
+	for _, session := range m.sessions {
+		if session.GameID != gameID {
+			continue
+		}
+		var position int64
+		for _, guess := range m.sessionGuesses(session.ID) {
+			if guess.Correct && guess.GroupID.Valid {
+				position++
+				times[key{guess.GroupID.Int64, position}]++
+			}
+		}
+	}
+
+	var rows []db.ListGroupSolvePositionsRow
+	for k, n := range times {
+		group := m.groups[k.groupID]
+		rows = append(rows, db.ListGroupSolvePositionsRow{
+			GroupID:  group.ID,
+			Link:     group.Link,
+			Tier:     group.Tier,
+			Position: k.position,
+			Times:    n,
+		})
+	}
+	slices.SortFunc(rows, func(a, b db.ListGroupSolvePositionsRow) int {
+		return cmp.Or(cmp.Compare(a.Tier, b.Tier), cmp.Compare(a.Position, b.Position))
+	})
+	return rows, nil
+}
+
+func (m *MemoryStore) ListIncorrectSets(ctx context.Context, arg db.ListIncorrectSetsParams) ([]db.ListIncorrectSetsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.ListIncorrectSetsRow
+	for _, guess := range m.incorrectGuesses(arg.GameID) {
+		tileIDs := slices.Sorted(slices.Values(guess.TileIds))
+		i := slices.IndexFunc(rows, func(row db.ListIncorrectSetsRow) bool { return slices.Equal(row.TileIds, tileIDs) })
+		if i < 0 {
+			rows = append(rows, db.ListIncorrectSetsRow{TileIds: tileIDs})
+			i = len(rows) - 1
+		}
+		rows[i].Times++
+	}
+	slices.SortFunc(rows, func(a, b db.ListIncorrectSetsRow) int {
+		return cmp.Or(cmp.Compare(b.Times, a.Times), slices.Compare(a.TileIds, b.TileIds))
+	})
+	return page(rows, int(arg.RowLimit), 0)
+}
+
+func (m *MemoryStore) ListWrongPairings(ctx context.Context, arg db.ListWrongPairingsParams) ([]db.ListWrongPairingsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.ListWrongPairingsRow
+	for _, guess := range m.incorrectGuesses(arg.GameID) {
+		for _, a := range guess.TileIds {
+			for _, b := range guess.TileIds {
+				ta, okA := m.tiles[a]
+				tb, okB := m.tiles[b]
+				if !okA || !okB || tb.ID <= ta.ID || ta.GroupID == tb.GroupID {
+					continue
+				}
+				i := slices.IndexFunc(rows, func(row db.ListWrongPairingsRow) bool { return row.TileID == ta.ID && row.PairedTileID == tb.ID })
+				if i < 0 {
+					row := db.ListWrongPairingsRow{TileID: ta.ID, TileTitle: ta.Title, PairedTileID: tb.ID, PairedTileTitle: tb.Title}
+					switch {
+					case ta.DecoyGroupID.Valid && ta.DecoyGroupID.Int64 == tb.GroupID:
+						row.DecoyReason = ta.DecoyReason.String
+					case tb.DecoyGroupID.Valid && tb.DecoyGroupID.Int64 == ta.GroupID:
+						row.DecoyReason = tb.DecoyReason.String
+					}
+					rows = append(rows, row)
+					i = len(rows) - 1
+				}
+				rows[i].Times++
+			}
+		}
+	}
+	slices.SortFunc(rows, func(a, b db.ListWrongPairingsRow) int {
+		return cmp.Or(cmp.Compare(b.Times, a.Times), cmp.Compare(a.TileID, b.TileID), cmp.Compare(a.PairedTileID, b.PairedTileID))
+	})
+	return page(rows, int(arg.RowLimit), 0)
+}
+
+// RefreshGameOutcomes has nothing to do, as outcomes are never stale here.
+func (m *MemoryStore) RefreshGameOutcomes(ctx context.Context) error {
+	return nil
+}
+
+// RefreshGroupSolvePositions has nothing to do, as solve positions are
+// never stale here.
+func (m *MemoryStore) RefreshGroupSolvePositions(ctx context.Context) error {
+	return nil
+}
+
+// Player stats
+
+func (m *MemoryStore) GetPlayerStats(ctx context.Context, playerID string) (db.PlayerStat, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.playerStats[playerID]
+	if !ok {
+		return db.PlayerStat{}, sql.ErrNoRows
+	}
+	return stats, nil
+}
+
+func (m *MemoryStore) RecordPlayerResult(ctx context.Context, arg db.RecordPlayerResultParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := nextStats(m.playerStats[arg.PlayerID], arg.DailyDate, arg.Won)
+	stats.PlayerID = arg.PlayerID
+	stats.UpdatedAt = time.Now()
+	m.playerStats[arg.PlayerID] = stats
+	return nil
+}
+
+// nextStats applies one finished session, as RecordPlayerResult does. Only
+// daily puzzles played on their day affect the streak: a win on the day after
+// the last daily extends it, any other win starts a new one and a loss resets
+// it.
+func nextStats(stats db.PlayerStat, dailyDate sql.NullTime, won bool) db.PlayerStat {
+	stats.Played++
+	if won {
+		stats.Won++
+	}
+
+	if !dailyDate.Valid {
+		return stats
+	}
+
+	date := dateOf(dailyDate.Time)
+	switch {
+	case !won:
+		stats.CurrentStreak = 0
+	case stats.LastDailyDate.Valid && dateOf(stats.LastDailyDate.Time).AddDate(0, 0, 1).Equal(date):
+		stats.CurrentStreak++
+	default:
+		stats.CurrentStreak = 1
+	}
+	stats.MaxStreak = max(stats.MaxStreak, stats.CurrentStreak)
+	stats.LastDailyDate = sql.NullTime{Time: date, Valid: true}
+
+	return stats
+}
+
+func (m *MemoryStore) IncrementPlayerMistakes(ctx context.Context, arg db.IncrementPlayerMistakesParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.playerStats[arg.PlayerID]; !ok {
+		return errForeignKeyViolation
+	}
+	if m.playerMistakes[arg.PlayerID] == nil {
+		m.playerMistakes[arg.PlayerID] = make(map[int32]int32)
+	}
+	m.playerMistakes[arg.PlayerID][arg.Mistakes]++
+	return nil
+}
+
+func (m *MemoryStore) ListPlayerMistakes(ctx context.Context, playerID string) ([]db.ListPlayerMistakesRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rows []db.ListPlayerMistakesRow
+	for mistakes, solved := range m.playerMistakes[playerID] {
+		rows = append(rows, db.ListPlayerMistakesRow{Mistakes: mistakes, Solved: solved})
+	}
+	slices.SortFunc(rows, func(a, b db.ListPlayerMistakesRow) int { return cmp.Compare(a.Mistakes, b.Mistakes) })
+	return rows, nil
+}
+
+func (m *MemoryStore) DeletePlayerStats(ctx context.Context, playerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.playerStats, playerID)
+	delete(m.playerMistakes, playerID)
+	return nil
+}
+
+// Accounts
+
+func (m *MemoryStore) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if user.Username == arg.Username || user.PlayerID == arg.PlayerID {
+			return db.User{}, errUniqueViolation
+		}
+	}
+	user := db.User{
+		ID:           m.id(),
+		Username:     arg.Username,
+		PasswordHash: arg.PasswordHash,
+		PlayerID:     arg.PlayerID,
+		CreatedAt:    time.Now(),
+	}
+	m.users[user.ID] = user
+	return user, nil
+}
+
+func (m *MemoryStore) GetUserByUsername(ctx context.Context, username string) (db.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return db.User{}, sql.ErrNoRows
+}
+
+// LockUser has nothing to do, as InTx already runs one transaction at a time.
+func (m *MemoryStore) LockUser(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (m *MemoryStore) MoveSessions(ctx context.Context, arg db.MoveSessionsParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	owned := make(map[int64]bool)
+	for _, session := range m.sessions {
+		if session.PlayerID == arg.ToPlayer {
+			owned[session.GameID] = true
+		}
+	}
+	var moved int64
+	for id, session := range m.sessions {
+		if session.PlayerID == arg.FromPlayer && !owned[session.GameID] {
+			session.PlayerID = arg.ToPlayer
+			session.UpdatedAt = time.Now()
+			m.sessions[id] = session
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// Helpers; callers hold m.mu.
+
+func (m *MemoryStore) sortedGames() []db.Game {
+	games := make([]db.Game, 0, len(m.games))
+	for _, game := range m.games {
+		games = append(games, game)
+	}
+	slices.SortFunc(games, func(a, b db.Game) int { return cmp.Compare(a.ID, b.ID) })
+	return games
+}
+
+func (m *MemoryStore) sortedGroups(gameID int64) []db.Group {
+	var groups []db.Group
+	for _, group := range m.groups {
+		if group.GameID == gameID {
+			groups = append(groups, group)
+		}
+	}
+	slices.SortFunc(groups, func(a, b db.Group) int { return cmp.Compare(a.Tier, b.Tier) })
+	return groups
+}
+
+func (m *MemoryStore) sortedTiles(groupID int64) []db.Tile {
+	var tiles []db.Tile
+	for _, tile := range m.tiles {
+		if tile.GroupID == groupID {
+			tiles = append(tiles, tile)
+		}
+	}
+	slices.SortFunc(tiles, func(a, b db.Tile) int { return cmp.Compare(a.ID, b.ID) })
+	return tiles
+}
+
+func (m *MemoryStore) gameTiles(gameID int64) []db.Tile {
+	var tiles []db.Tile
+	for _, group := range m.sortedGroups(gameID) {
+		tiles = append(tiles, m.sortedTiles(group.ID)...)
+	}
+	slices.SortFunc(tiles, func(a, b db.Tile) int { return cmp.Compare(a.ID, b.ID) })
+	return tiles
+}
+
+func (m *MemoryStore) released(game db.Game, today time.Time) bool {
+	return game.ModerationStatus == db.ModerationStatusPublished && !m.embargoed(game.ID, today)
+}
+
+func (m *MemoryStore) embargoed(gameID int64, today time.Time) bool {
+	var first time.Time
+	for date, daily := range m.daily {
+		if daily.GameID == gameID && (first.IsZero() || date.Before(first)) {
+			first = date
+		}
+	}
+	return !first.IsZero() && first.After(dateKey(today))
+}
+
+func (m *MemoryStore) finished(gameID int64, playerID string) bool {
+	for _, session := range m.sessions {
+		if session.GameID == gameID && session.PlayerID == playerID && session.Status != db.SessionStatusInProgress {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionGuesses lists a session's guesses in the order they were made.
+func (m *MemoryStore) sessionGuesses(sessionID int64) []db.Guess {
+	var guesses []db.Guess
+	for _, guess := range m.guesses {
+		if guess.SessionID == sessionID {
+			guesses = append(guesses, guess)
+		}
+	}
+	slices.SortFunc(guesses, func(a, b db.Guess) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return guesses
+}
+
+func (m *MemoryStore) incorrectGuesses(gameID int64) []db.Guess {
+	var guesses []db.Guess
+	for _, guess := range m.guesses {
+		if !guess.Correct && m.sessions[guess.SessionID].GameID == gameID {
+			guesses = append(guesses, guess)
+		}
+	}
+	slices.SortFunc(guesses, func(a, b db.Guess) int { return cmp.Compare(a.ID, b.ID) })
+	return guesses
+}
+
+// gameRating follows the game_ratings view.
+func (m *MemoryStore) gameRating(gameID int64) db.GameRating {
+	summary := db.GameRating{GameID: gameID}
+	var stars int64
+	for _, rating := range m.ratings {
+		if rating.GameID != gameID {
+			continue
+		}
+		summary.Ratings++
+		stars += int64(rating.Stars)
+		switch rating.Feedback.RatingFeedback {
+		case db.RatingFeedbackTooEasy:
+			summary.TooEasy++
+		case db.RatingFeedbackTooHard:
+			summary.TooHard++
+		case db.RatingFeedbackUnfair:
+			summary.Unfair++
+		}
+	}
+	if summary.Ratings > 0 {
+		summary.AverageStars = float64(stars) / float64(summary.Ratings)
+	}
+	return summary
+}
+
+// leaderboard follows the leaderboard_entries view: hintless wins of a timed
+// game inside its limit, ranked by mistakes, then solve time, then finish.
+func (m *MemoryStore) leaderboard(gameID int64) []db.LeaderboardEntry {
+	game, ok := m.games[gameID]
+	if !ok || game.TimeLimit == db.TimeLimitUnlimited {
+		return nil
+	}
+	minutes, err := strconv.Atoi(string(game.TimeLimit))
+	if err != nil {
+		return nil
+	}
+
+	var sessions []db.Session
+	for _, session := range m.sessions {
+		elapsed := session.FinishedAt.Time.Sub(session.StartedAt)
+		if session.GameID == gameID && session.Status == db.SessionStatusWon && session.HintsUsed == 0 &&
+			elapsed <= time.Duration(minutes)*time.Minute {
+			sessions = append(sessions, session)
+		}
+	}
+	better := func(a, b db.Session) int {
+		return cmp.Or(
+			cmp.Compare(a.Mistakes, b.Mistakes),
+			cmp.Compare(a.FinishedAt.Time.Sub(a.StartedAt), b.FinishedAt.Time.Sub(b.StartedAt)),
+			a.FinishedAt.Time.Compare(b.FinishedAt.Time),
+		)
+	}
+
+	entries := make([]db.LeaderboardEntry, 0, len(sessions))
+	for _, session := range sessions {
+		entry := db.LeaderboardEntry{
+			SessionID:    session.ID,
+			GameID:       gameID,
+			PlayerID:     session.PlayerID,
+			Mistakes:     session.Mistakes,
+			SolveSeconds: session.FinishedAt.Time.Sub(session.StartedAt).Seconds(),
+			FinishedAt:   session.FinishedAt,
+			Rank:         1,
+		}
+		for _, other := range sessions {
+			if better(other, session) < 0 {
+				entry.Rank++
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (m *MemoryStore) tagNamed(name string) (db.Tag, bool) {
+	for _, tag := range m.tags {
+		if tag.Name == name {
+			return tag, true
+		}
+	}
+	return db.Tag{}, false
+}
+
+func (m *MemoryStore) tagsForGame(gameID int64) []string {
+	names := []string{}
+	for tagID := range m.gameTags[gameID] {
+		names = append(names, m.tags[tagID].Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// dateKey drops the time of day, as a Postgres DATE column would.
+func dateKey(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func matchesAll(text string, words []string) bool {
+	if len(words) == 0 {
+		return false
+	}
+	fields := strings.Fields(strings.ToLower(text))
+	for _, word := range words {
+		if !slices.Contains(fields, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func highlight(text string, words []string) string {
+	fields := strings.Fields(text)
+	for i, field := range fields {
+		if slices.Contains(words, strings.ToLower(field)) {
+			fields[i] = "**" + field + "**"
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+func page[T any](rows []T, limit, offset int) ([]T, error) {
+	switch {
+	case limit < 0:
+		return nil, errNegativeLimit
+	case offset < 0:
+		return nil, errNegativeOffset
+	case offset >= len(rows):
+		return nil, nil
+	}
+	rows = rows[offset:]
+	if limit > 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+func TestMemoryStoreMissingRows(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if _, err := store.GetGame(ctx, 1); err != sql.ErrNoRows {
+		t.Errorf("GetGame: got %v, want sql.ErrNoRows", err)
+	}
+	if _, err := store.GetSession(ctx, 1); err != sql.ErrNoRows {
+		t.Errorf("GetSession: got %v, want sql.ErrNoRows", err)
+	}
+	if _, err := store.GetCollection(ctx, 1); err != sql.ErrNoRows {
+		t.Errorf("GetCollection: got %v, want sql.ErrNoRows", err)
+	}
+	if _, err := store.GetRating(ctx, db.GetRatingParams{GameID: 1, PlayerID: "alice"}); err != sql.ErrNoRows {
+		t.Errorf("GetRating: got %v, want sql.ErrNoRows", err)
+	}
+	if rows, err := store.DeleteGame(ctx, 1); err != nil || rows != 0 {
+		t.Errorf("DeleteGame: got %d, %v, want 0 rows", rows, err)
+	}
+}
+
+func TestMemoryStoreConstraints(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	gameID, err := store.CreateGame(ctx, db.CreateGameParams{Author: "a", GroupCount: 2, GroupSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateGroup(ctx, db.CreateGroupParams{GameID: gameID, Tier: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var pqErr *pq.Error
+	_, err = store.CreateGroup(ctx, db.CreateGroupParams{GameID: gameID, Tier: 1})
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		t.Errorf("duplicate tier: got %v, want unique violation", err)
+	}
+	_, err = store.CreateGroup(ctx, db.CreateGroupParams{GameID: gameID + 100, Tier: 1})
+	if !errors.As(err, &pqErr) || pqErr.Code != "23503" {
+		t.Errorf("unknown game: got %v, want foreign key violation", err)
+	}
+
+	today := dateKey(time.Now())
+	for _, date := range []time.Time{today, today.AddDate(0, 0, 1)} {
+		if _, err := store.UpsertDailyPuzzle(ctx, db.UpsertDailyPuzzleParams{PuzzleDate: date, GameID: gameID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = store.MoveDailyPuzzle(ctx, db.MoveDailyPuzzleParams{FromDate: today, ToDate: today.AddDate(0, 0, 1)})
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		t.Errorf("move onto taken date: got %v, want unique violation", err)
+	}
+
+	collection, err := store.CreateCollection(ctx, db.CreateCollectionParams{Title: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.AddCollectionGames(ctx, db.AddCollectionGamesParams{CollectionID: collection.ID, GameIds: []int64{gameID, gameID}})
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		t.Errorf("game twice in a collection: got %v, want unique violation", err)
+	}
+	err = store.AddCollectionGames(ctx, db.AddCollectionGamesParams{CollectionID: collection.ID, GameIds: []int64{gameID + 100}})
+	if !errors.As(err, &pqErr) || pqErr.Code != "23503" {
+		t.Errorf("unknown game in a collection: got %v, want foreign key violation", err)
+	}
+}
+
+func TestMemoryStoreSessions(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	gameID, err := store.CreateGame(ctx, db.CreateGameParams{Author: "a", GroupCount: 2, GroupSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := store.StartSession(ctx, db.StartSessionParams{GameID: gameID, PlayerID: "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := store.StartSession(ctx, db.StartSessionParams{GameID: gameID, PlayerID: "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("starting again gave session %d, want the existing %d", again.ID, first.ID)
+	}
+
+	for range 2 {
+		if _, err := store.AdvanceSession(ctx, db.AdvanceSessionParams{ID: first.ID, GroupsSolved: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	finish := db.FinishSessionParams{ID: first.ID, Status: db.SessionStatusWon}
+	finished, err := store.FinishSession(ctx, finish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finished.GroupsSolved != 2 {
+		t.Errorf("got %d groups solved, want 2", finished.GroupsSolved)
+	}
+	if _, err := store.FinishSession(ctx, finish); err != sql.ErrNoRows {
+		t.Errorf("finishing a finished session: got %v, want sql.ErrNoRows", err)
+	}
+	if _, err := store.AdvanceSession(ctx, db.AdvanceSessionParams{ID: first.ID, Mistakes: 1}); err != sql.ErrNoRows {
+		t.Errorf("advancing a finished session: got %v, want sql.ErrNoRows", err)
+	}
+
+	// Hints are never paid for with the last mistake
+	hinted, err := store.StartSession(ctx, db.StartSessionParams{GameID: gameID, PlayerID: "q"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AdvanceSession(ctx, db.AdvanceSessionParams{ID: hinted.ID, Mistakes: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UseHint(ctx, db.UseHintParams{ID: hinted.ID, Mistakes: 2, MaxMistakes: 4}); err != sql.ErrNoRows {
+		t.Errorf("hint costing the last mistake: got %v, want sql.ErrNoRows", err)
+	}
+	if hinted, err = store.UseHint(ctx, db.UseHintParams{ID: hinted.ID, Mistakes: 1, MaxMistakes: 4}); err != nil || hinted.Mistakes != 3 {
+		t.Errorf("affordable hint: got %d mistakes, %v, want 3", hinted.Mistakes, err)
+	}
+
+	code := sql.NullString{String: "ABC", Valid: true}
+	if _, err := store.SetSessionShareCode(ctx, db.SetSessionShareCodeParams{ID: first.ID, ShareCode: code}); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := store.SetSessionShareCode(ctx, db.SetSessionShareCodeParams{
+		ID:        first.ID,
+		ShareCode: sql.NullString{String: "XYZ", Valid: true},
+	})
+	if err != nil || kept != code {
+		t.Errorf("share code: got %v, %v, want the first code kept", kept, err)
+	}
+}
+
+func TestMemoryStoreInTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	record := func(store GameStore) error {
+		if err := store.RecordPlayerResult(ctx, db.RecordPlayerResultParams{PlayerID: "alice", Won: true}); err != nil {
+			return err
+		}
+		return store.IncrementPlayerMistakes(ctx, db.IncrementPlayerMistakesParams{PlayerID: "alice", Mistakes: 1})
+	}
+
+	failed := errors.New("failed")
+	err := store.InTx(ctx, func(tx GameStore) error {
+		if err := record(tx); err != nil {
+			return err
+		}
+		return failed
+	})
+	if err != failed {
+		t.Fatalf("got %v, want the error from fn", err)
+	}
+	if _, err := store.GetPlayerStats(ctx, "alice"); err != sql.ErrNoRows {
+		t.Errorf("stats after a failed transaction: got %v, want sql.ErrNoRows", err)
+	}
+	if mistakes, _ := store.ListPlayerMistakes(ctx, "alice"); len(mistakes) != 0 {
+		t.Errorf("mistakes after a failed transaction: got %+v, want none", mistakes)
+	}
+
+	if err := store.InTx(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err := store.GetPlayerStats(ctx, "alice"); err != nil || stats.Played != 1 {
+		t.Errorf("stats after a transaction: got %+v, %v, want 1 played", stats, err)
+	}
+	if mistakes, _ := store.ListPlayerMistakes(ctx, "alice"); len(mistakes) != 1 {
+		t.Errorf("mistakes after a transaction: got %+v, want 1", mistakes)
+	}
+}
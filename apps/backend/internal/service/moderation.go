@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+var (
+	ErrInvalidReason     = errors.New("a reason of at most 500 characters is required")
+	ErrInvalidModeration = errors.New("invalid moderation status")
+)
+
+const maxReasonLength = 500
+
+// ReportsForReview is how many players with server-issued IDs must report a
+// published game before it is taken down for review. Until then it stays up,
+// with its reports waiting for a moderator.
+const ReportsForReview = 3
+
+type ModerationService struct {
+	store GameStore
+	games *GameService
+}
+
+func NewModerationService(store GameStore, games *GameService) *ModerationService {
+	return &ModerationService{
+		store: store,
+		games: games,
+	}
+}
+
+type ModerationItem struct {
+	ID          int64              `json:"id"`
+	Author      string             `json:"author"`
+	Difficulty  db.DifficultyLevel `json:"difficulty_level"`
+	Status      string             `json:"status"`
+	Reason      string             `json:"reason,omitempty"`
+	Reports     int64              `json:"reports"`
+	CreatedAt   time.Time          `json:"created_at"`
+	ModeratedAt *time.Time         `json:"moderated_at,omitempty"`
+}
+
+// Review is everything a moderator needs to judge a game.
+type Review struct {
+	ModerationItem
+	Game    models.CreateGameRequest `json:"game"`
+	Reports []Report                 `json:"reports"`
+}
+
+// Report leaves out the reporting player, as moderators don't need it.
+type Report struct {
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Queue lists games in one moderation state, oldest first.
+func (s *ModerationService) Queue(ctx context.Context, status string) ([]ModerationItem, error) {
+	moderationStatus := db.ModerationStatus(strings.ToLower(status))
+	switch moderationStatus {
+	case db.ModerationStatusPending, db.ModerationStatusPublished,
+		db.ModerationStatusRejected, db.ModerationStatusFlagged:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidModeration, status)
+	}
+
+	rows, err := s.store.ListGamesForModeration(ctx, moderationStatus)
+	if err != nil {
+		log.Printf("unable to fetch moderation queue: %v", err)
+		return nil, err
+	}
+
+	items := make([]ModerationItem, 0, len(rows))
+	for _, row := range rows {
+		item := ModerationItem{
+			ID:         row.ID,
+			Author:     row.Author,
+			Difficulty: row.Difficulty,
+			Status:     string(row.ModerationStatus),
+			Reason:     row.ModerationReason,
+			Reports:    row.Reports,
+			CreatedAt:  row.CreatedAt,
+		}
+		if row.ModeratedAt.Valid {
+			item.ModeratedAt = &row.ModeratedAt.Time
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *ModerationService) Review(ctx context.Context, gameID int64) (Review, error) {
+	game, err := s.store.GetGame(ctx, gameID)
+	if err == sql.ErrNoRows {
+		return Review{}, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
+	}
+	if err != nil {
+		log.Printf("error fetching game %d: %v", gameID, err)
+		return Review{}, err
+	}
+
+	content, err := s.games.ExportGame(ctx, gameID)
+	if err != nil {
+		return Review{}, err
+	}
+	reports, err := s.store.ListGameReports(ctx, gameID)
+	if err != nil {
+		log.Printf("unable to fetch reports for game %d: %v", gameID, err)
+		return Review{}, err
+	}
+
+	review := Review{
+		ModerationItem: moderationItem(game),
+		Game:           content,
+		Reports:        make([]Report, 0, len(reports)),
+	}
+	for _, report := range reports {
+		review.Reports = append(review.Reports, Report{Reason: report.Reason, CreatedAt: report.CreatedAt})
+	}
+	review.ModerationItem.Reports = int64(len(review.Reports))
+	return review, nil
+}
+
+// Approve publishes a game and clears any reports against it. Unless the game
+// was already published, it is announced on the activity feed, or for a game
+// scheduled as a future daily, on the day it goes live.
+func (s *ModerationService) Approve(ctx context.Context, gameID int64) (ModerationItem, error) {
+	game, err := s.store.GetGame(ctx, gameID)
+	if err == sql.ErrNoRows {
+		return ModerationItem{}, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
+	}
+	if err != nil {
+		log.Printf("error fetching game %d: %v", gameID, err)
+		return ModerationItem{}, err
+	}
+
+	item, err := s.setStatus(ctx, gameID, db.ModerationStatusPublished, "")
+	if err != nil {
+		return ModerationItem{}, err
+	}
+	if err := s.store.ClearGameReports(ctx, gameID); err != nil {
+		log.Printf("unable to clear reports for game %d: %v", gameID, err)
+		return ModerationItem{}, err
+	}
+	item.Reports = 0
+
+	if game.ModerationStatus == db.ModerationStatusPublished {
+		return item, nil
+	}
+	embargoed, err := s.store.IsGameEmbargoed(ctx, db.IsGameEmbargoedParams{
+		GameID: gameID,
+		Today:  s.games.Today(),
+	})
+	if err != nil {
+		log.Printf("unable to check schedule for game %d: %v", gameID, err)
+		return ModerationItem{}, err
+	}
+	if !embargoed {
+		s.games.activity.Publish(Activity{Type: ActivityPublished, GameID: gameID, Author: item.Author})
+	}
+	return item, nil
+}
+
+// ImportGames creates each puzzle as GameService.ImportGames does, then
+// publishes the ones created. Imports come from admins or the operator, so
+// they skip the moderation queue.
+func (s *ModerationService) ImportGames(ctx context.Context, reqs []models.CreateGameRequest) []ImportResult {
+	results := s.games.ImportGames(ctx, reqs)
+	for i, result := range results {
+		if result.Status != ImportCreated {
+			continue
+		}
+		if _, err := s.Approve(ctx, result.GameID); err != nil {
+			results[i].Status = ImportFailed
+			results[i].Problems = []string{fmt.Sprintf("saved as game %d but failed to publish it", result.GameID)}
+		}
+	}
+	return results
+}
+
+func (s *ModerationService) Reject(ctx context.Context, gameID int64, reason string) (ModerationItem, error) {
+	reason, err := moderationReason(reason)
+	if err != nil {
+		return ModerationItem{}, err
+	}
+	return s.setStatus(ctx, gameID, db.ModerationStatusRejected, reason)
+}
+
+// Flag takes a game out of circulation while it gets a closer look.
+func (s *ModerationService) Flag(ctx context.Context, gameID int64, reason string) (ModerationItem, error) {
+	reason, err := moderationReason(reason)
+	if err != nil {
+		return ModerationItem{}, err
+	}
+	return s.setStatus(ctx, gameID, db.ModerationStatusFlagged, reason)
+}
+
+// Report records a player's abuse report. Once ReportsForReview players with
+// server-issued IDs have reported a published game, it goes back to the
+// pending queue. Each player has one report per game; reporting again
+// replaces the reason.
+func (s *ModerationService) Report(ctx context.Context, playerID string, gameID int64, reason string) error {
+	reason, err := moderationReason(reason)
+	if err != nil {
+		return err
+	}
+	if err := s.games.EnsureReleased(ctx, gameID); err != nil {
+		return err
+	}
+
+	err = s.store.CreateGameReport(ctx, db.CreateGameReportParams{
+		GameID:   gameID,
+		PlayerID: playerID,
+		Issued:   IsIssuedPlayer(playerID),
+		Reason:   reason,
+	})
+	if err != nil {
+		log.Printf("unable to report game %d: %v", gameID, err)
+		return err
+	}
+	err = s.store.ReturnGameToReview(ctx, db.ReturnGameToReviewParams{ID: gameID, MinReports: ReportsForReview})
+	if err != nil {
+		log.Printf("unable to return game %d to review: %v", gameID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *ModerationService) setStatus(ctx context.Context, gameID int64, status db.ModerationStatus, reason string) (ModerationItem, error) {
+	game, err := s.store.SetModerationStatus(ctx, db.SetModerationStatusParams{
+		ID:               gameID,
+		ModerationStatus: status,
+		ModerationReason: reason,
+	})
+	if err == sql.ErrNoRows {
+		return ModerationItem{}, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
+	}
+	if err != nil {
+		log.Printf("unable to moderate game %d: %v", gameID, err)
+		return ModerationItem{}, err
+	}
+	return moderationItem(game), nil
+}
+
+func moderationItem(game db.Game) ModerationItem {
+	item := ModerationItem{
+		ID:         game.ID,
+		Author:     game.Author,
+		Difficulty: game.Difficulty,
+		Status:     string(game.ModerationStatus),
+		Reason:     game.ModerationReason,
+		CreatedAt:  game.CreatedAt,
+	}
+	if game.ModeratedAt.Valid {
+		item.ModeratedAt = &game.ModeratedAt.Time
+	}
+	return item
+}
+
+func moderationReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxReasonLength {
+		return "", ErrInvalidReason
+	}
+	return reason, nil
+}
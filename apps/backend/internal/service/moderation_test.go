@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestModeration(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		moderation := service.NewModerationService(store, games)
+
+		game := testutil.Seed(t, games, store, testutil.NewGame())
+		pending, err := games.CreateGame(ctx, testutil.NewGame().Request())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		queue := func(status string) []service.ModerationItem {
+			t.Helper()
+			items, err := moderation.Queue(ctx, status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return items
+		}
+		if _, err := moderation.Queue(ctx, "lost"); !errors.Is(err, service.ErrInvalidModeration) {
+			t.Errorf("unknown status: got %v, want ErrInvalidModeration", err)
+		}
+		if items := queue("Pending"); len(items) != 1 || items[0].ID != pending {
+			t.Errorf("got pending queue %+v, want game %d", items, pending)
+		}
+
+		if err := moderation.Report(ctx, "alice", pending, "spam"); !errors.Is(err, service.ErrGameNotFound) {
+			t.Errorf("reporting an unpublished game: got %v, want ErrGameNotFound", err)
+		}
+		if err := moderation.Report(ctx, "alice", game.ID, ""); !errors.Is(err, service.ErrInvalidReason) {
+			t.Errorf("reporting without a reason: got %v, want ErrInvalidReason", err)
+		}
+
+		// The game stays up until enough players with issued IDs report it.
+		// Players who chose their own ID, or report twice, don't count.
+		reporters := []string{"alice", "anon-1", "anon-1", "mallory"}
+		for i := 2; i < service.ReportsForReview; i++ {
+			reporters = append(reporters, "user-"+strconv.Itoa(i))
+		}
+		for _, player := range reporters {
+			if err := moderation.Report(ctx, player, game.ID, "spam"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if items := queue("published"); len(items) != 1 || items[0].Reports != int64(len(reporters)-1) {
+			t.Errorf("got published queue %+v, want game %d still up with its reports", items, game.ID)
+		}
+		if err := games.EnsureReleased(ctx, game.ID); err != nil {
+			t.Errorf("reported game: got %v, want it still playable", err)
+		}
+		if err := moderation.Report(ctx, "anon-last", game.ID, "offensive"); err != nil {
+			t.Fatal(err)
+		}
+		if items := queue("pending"); len(items) != 2 || items[0].ID != game.ID || items[0].Reports != int64(len(reporters)) {
+			t.Errorf("got pending queue %+v, want game %d first with its reports", items, game.ID)
+		}
+		if err := moderation.Report(ctx, "anon-late", game.ID, "offensive"); !errors.Is(err, service.ErrGameNotFound) {
+			t.Errorf("reporting a game under review: got %v, want ErrGameNotFound", err)
+		}
+
+		review, err := moderation.Review(ctx, game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(review.Reports) != len(reporters) || review.Reports[0].Reason != "spam" || len(review.Game.Groups) != 4 {
+			t.Errorf("got review %+v, want the game and its reports", review)
+		}
+
+		item, err := moderation.Approve(ctx, game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Status != string(db.ModerationStatusPublished) || item.Reports != 0 || item.ModeratedAt == nil {
+			t.Errorf("approved %+v, want it published without reports", item)
+		}
+		if items := queue("published"); len(items) != 1 || items[0].Reports != 0 {
+			t.Errorf("got published queue %+v, want the game with its reports cleared", items)
+		}
+
+		if _, err := moderation.Reject(ctx, pending, " "); !errors.Is(err, service.ErrInvalidReason) {
+			t.Errorf("rejecting without a reason: got %v, want ErrInvalidReason", err)
+		}
+		if item, err := moderation.Reject(ctx, pending, "duplicate"); err != nil || item.Reason != "duplicate" {
+			t.Errorf("rejecting: got %+v, %v", item, err)
+		}
+		if _, err := moderation.Flag(ctx, pending+1000, "spam"); !errors.Is(err, service.ErrGameNotFound) {
+			t.Errorf("flagging an unknown game: got %v, want ErrGameNotFound", err)
+		}
+		if items := queue("rejected"); len(items) != 1 || items[0].ID != pending {
+			t.Errorf("got rejected queue %+v, want game %d", items, pending)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// Player IDs the server hands out say whose they are: an anonymous player's
+// or an account's. Clients that still bring their own ID in a header have
+// neither prefix.
+const (
+	anonymousPlayerPrefix = "anon-"
+	accountPlayerPrefix   = "user-"
+)
+
+// PlayerHandle is how other players see someone. A player ID doubles as the
+// player's credential, so it is never shown to anyone else; the handle is
+// derived from it, stable, and can't be turned back into it.
+func PlayerHandle(playerID string) string {
+	sum := sha256.Sum256([]byte(playerID))
+	return "player-" + hex.EncodeToString(sum[:4])
+}
+
+// NewAnonymousPlayer makes up the ID of a player who hasn't signed up.
+func NewAnonymousPlayer() string {
+	return newPlayerID(anonymousPlayerPrefix)
+}
+
+// IsAnonymousPlayer reports whether the server made playerID up for someone
+// without an account.
+func IsAnonymousPlayer(playerID string) bool {
+	return strings.HasPrefix(playerID, anonymousPlayerPrefix)
+}
+
+// IsIssuedPlayer reports whether the server handed playerID out, to an
+// anonymous player or an account. Such IDs are only good in the signed
+// cookie, never in a header.
+func IsIssuedPlayer(playerID string) bool {
+	return IsAnonymousPlayer(playerID) || strings.HasPrefix(playerID, accountPlayerPrefix)
+}
+
+func newPlayerID(prefix string) string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return prefix + hex.EncodeToString(id)
+}
+
+// PlayerSigner signs player IDs the server hands out, so that a client can
+// keep one, in a cookie, but can't make one up or swap it for another.
+type PlayerSigner struct {
+	key []byte
+}
+
+func NewPlayerSigner(key []byte) *PlayerSigner {
+	return &PlayerSigner{key: key}
+}
+
+// Sign returns playerID followed by its signature.
+func (s *PlayerSigner) Sign(playerID string) string {
+	return playerID + "." + base64.RawURLEncoding.EncodeToString(s.mac(playerID))
+}
+
+// Verify returns the player ID from a value made by Sign, if the signature
+// holds.
+func (s *PlayerSigner) Verify(signed string) (string, bool) {
+	i := strings.LastIndexByte(signed, '.')
+	if i <= 0 {
+		return "", false
+	}
+	playerID := signed[:i]
+	mac, err := base64.RawURLEncoding.DecodeString(signed[i+1:])
+	if err != nil || !hmac.Equal(mac, s.mac(playerID)) {
+		return "", false
+	}
+	return playerID, true
+}
+
+func (s *PlayerSigner) mac(playerID string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(playerID))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,566 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+)
+
+var (
+	ErrRaceNotFound = errors.New("race not found")
+	ErrRaceStarted  = errors.New("race has already started")
+	ErrRaceFull     = errors.New("race is full")
+	ErrNotRaceHost  = errors.New("only the host can start the race")
+	ErrNotRacing    = errors.New("race is not running")
+)
+
+const (
+	// MaxRacers is how many players can join one race.
+	MaxRacers = 16
+	// RaceIdleTimeout closes a race once nobody has been connected for this long.
+	RaceIdleTimeout = 10 * time.Minute
+
+	raceCodeLength   = 6
+	raceCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	raceEventBuffer  = 32
+)
+
+type RaceStatus string
+
+const (
+	RaceWaiting  RaceStatus = "waiting"
+	RaceRunning  RaceStatus = "racing"
+	RaceFinished RaceStatus = "finished"
+)
+
+// Events a race sends its racers.
+const (
+	// RaceEventLobby carries the race summary whenever someone joins or leaves.
+	RaceEventLobby = "lobby"
+	// RaceEventStart carries the board, to everyone at once.
+	RaceEventStart = "start"
+	// RaceEventGuess answers a racer's own guess.
+	RaceEventGuess = "guess"
+	// RaceEventProgress tells everyone how a racer is doing after each guess.
+	RaceEventProgress = "progress"
+	// RaceEventStandings is the final result, once every racer has finished.
+	RaceEventStandings = "standings"
+	RaceEventError     = "error"
+)
+
+// RacerProgress shows a racer by their PlayerHandle.
+type RacerProgress struct {
+	Player       string `json:"player"`
+	Connected    bool   `json:"connected"`
+	GroupsSolved int    `json:"groups_solved"`
+	Mistakes     int    `json:"mistakes"`
+	Finished     bool   `json:"finished"`
+	Won          bool   `json:"won"`
+	// TimeMs is how long the racer took to finish, from the start.
+	TimeMs int64 `json:"time_ms,omitempty"`
+}
+
+type RaceStanding struct {
+	Rank int `json:"rank"`
+	RacerProgress
+}
+
+// RaceSummary leaves out the game until the race starts, so nobody can study
+// the board first.
+type RaceSummary struct {
+	Code   string          `json:"code"`
+	GameID int64           `json:"game_id,omitempty"`
+	Host   string          `json:"host"`
+	Status RaceStatus      `json:"status"`
+	Racers []RacerProgress `json:"racers"`
+}
+
+type RaceEvent struct {
+	Type      string                     `json:"type"`
+	Race      *RaceSummary               `json:"race,omitempty"`
+	Board     *models.GameTilesResponse  `json:"board,omitempty"`
+	Progress  *RacerProgress             `json:"progress,omitempty"`
+	Result    *models.CheckTilesResponse `json:"result,omitempty"`
+	Standings []RaceStanding             `json:"standings,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+	// You is the receiving racer's own handle.
+	You string `json:"you,omitempty"`
+}
+
+// RaceService keeps the races in progress. Races live only in memory: each
+// is run by its own goroutine, and is gone once everyone has left.
+type RaceService struct {
+	games *GameService
+
+	mu    sync.Mutex
+	races map[string]*Race
+}
+
+func NewRaceService(games *GameService) *RaceService {
+	return &RaceService{
+		games: games,
+		races: make(map[string]*Race),
+	}
+}
+
+// CreateRace opens a race on a released game, hosted by the player, and
+// returns it with the code others join by. The race has a board key of its
+// own, so its tiles can't be looked up or checked on the public board.
+func (s *RaceService) CreateRace(ctx context.Context, playerID string, gameID int64) (RaceSummary, error) {
+	key := tileKey()
+	board, err := s.games.fetchTiles(ctx, gameID, key)
+	if err != nil {
+		return RaceSummary{}, err
+	}
+
+	s.mu.Lock()
+	code := newRaceCode()
+	for s.races[code] != nil {
+		code = newRaceCode()
+	}
+	race := &Race{
+		code:      code,
+		host:      playerID,
+		board:     board,
+		key:       key,
+		games:     s.games,
+		closed:    func() { s.remove(code) },
+		joins:     make(chan raceJoin),
+		leaves:    make(chan *Racer),
+		starts:    make(chan *Racer),
+		guesses:   make(chan raceGuess),
+		summaries: make(chan chan RaceSummary),
+		done:      make(chan struct{}),
+		status:    RaceWaiting,
+		racers:    make(map[string]*racerState),
+	}
+	s.races[code] = race
+	s.mu.Unlock()
+
+	go race.run()
+	return race.Summary()
+}
+
+// Race finds a race by its join code, ignoring case.
+func (s *RaceService) Race(code string) (*Race, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	race, ok := s.races[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return nil, ErrRaceNotFound
+	}
+	return race, nil
+}
+
+func (s *RaceService) remove(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.races, code)
+}
+
+func newRaceCode() string {
+	code := make([]byte, raceCodeLength)
+	rand.Read(code)
+	for i, b := range code {
+		// The alphabet's length divides 256, so this is unbiased
+		code[i] = raceCodeAlphabet[int(b)%len(raceCodeAlphabet)]
+	}
+	return string(code)
+}
+
+// Race is one room. Everything about it is owned by the goroutine in run;
+// other goroutines only talk to it through channels.
+type Race struct {
+	code   string
+	host   string
+	board  models.GameTilesResponse
+	key    []byte
+	games  *GameService
+	closed func()
+
+	joins     chan raceJoin
+	leaves    chan *Racer
+	starts    chan *Racer
+	guesses   chan raceGuess
+	summaries chan chan RaceSummary
+	done      chan struct{}
+
+	// Owned by run
+	status    RaceStatus
+	startedAt time.Time
+	racers    map[string]*racerState
+	order     []string
+}
+
+// Racer is a player's connection to a race.
+type Racer struct {
+	Player string
+	// Events carries what the race sends this racer. It is closed when the
+	// racer leaves, is replaced by a newer connection, falls too far behind,
+	// or the race closes.
+	Events <-chan RaceEvent
+
+	events chan RaceEvent
+	race   *Race
+}
+
+type racerState struct {
+	progress RacerProgress
+	conn     *Racer
+	guessed  map[string]bool
+}
+
+type raceJoin struct {
+	player string
+	reply  chan raceJoined
+}
+
+type raceJoined struct {
+	racer *Racer
+	err   error
+}
+
+// raceGuess is a guess already checked against the game, or a rejected
+// message to report back to the racer.
+type raceGuess struct {
+	racer    *Racer
+	tiles    []string
+	result   models.CheckTilesResponse
+	err      error
+	rejected error
+}
+
+func (r *Race) Code() string {
+	return r.code
+}
+
+// Join connects the player to the race. Anyone can join while it waits to
+// start; once it has, only players already in it can reconnect.
+func (r *Race) Join(playerID string) (*Racer, error) {
+	reply := make(chan raceJoined, 1)
+	select {
+	case r.joins <- raceJoin{player: playerID, reply: reply}:
+	case <-r.done:
+		return nil, ErrRaceNotFound
+	}
+	joined := <-reply
+	return joined.racer, joined.err
+}
+
+func (r *Race) Summary() (RaceSummary, error) {
+	reply := make(chan RaceSummary, 1)
+	select {
+	case r.summaries <- reply:
+	case <-r.done:
+		return RaceSummary{}, ErrRaceNotFound
+	}
+	return <-reply, nil
+}
+
+// Leave disconnects the racer. Their progress stays in the race.
+func (c *Racer) Leave() {
+	select {
+	case c.race.leaves <- c:
+	case <-c.race.done:
+	}
+}
+
+// Start starts the race for everyone connected, if the racer is the host.
+func (c *Racer) Start() {
+	select {
+	case c.race.starts <- c:
+	case <-c.race.done:
+	}
+}
+
+// Guess checks the tiles, given by their tokens on the race's board, the same
+// way as a solo check, and counts the result towards the racer's progress.
+func (c *Racer) Guess(ctx context.Context, tiles []string) {
+	guess := raceGuess{racer: c, tiles: tiles}
+	guess.result, guess.err = c.race.games.checkTiles(ctx, c.race.board.GameID, c.race.key, tiles)
+	c.send(guess)
+}
+
+// Reject reports a problem with something the racer sent.
+func (c *Racer) Reject(err error) {
+	c.send(raceGuess{racer: c, rejected: err})
+}
+
+func (c *Racer) send(guess raceGuess) {
+	select {
+	case c.race.guesses <- guess:
+	case <-c.race.done:
+	}
+}
+
+func (r *Race) run() {
+	defer r.close()
+
+	idle := time.After(RaceIdleTimeout)
+	for {
+		select {
+		case join := <-r.joins:
+			racer, err := r.join(join.player)
+			join.reply <- raceJoined{racer: racer, err: err}
+		case racer := <-r.leaves:
+			r.leave(racer)
+		case racer := <-r.starts:
+			r.start(racer)
+		case guess := <-r.guesses:
+			r.guess(guess)
+		case reply := <-r.summaries:
+			reply <- r.summary()
+		case <-idle:
+			return
+		}
+
+		switch {
+		case r.connected() > 0:
+			idle = nil
+		case idle == nil:
+			idle = time.After(RaceIdleTimeout)
+		}
+	}
+}
+
+func (r *Race) close() {
+	r.closed()
+	close(r.done)
+	for _, state := range r.racers {
+		r.disconnect(state)
+	}
+}
+
+func (r *Race) join(playerID string) (*Racer, error) {
+	state, ok := r.racers[playerID]
+	switch {
+	case !ok && r.status != RaceWaiting:
+		return nil, ErrRaceStarted
+	case !ok && len(r.racers) >= MaxRacers:
+		return nil, ErrRaceFull
+	case !ok:
+		state = &racerState{
+			progress: RacerProgress{Player: PlayerHandle(playerID)},
+			guessed:  make(map[string]bool),
+		}
+		r.racers[playerID] = state
+		r.order = append(r.order, playerID)
+	}
+
+	// A newer connection replaces the old one, say after a page reload
+	r.disconnect(state)
+	events := make(chan RaceEvent, raceEventBuffer)
+	state.conn = &Racer{Player: playerID, Events: events, events: events, race: r}
+	state.progress.Connected = true
+
+	r.lobby()
+	switch r.status {
+	case RaceRunning:
+		progress := state.progress
+		r.send(state, RaceEvent{Type: RaceEventStart, Board: &r.board, Progress: &progress})
+	case RaceFinished:
+		r.send(state, RaceEvent{Type: RaceEventStandings, Standings: r.standings()})
+	}
+	return state.conn, nil
+}
+
+func (r *Race) leave(racer *Racer) {
+	state := r.racers[racer.Player]
+	if state == nil || state.conn != racer {
+		return
+	}
+	r.disconnect(state)
+	r.lobby()
+	r.finishIfDone()
+}
+
+func (r *Race) start(racer *Racer) {
+	state := r.racers[racer.Player]
+	if state == nil || state.conn != racer {
+		return
+	}
+	switch {
+	case racer.Player != r.host:
+		r.send(state, RaceEvent{Type: RaceEventError, Error: ErrNotRaceHost.Error()})
+		return
+	case r.status != RaceWaiting:
+		r.send(state, RaceEvent{Type: RaceEventError, Error: ErrRaceStarted.Error()})
+		return
+	}
+
+	// Only those here at the start take part
+	order := r.order[:0]
+	for _, player := range r.order {
+		if r.racers[player].conn == nil {
+			delete(r.racers, player)
+			continue
+		}
+		order = append(order, player)
+	}
+	r.order = order
+
+	r.status = RaceRunning
+	r.startedAt = time.Now()
+	r.broadcast(RaceEvent{Type: RaceEventStart, Board: &r.board})
+}
+
+func (r *Race) guess(guess raceGuess) {
+	state := r.racers[guess.racer.Player]
+	if state == nil || state.conn != guess.racer {
+		return
+	}
+
+	switch {
+	case guess.rejected != nil:
+		r.send(state, RaceEvent{Type: RaceEventError, Error: guess.rejected.Error()})
+		return
+	case errors.Is(guess.err, ErrInvalidSelection):
+		r.send(state, RaceEvent{Type: RaceEventError, Error: guess.err.Error()})
+		return
+	case guess.err != nil:
+		log.Printf("unable to check guess in race %s: %v", r.code, guess.err)
+		r.send(state, RaceEvent{Type: RaceEventError, Error: "Failed to check tiles"})
+		return
+	case r.status != RaceRunning:
+		r.send(state, RaceEvent{Type: RaceEventError, Error: ErrNotRacing.Error()})
+		return
+	case state.progress.Finished:
+		r.send(state, RaceEvent{Type: RaceEventError, Error: ErrSessionFinished.Error()})
+		return
+	}
+
+	key := slices.Clone(guess.tiles)
+	slices.Sort(key)
+	if state.guessed[strings.Join(key, ",")] {
+		r.send(state, RaceEvent{Type: RaceEventError, Error: ErrAlreadyGuessed.Error()})
+		return
+	}
+	state.guessed[strings.Join(key, ",")] = true
+
+	progress := &state.progress
+	if guess.result.Correct {
+		progress.GroupsSolved++
+	} else {
+		progress.Mistakes++
+	}
+	if progress.GroupsSolved >= r.board.GroupCount || progress.Mistakes >= MaxMistakes {
+		progress.Finished = true
+		progress.Won = progress.GroupsSolved >= r.board.GroupCount
+		progress.TimeMs = time.Since(r.startedAt).Milliseconds()
+	}
+
+	r.send(state, RaceEvent{Type: RaceEventGuess, Result: &guess.result})
+	update := *progress
+	r.broadcast(RaceEvent{Type: RaceEventProgress, Progress: &update})
+	r.finishIfDone()
+}
+
+// finishIfDone ends the race once everyone still connected has finished.
+func (r *Race) finishIfDone() {
+	if r.status != RaceRunning {
+		return
+	}
+	for _, state := range r.racers {
+		if state.conn != nil && !state.progress.Finished {
+			return
+		}
+	}
+	r.status = RaceFinished
+	r.broadcast(RaceEvent{Type: RaceEventStandings, Standings: r.standings()})
+}
+
+// standings ranks winners by time, then everyone else by how far they got.
+func (r *Race) standings() []RaceStanding {
+	standings := make([]RaceStanding, 0, len(r.order))
+	for _, player := range r.order {
+		standings = append(standings, RaceStanding{RacerProgress: r.racers[player].progress})
+	}
+	slices.SortStableFunc(standings, func(a, b RaceStanding) int {
+		switch {
+		case a.Won != b.Won:
+			if a.Won {
+				return -1
+			}
+			return 1
+		case a.Won:
+			return cmp.Compare(a.TimeMs, b.TimeMs)
+		case a.GroupsSolved != b.GroupsSolved:
+			return b.GroupsSolved - a.GroupsSolved
+		default:
+			return a.Mistakes - b.Mistakes
+		}
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
+
+func (r *Race) summary() RaceSummary {
+	summary := RaceSummary{
+		Code:   r.code,
+		Host:   PlayerHandle(r.host),
+		Status: r.status,
+		Racers: make([]RacerProgress, 0, len(r.order)),
+	}
+	if r.status != RaceWaiting {
+		summary.GameID = r.board.GameID
+	}
+	for _, player := range r.order {
+		summary.Racers = append(summary.Racers, r.racers[player].progress)
+	}
+	return summary
+}
+
+func (r *Race) lobby() {
+	summary := r.summary()
+	r.broadcast(RaceEvent{Type: RaceEventLobby, Race: &summary})
+}
+
+func (r *Race) broadcast(event RaceEvent) {
+	for _, player := range r.order {
+		r.send(r.racers[player], event)
+	}
+}
+
+// send never blocks the race: a racer too far behind is disconnected.
+func (r *Race) send(state *racerState, event RaceEvent) {
+	if state.conn == nil {
+		return
+	}
+	event.You = state.progress.Player
+	select {
+	case state.conn.events <- event:
+	default:
+		log.Printf("disconnecting %s from race %s: too far behind", state.progress.Player, r.code)
+		r.disconnect(state)
+	}
+}
+
+func (r *Race) disconnect(state *racerState) {
+	if state.conn == nil {
+		return
+	}
+	close(state.conn.events)
+	state.conn = nil
+	state.progress.Connected = false
+}
+
+func (r *Race) connected() int {
+	n := 0
+	for _, state := range r.racers {
+		if state.conn != nil {
+			n++
+		}
+	}
+	return n
+}
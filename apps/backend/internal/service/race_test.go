@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+// next waits for the racer's next event of the given type, skipping others.
+func next(t *testing.T, racer *service.Racer, eventType string) service.RaceEvent {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event, ok := <-racer.Events:
+			if !ok {
+				t.Fatalf("%s: events closed waiting for %s", racer.Player, eventType)
+			}
+			if event.Type == eventType {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("%s: no %s event", racer.Player, eventType)
+		}
+	}
+}
+
+func TestRace(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewMemoryStore()
+	games := newGameService(store)
+	races := service.NewRaceService(games)
+	game := testutil.Seed(t, games, store, testutil.NewGame())
+
+	created, err := races.CreateRace(ctx, "alice", game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.GameID != 0 {
+		t.Errorf("race shows game %d before the start", created.GameID)
+	}
+	race, err := races.Race(created.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice, err := race.Join("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := race.Join("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lobby := next(t, alice, service.RaceEventLobby)
+	for lobby.Race == nil || len(lobby.Race.Racers) < 2 {
+		lobby = next(t, alice, service.RaceEventLobby)
+	}
+	// Player IDs are credentials, so racers only ever see handles
+	if lobby.You != service.PlayerHandle("alice") || lobby.Race.Host != lobby.You || lobby.Race.Racers[1].Player != service.PlayerHandle("bob") {
+		t.Errorf("got lobby %+v for alice", lobby)
+	}
+	if lobby.Race.GameID != 0 {
+		t.Errorf("lobby shows game %d before the start", lobby.Race.GameID)
+	}
+
+	bob.Start()
+	if event := next(t, bob, service.RaceEventError); event.Error != service.ErrNotRaceHost.Error() {
+		t.Errorf("bob starting: got %q, want %q", event.Error, service.ErrNotRaceHost)
+	}
+
+	alice.Start()
+	board := next(t, alice, service.RaceEventStart).Board
+	next(t, bob, service.RaceEventStart)
+	if _, err := race.Join("carol"); !errors.Is(err, service.ErrRaceStarted) {
+		t.Errorf("joining after the start: got %v, want ErrRaceStarted", err)
+	}
+	if summary, err := race.Summary(); err != nil || summary.GameID != game.ID {
+		t.Errorf("got summary %+v, %v after the start, want game %d", summary, err, game.ID)
+	}
+
+	// The race's tiles are its own, so the public board can't check them
+	_, err = games.CheckTileSelection(ctx, game.ID, testutil.Tokens(t, board.Tiles, game.Groups[0].Titles...))
+	if !errors.Is(err, service.ErrInvalidSelection) {
+		t.Errorf("checking race tiles on the public board: got %v, want ErrInvalidSelection", err)
+	}
+
+	// Alice solves every group; Bob makes four mistakes
+	for _, group := range game.Groups {
+		alice.Guess(ctx, testutil.Tokens(t, board.Tiles, group.Titles...))
+		if result := next(t, alice, service.RaceEventGuess).Result; !result.Correct {
+			t.Fatalf("alice guessing group %d was wrong", group.Tier)
+		}
+		progress := next(t, bob, service.RaceEventProgress).Progress
+		if progress.Player != service.PlayerHandle("alice") || progress.GroupsSolved != group.Tier {
+			t.Errorf("bob saw %+v", progress)
+		}
+	}
+	for i := range service.MaxMistakes {
+		titles := []string{game.Groups[0].Titles[0], game.Groups[1].Titles[0], game.Groups[2].Titles[0], game.Groups[3].Titles[i]}
+		bob.Guess(ctx, testutil.Tokens(t, board.Tiles, titles...))
+		if result := next(t, bob, service.RaceEventGuess).Result; result.Correct {
+			t.Fatal("bob's mixed guess was right")
+		}
+	}
+
+	standings := next(t, alice, service.RaceEventStandings).Standings
+	if len(standings) != 2 || standings[0].Player != service.PlayerHandle("alice") || !standings[0].Won || standings[1].Player != service.PlayerHandle("bob") || standings[1].Won {
+		t.Fatalf("got standings %+v, want alice winning ahead of bob", standings)
+	}
+	if standings[1].Mistakes != service.MaxMistakes {
+		t.Errorf("bob has %d mistakes, want %d", standings[1].Mistakes, service.MaxMistakes)
+	}
+
+	// Reconnecting replaces the old connection and sees the result
+	again, err := race.Join("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range bob.Events {
+		// The old connection drains, then closes
+	}
+	next(t, again, service.RaceEventStandings)
+
+	alice.Leave()
+	again.Leave()
+	summary, err := race.Summary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Status != service.RaceFinished || summary.Racers[0].Connected || summary.Racers[1].Connected {
+		t.Errorf("got %+v after everyone left", summary)
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+var (
+	ErrNotPlayed      = errors.New("finish the game before rating it")
+	ErrRatingNotFound = errors.New("rating not found")
+)
+
+type RatingService struct {
+	store GameStore
+	games *GameService
+}
+
+func NewRatingService(store GameStore, games *GameService) *RatingService {
+	return &RatingService{
+		store: store,
+		games: games,
+	}
+}
+
+type Rating struct {
+	GameID    int64     `json:"game_id"`
+	Stars     int       `json:"stars"`
+	Feedback  string    `json:"feedback,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RatingSummary aggregates a game's ratings for the listing.
+type RatingSummary struct {
+	Ratings      int64   `json:"ratings"`
+	AverageStars float64 `json:"average_stars"`
+	TooEasy      int64   `json:"too_easy"`
+	TooHard      int64   `json:"too_hard"`
+	Unfair       int64   `json:"unfair"`
+}
+
+// RateGame records the player's rating, replacing any earlier one. Only
+// players who have finished the game may rate it.
+func (s *RatingService) RateGame(ctx context.Context, playerID string, gameID int64, req models.RatingRequest) (Rating, error) {
+	if err := req.Validate(); err != nil {
+		return Rating{}, err
+	}
+	if err := s.games.EnsureReleased(ctx, gameID); err != nil {
+		return Rating{}, err
+	}
+
+	session, err := s.store.GetSessionForPlayer(ctx, db.GetSessionForPlayerParams{
+		GameID:   gameID,
+		PlayerID: playerID,
+	})
+	if err == sql.ErrNoRows || (err == nil && session.Status == db.SessionStatusInProgress) {
+		return Rating{}, ErrNotPlayed
+	}
+	if err != nil {
+		log.Printf("unable to fetch session for game %d: %v", gameID, err)
+		return Rating{}, err
+	}
+
+	feedback := db.NullRatingFeedback{
+		RatingFeedback: db.RatingFeedback(strings.ToLower(req.Feedback)),
+		Valid:          req.Feedback != "",
+	}
+	rating, err := s.store.UpsertRating(ctx, db.UpsertRatingParams{
+		GameID:   gameID,
+		PlayerID: playerID,
+		Stars:    int32(req.Stars),
+		Feedback: feedback,
+		Comment:  strings.TrimSpace(req.Comment),
+	})
+	if err != nil {
+		log.Printf("unable to save rating for game %d: %v", gameID, err)
+		return Rating{}, err
+	}
+
+	return toRating(rating), nil
+}
+
+func (s *RatingService) Rating(ctx context.Context, playerID string, gameID int64) (Rating, error) {
+	rating, err := s.store.GetRating(ctx, db.GetRatingParams{
+		GameID:   gameID,
+		PlayerID: playerID,
+	})
+	if err == sql.ErrNoRows {
+		return Rating{}, fmt.Errorf("%w: game %d", ErrRatingNotFound, gameID)
+	}
+	if err != nil {
+		log.Printf("unable to fetch rating for game %d: %v", gameID, err)
+		return Rating{}, err
+	}
+	return toRating(rating), nil
+}
+
+func toRating(rating db.Rating) Rating {
+	return Rating{
+		GameID:    rating.GameID,
+		Stars:     int(rating.Stars),
+		Feedback:  string(rating.Feedback.RatingFeedback),
+		Comment:   rating.Comment,
+		UpdatedAt: rating.UpdatedAt,
+	}
+}
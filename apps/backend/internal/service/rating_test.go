@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestRateGame(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		ratings := service.NewRatingService(store, games)
+		unrated := testutil.Seed(t, games, store, testutil.NewGame())
+		game := testutil.Seed(t, games, store, testutil.NewGame())
+
+		var verr *models.ValidationError
+		if _, err := ratings.RateGame(ctx, "alice", game.ID, models.RatingRequest{Stars: 6}); !errors.As(err, &verr) {
+			t.Errorf("six stars: got %v, want a ValidationError", err)
+		}
+		if _, err := ratings.RateGame(ctx, "alice", game.ID, models.RatingRequest{Stars: 4}); !errors.Is(err, service.ErrNotPlayed) {
+			t.Errorf("rating before playing: got %v, want ErrNotPlayed", err)
+		}
+		session, err := sessions.StartSession(ctx, "alice", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ratings.RateGame(ctx, "alice", game.ID, models.RatingRequest{Stars: 4}); !errors.Is(err, service.ErrNotPlayed) {
+			t.Errorf("rating mid-game: got %v, want ErrNotPlayed", err)
+		}
+		solveAll(t, sessions, "alice", session, game, 0)
+
+		// Rating again replaces the first rating
+		if _, err := ratings.RateGame(ctx, "alice", game.ID, models.RatingRequest{Stars: 4, Feedback: "Too_Easy"}); err != nil {
+			t.Fatal(err)
+		}
+		rating, err := ratings.RateGame(ctx, "alice", game.ID, models.RatingRequest{Stars: 2, Feedback: "too_hard", Comment: " tough "})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rating.Stars != 2 || rating.Feedback != "too_hard" || rating.Comment != "tough" {
+			t.Errorf("got rating %+v, want the second one", rating)
+		}
+		if got, err := ratings.Rating(ctx, "alice", game.ID); err != nil || got.Stars != 2 || !got.UpdatedAt.Equal(rating.UpdatedAt) {
+			t.Errorf("fetching rating: got %+v, %v, want %+v", got, err, rating)
+		}
+		if _, err := ratings.Rating(ctx, "bob", game.ID); !errors.Is(err, service.ErrRatingNotFound) {
+			t.Errorf("unrated: got %v, want ErrRatingNotFound", err)
+		}
+
+		session, err = sessions.StartSession(ctx, "bob", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lose(t, sessions, "bob", session, game)
+		if _, err := ratings.RateGame(ctx, "bob", game.ID, models.RatingRequest{Stars: 5}); err != nil {
+			t.Fatal(err)
+		}
+
+		// The listing sums up ratings, best rated first
+		listed, err := games.FetchAllGames(ctx, service.GameListOptions{Sort: "rating"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(listed) != 2 || listed[0].ID != game.ID || listed[1].ID != unrated.ID {
+			t.Fatalf("got %+v, want the rated game first", listed)
+		}
+		want := service.RatingSummary{Ratings: 2, AverageStars: 3.5, TooHard: 1}
+		if listed[0].Rating != want || listed[1].Rating != (service.RatingSummary{}) {
+			t.Errorf("got ratings %+v and %+v, want %+v and none", listed[0].Rating, listed[1].Rating, want)
+		}
+	})
+}
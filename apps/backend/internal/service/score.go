@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+// Scorer turns how a session went into its score. It is given each finished
+// session once, and what it returns is stored with the session.
+type Scorer interface {
+	Score(play ScoreInput) Score
+}
+
+// ScoreInput is what a Scorer knows about a finished session.
+type ScoreInput struct {
+	Won          bool
+	GroupCount   int
+	GroupsSolved int
+	Mistakes     int
+	HintsUsed    int
+	HintPoints   int
+	Elapsed      time.Duration
+	// TimeLimit is zero for untimed games.
+	TimeLimit time.Duration
+	// Tiers is the tier of every group, and SolveOrder the tiers of the
+	// groups solved, in the order they were solved.
+	Tiers      []int
+	SolveOrder []int
+}
+
+// Score is a session's total and the named parts it is made of.
+type Score struct {
+	Total     int            `json:"total"`
+	Breakdown map[string]int `json:"breakdown"`
+}
+
+// WeightedScorer is the default Scorer. Solving every group is worth Groups,
+// each mistake loses Mistake, and hints lose what they cost. Winning a timed
+// game adds up to Time, less the more of the limit was used, and solving the
+// hardest group left each time adds up to Order. Scores are never negative.
+type WeightedScorer struct {
+	Groups  int
+	Mistake int
+	Time    int
+	Order   int
+}
+
+// DefaultScorer scores out of 1000, the most hints can cost.
+var DefaultScorer = WeightedScorer{Groups: 600, Mistake: 50, Time: 200, Order: 200}
+
+func (w WeightedScorer) Score(play ScoreInput) Score {
+	parts := map[string]int{
+		"groups":   0,
+		"mistakes": -w.Mistake * play.Mistakes,
+		"hints":    -play.HintPoints,
+		"time":     0,
+		"order":    0,
+	}
+	if play.GroupCount > 0 {
+		parts["groups"] = w.Groups * play.GroupsSolved / play.GroupCount
+	}
+	if play.Won && play.TimeLimit > 0 && play.Elapsed < play.TimeLimit {
+		parts["time"] = int(int64(w.Time) * int64(play.TimeLimit-play.Elapsed) / int64(play.TimeLimit))
+	}
+
+	// The last group left is always the hardest, so only the choices before
+	// it count
+	if choices := len(play.Tiers) - 1; choices > 0 {
+		left := slices.Clone(play.Tiers)
+		hardFirst := 0
+		for _, tier := range play.SolveOrder {
+			if len(left) > 1 && tier == slices.Max(left) {
+				hardFirst++
+			}
+			if i := slices.Index(left, tier); i >= 0 {
+				left = slices.Delete(left, i, i+1)
+			}
+		}
+		parts["order"] = w.Order * hardFirst / choices
+	}
+
+	total := 0
+	for _, points := range parts {
+		total += points
+	}
+	return Score{Total: max(0, total), Breakdown: parts}
+}
+
+// score works out a finishing session's score. session already has its
+// final progress.
+func (s *SessionService) score(ctx context.Context, game db.Game, session db.Session) (Score, error) {
+	groups, err := s.store.ListGroupsForGame(ctx, game.ID)
+	if err != nil {
+		log.Printf("unable to fetch groups for game %d: %v", game.ID, err)
+		return Score{}, err
+	}
+	guesses, err := s.store.ListGuessesForSession(ctx, session.ID)
+	if err != nil {
+		log.Printf("unable to fetch guesses for session %d: %v", session.ID, err)
+		return Score{}, err
+	}
+
+	play := ScoreInput{
+		Won:          session.Status == db.SessionStatusWon,
+		GroupCount:   int(game.GroupCount),
+		GroupsSolved: int(session.GroupsSolved),
+		Mistakes:     int(session.Mistakes),
+		HintsUsed:    int(session.HintsUsed),
+		HintPoints:   int(session.HintPoints),
+		Elapsed:      session.FinishedAt.Time.Sub(session.StartedAt),
+	}
+	if minutes, err := strconv.Atoi(string(game.TimeLimit)); err == nil {
+		play.TimeLimit = time.Duration(minutes) * time.Minute
+	}
+	tiers := make(map[int64]int)
+	for _, group := range groups {
+		tiers[group.ID] = int(group.Tier)
+		play.Tiers = append(play.Tiers, int(group.Tier))
+	}
+	for _, guess := range guesses {
+		if guess.Correct && guess.GroupID.Valid {
+			play.SolveOrder = append(play.SolveOrder, tiers[guess.GroupID.Int64])
+		}
+	}
+
+	return s.scorer.Score(play), nil
+}
+
+// storedScore reads back the score saved with a finished session, if it has
+// one.
+func storedScore(session db.Session) *Score {
+	if !session.Score.Valid {
+		return nil
+	}
+	score := &Score{Total: int(session.Score.Int32)}
+	if err := json.Unmarshal(session.ScoreBreakdown, &score.Breakdown); err != nil {
+		log.Printf("unable to read score breakdown for session %d: %v", session.ID, err)
+	}
+	return score
+}
@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestWeightedScorer(t *testing.T) {
+	tests := []struct {
+		name string
+		play service.ScoreInput
+		want map[string]int
+	}{
+		{
+			name: "flawless, hardest first, untimed",
+			play: service.ScoreInput{Won: true, GroupCount: 4, GroupsSolved: 4, Tiers: []int{1, 2, 3, 4}, SolveOrder: []int{4, 3, 2, 1}},
+			want: map[string]int{"groups": 600, "mistakes": 0, "hints": 0, "time": 0, "order": 200},
+		},
+		{
+			name: "easiest first with a hint, a quarter of the time left",
+			play: service.ScoreInput{
+				Won: true, GroupCount: 4, GroupsSolved: 4, Mistakes: 2, HintsUsed: 1, HintPoints: 50,
+				Elapsed: 3 * time.Minute, TimeLimit: 4 * time.Minute,
+				Tiers: []int{1, 2, 3, 4}, SolveOrder: []int{1, 2, 3, 4},
+			},
+			want: map[string]int{"groups": 600, "mistakes": -100, "hints": -50, "time": 50, "order": 0},
+		},
+		{
+			name: "lost after the hardest group",
+			play: service.ScoreInput{
+				GroupCount: 4, GroupsSolved: 1, Mistakes: 4,
+				Elapsed: time.Minute, TimeLimit: 5 * time.Minute,
+				Tiers: []int{1, 2, 3, 4}, SolveOrder: []int{4},
+			},
+			want: map[string]int{"groups": 150, "mistakes": -200, "hints": 0, "time": 0, "order": 66},
+		},
+		{
+			name: "over the time limit",
+			play: service.ScoreInput{Won: true, GroupCount: 2, GroupsSolved: 2, Elapsed: 6 * time.Minute, TimeLimit: 5 * time.Minute, Tiers: []int{1, 2}, SolveOrder: []int{1, 2}},
+			want: map[string]int{"groups": 600, "mistakes": 0, "hints": 0, "time": 0, "order": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := service.DefaultScorer.Score(tt.play)
+			if !maps.Equal(score.Breakdown, tt.want) {
+				t.Errorf("got breakdown %v, want %v", score.Breakdown, tt.want)
+			}
+			total := 0
+			for _, points := range tt.want {
+				total += points
+			}
+			if score.Total != max(0, total) {
+				t.Errorf("got total %d, want %d", score.Total, max(0, total))
+			}
+		})
+	}
+}
+
+type fixedScorer struct{}
+
+func (fixedScorer) Score(play service.ScoreInput) service.Score {
+	return service.Score{Total: 42, Breakdown: map[string]int{"solved": play.GroupsSolved}}
+}
+
+func TestSessionScore(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		game := testutil.Seed(t, games, store, testutil.NewGame().TimeLimit("5"))
+
+		session, err := sessions.StartSession(ctx, "alice", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := len(game.Groups) - 1; i >= 0; i-- {
+			result, err := sessions.SubmitGuess(ctx, "alice", session.ID, testutil.Tokens(t, session.Tiles, game.Groups[i].Titles...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i > 0 && result.Session.Score != nil {
+				t.Fatalf("scored %+v before finishing", result.Session.Score)
+			}
+		}
+
+		state, err := sessions.GetSession(ctx, "alice", session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Score == nil {
+			t.Fatal("no score for the finished session")
+		}
+		breakdown := state.Score.Breakdown
+		if breakdown["groups"] != 600 || breakdown["order"] != 200 || breakdown["time"] < 190 {
+			t.Errorf("got breakdown %v, want every group, hardest first, in no time", breakdown)
+		}
+		if state.Score.Total < 990 {
+			t.Errorf("got total %d, want nearly 1000", state.Score.Total)
+		}
+
+		// Scoring is up to the scorer the service is given
+		custom := service.NewSessionService(store, games, service.NewStatsService(store, time.UTC), fixedScorer{}, "http://puzzle.test")
+		other, err := custom.StartSession(ctx, "bob", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := custom.SubmitGuess(ctx, "bob", other.ID, testutil.Tokens(t, other.Tiles, game.Groups[0].Titles...)); err != nil {
+			t.Fatal(err)
+		}
+		var result service.GuessResult
+		for i := range service.MaxMistakes {
+			titles := []string{game.Groups[1].Titles[0], game.Groups[2].Titles[0], game.Groups[3].Titles[0], game.Groups[1].Titles[1+i%3]}
+			if i == 3 {
+				titles[0] = game.Groups[2].Titles[1]
+			}
+			if result, err = custom.SubmitGuess(ctx, "bob", other.ID, testutil.Tokens(t, other.Tiles, titles...)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if result.Session.Status != "lost" || result.Session.Score == nil || result.Session.Score.Total != 42 || result.Session.Score.Breakdown["solved"] != 1 {
+			t.Errorf("got %s session scored %+v, want lost and the custom score", result.Session.Status, result.Session.Score)
+		}
+	})
+}
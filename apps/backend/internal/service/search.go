@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 50
+)
+
+var ErrEmptyQuery = errors.New("search query is required")
+
+type SearchResult struct {
+	ID         int64              `json:"id"`
+	Author     string             `json:"author"`
+	Difficulty db.DifficultyLevel `json:"difficulty_level"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Relevance  float32            `json:"relevance"`
+	// TileSnippet highlights matching tiles, which are on the board anyway.
+	TileSnippet string `json:"tile_snippet,omitempty"`
+	// LinkSnippet highlights matching links, and is left out unless the
+	// player has finished the game.
+	LinkSnippet string `json:"link_snippet,omitempty"`
+}
+
+// SearchGames ranks released games against a web-style query, e.g.
+// `rivers -europe`. Links only match games playerID has finished; playerID
+// may be empty, in which case only tiles match.
+func (s *GameService) SearchGames(ctx context.Context, query, playerID string, limit, offset int) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+	offset = max(offset, 0)
+
+	rows, err := s.store.SearchGames(ctx, db.SearchGamesParams{
+		Query:      query,
+		PlayerID:   playerID,
+		Today:      s.Today(),
+		PageLimit:  int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		log.Printf("unable to search games for %q: %v", query, err)
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, SearchResult{
+			ID:          row.ID,
+			Author:      row.Author,
+			Difficulty:  row.Difficulty,
+			CreatedAt:   row.CreatedAt,
+			Relevance:   row.Relevance,
+			TileSnippet: row.TileSnippet,
+			LinkSnippet: row.LinkSnippet,
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestSearchGamesPaging(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit, offset int
+		want          int
+	}{
+		{"defaults", 0, 0, 3},
+		{"negative limit", -1, 0, 3},
+		{"negative offset", 0, -5, 3},
+		{"one page", 1, 1, 1},
+		{"past the end", 0, 10, 0},
+	}
+
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		games := newGameService(store)
+		for range 3 {
+			testutil.Seed(t, games, store, testutil.NewGame())
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				results, err := games.SearchGames(context.Background(), "tile", "", tt.limit, tt.offset)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(results) != tt.want {
+					t.Errorf("got %d results, want %d", len(results), tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestSearchGamesHidesGroups(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		rivers := testutil.Seed(t, games, store, testutil.NewGame().
+			Group(testutil.NewGroup(1).Link("Rivers", "river").Tiles("Thames", "Nile", "Amazon", "Danube")))
+		moons := testutil.Seed(t, games, store, testutil.NewGame().
+			Group(testutil.NewGroup(1).Tiles("Red Moon", "Pear", "Plum", "Fig")).
+			Group(testutil.NewGroup(2).Tiles("Blue Moon", "Kiwi", "Lime", "Date")))
+
+		// Tiles are snippeted in title order, not the order of their groups
+		results, err := games.SearchGames(ctx, "moon", "", 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].ID != moons.ID {
+			t.Fatalf("got %+v, want the moons game", results)
+		}
+		if snippet := results[0].TileSnippet; strings.Index(snippet, "Blue") > strings.Index(snippet, "Red") {
+			t.Errorf("tile snippet %q is in group order", snippet)
+		}
+
+		// A link only finds a game once the player has finished it
+		results, err = games.SearchGames(ctx, "river", "alice", 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 0 {
+			t.Errorf("before playing: got %+v, want no results", results)
+		}
+		session, err := sessions.StartSession(ctx, "alice", rivers.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		solveAll(t, sessions, "alice", session, rivers, 0)
+		results, err = games.SearchGames(ctx, "river", "alice", 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].ID != rivers.ID || results[0].LinkSnippet == "" {
+			t.Errorf("after finishing: got %+v, want the rivers game with its link", results)
+		}
+	})
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+// MaxMistakes is the number of wrong guesses that ends a session.
+const MaxMistakes = 4
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionFinished = errors.New("session is already finished")
+	ErrAlreadyGuessed  = errors.New("that selection has already been guessed")
+)
+
+type SessionService struct {
+	store GameStore
+	games *GameService
+	stats *StatsService
+	// scorer scores sessions as they finish.
+	scorer Scorer
+	// publicURL is prefixed to share codes to make share links.
+	publicURL string
+}
+
+func NewSessionService(store GameStore, games *GameService, stats *StatsService, scorer Scorer, publicURL string) *SessionService {
+	return &SessionService{
+		store:     store,
+		games:     games,
+		stats:     stats,
+		scorer:    scorer,
+		publicURL: publicURL,
+	}
+}
+
+type SolvedGroup struct {
+	GroupID int64              `json:"group_id"`
+	Link    string             `json:"link"`
+	Tier    int                `json:"tier"`
+	Tiles   []models.BoardTile `json:"tiles"`
+}
+
+// SessionState is a play as its player sees it. Tiles are known by tokens
+// that only mean anything in this session, and Tiles holds those not yet in
+// a solved group, so nothing shows which group a tile is in before it is
+// solved.
+type SessionState struct {
+	ID                int64              `json:"id"`
+	GameID            int64              `json:"game_id"`
+	Status            string             `json:"status"`
+	Mistakes          int                `json:"mistakes"`
+	MistakesRemaining int                `json:"mistakes_remaining"`
+	Tiles             []models.BoardTile `json:"tiles"`
+	Solved            []SolvedGroup      `json:"solved"`
+	Hints             []SessionHint      `json:"hints"`
+	// HintPoints is how much of the score hints have cost so far, and
+	// HintCosts what each further hint will cost.
+	HintPoints int              `json:"hint_points"`
+	HintCosts  models.HintCosts `json:"hint_costs"`
+	DailyDate  string           `json:"daily_date,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	// Decoys explains the red herrings, once the session has ended.
+	Decoys []RevealedDecoy `json:"decoys,omitempty"`
+	// Score is set once the session has ended.
+	Score *Score `json:"score,omitempty"`
+}
+
+type RevealedDecoy struct {
+	Token     string `json:"token"`
+	Title     string `json:"title"`
+	Tier      int    `json:"tier"`
+	DecoyTier int    `json:"decoy_tier"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type GuessResult struct {
+	Correct  bool         `json:"correct"`
+	LinkText string       `json:"link_text,omitempty"`
+	Tier     int          `json:"tier,omitempty"`
+	Session  SessionState `json:"session"`
+}
+
+// StartSession begins a play of a game, or resumes the player's existing one.
+// A game started while it is today's daily puzzle counts towards streaks.
+func (s *SessionService) StartSession(ctx context.Context, playerID string, gameID int64) (SessionState, error) {
+	if err := s.games.EnsureReleased(ctx, gameID); err != nil {
+		return SessionState{}, err
+	}
+
+	var dailyDate sql.NullTime
+	today := s.games.Today()
+	daily, err := s.games.DailyPuzzle(ctx, today)
+	switch {
+	case err == nil && daily.GameID == gameID:
+		dailyDate = sql.NullTime{Time: today, Valid: true}
+	case err != nil && !errors.Is(err, ErrNoDailyPuzzle):
+		return SessionState{}, err
+	}
+
+	session, err := s.store.StartSession(ctx, db.StartSessionParams{
+		GameID:    gameID,
+		PlayerID:  playerID,
+		DailyDate: dailyDate,
+	})
+	if err != nil {
+		log.Printf("unable to start session for game %d: %v", gameID, err)
+		return SessionState{}, err
+	}
+
+	return s.state(ctx, session)
+}
+
+func (s *SessionService) GetSession(ctx context.Context, playerID string, sessionID int64) (SessionState, error) {
+	session, err := s.session(ctx, playerID, sessionID)
+	if err != nil {
+		return SessionState{}, err
+	}
+	return s.state(ctx, session)
+}
+
+// SubmitGuess takes the tiles by their tokens in this session.
+func (s *SessionService) SubmitGuess(ctx context.Context, playerID string, sessionID int64, tokens []string) (GuessResult, error) {
+	session, err := s.session(ctx, playerID, sessionID)
+	if err != nil {
+		return GuessResult{}, err
+	}
+	if session.Status != db.SessionStatusInProgress {
+		return GuessResult{}, ErrSessionFinished
+	}
+
+	game, err := s.store.GetGame(ctx, session.GameID)
+	if err != nil {
+		return GuessResult{}, err
+	}
+	if len(tokens) != int(game.GroupSize) {
+		return GuessResult{}, fmt.Errorf("%w: select exactly %d tiles", ErrInvalidSelection, game.GroupSize)
+	}
+
+	board, err := s.games.board(ctx, session.GameID, session.TileKey)
+	if err != nil {
+		return GuessResult{}, err
+	}
+	tileIDs, err := board.resolve(tokens)
+	if err != nil {
+		return GuessResult{}, err
+	}
+
+	guesses, err := s.store.ListGuessesForSession(ctx, session.ID)
+	if err != nil {
+		log.Printf("unable to fetch guesses for session %d: %v", session.ID, err)
+		return GuessResult{}, err
+	}
+	solved := make(map[int64]bool)
+	for _, guess := range guesses {
+		if sameTiles(guess.TileIds, tileIDs) {
+			return GuessResult{}, ErrAlreadyGuessed
+		}
+		if guess.Correct {
+			for _, id := range guess.TileIds {
+				solved[id] = true
+			}
+		}
+	}
+	for _, id := range tileIDs {
+		if solved[id] {
+			return GuessResult{}, fmt.Errorf("%w: that tile is already solved", ErrInvalidSelection)
+		}
+	}
+
+	group, correct, err := s.games.matchGroup(ctx, session.GameID, tileIDs, int(game.GroupSize))
+	if err != nil {
+		return GuessResult{}, err
+	}
+
+	var groupID sql.NullInt64
+	if correct {
+		groupID = sql.NullInt64{Int64: group.ID, Valid: true}
+	}
+	guess, err := s.store.CreateGuess(ctx, db.CreateGuessParams{
+		SessionID: session.ID,
+		TileIds:   tileIDs,
+		Correct:   correct,
+		GroupID:   groupID,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		// Another request solved this group first
+		return GuessResult{}, ErrAlreadyGuessed
+	}
+	if err != nil {
+		log.Printf("unable to record guess for session %d: %v", session.ID, err)
+		return GuessResult{}, err
+	}
+
+	session, err = s.advance(ctx, game, guess, correct)
+	if err != nil {
+		return GuessResult{}, err
+	}
+
+	state, err := s.state(ctx, session)
+	if err != nil {
+		return GuessResult{}, err
+	}
+
+	result := GuessResult{Correct: correct, Session: state}
+	if correct {
+		result.LinkText = group.Link
+		result.Tier = int(group.Tier)
+	}
+	return result, nil
+}
+
+// advance applies a recorded guess to the session counters and finishes the
+// session once every group is solved or the mistakes run out. Counters are
+// added to in the store, so concurrent guesses all count, and only the
+// request that finishes the session records it in the stats.
+func (s *SessionService) advance(ctx context.Context, game db.Game, guess db.Guess, correct bool) (db.Session, error) {
+	params := db.AdvanceSessionParams{ID: guess.SessionID}
+	if correct {
+		params.GroupsSolved = 1
+	} else {
+		params.Mistakes = 1
+	}
+	session, err := s.store.AdvanceSession(ctx, params)
+	if err == sql.ErrNoRows {
+		// The session finished while the guess was being checked, so the
+		// guess never counted
+		if err := s.store.DeleteGuess(ctx, guess.ID); err != nil {
+			log.Printf("unable to delete guess %d: %v", guess.ID, err)
+		}
+		return db.Session{}, ErrSessionFinished
+	}
+	if err != nil {
+		log.Printf("unable to update session %d: %v", guess.SessionID, err)
+		return db.Session{}, err
+	}
+
+	finished := session
+	switch {
+	case session.GroupsSolved >= game.GroupCount:
+		finished.Status = db.SessionStatusWon
+	case session.Mistakes >= MaxMistakes:
+		finished.Status = db.SessionStatusLost
+	default:
+		return session, nil
+	}
+	finished.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	score, err := s.score(ctx, game, finished)
+	if err != nil {
+		return db.Session{}, err
+	}
+	breakdown, err := json.Marshal(score.Breakdown)
+	if err != nil {
+		return db.Session{}, err
+	}
+	finished, err = s.store.FinishSession(ctx, db.FinishSessionParams{
+		ID:             session.ID,
+		Status:         finished.Status,
+		FinishedAt:     finished.FinishedAt,
+		Score:          sql.NullInt32{Int32: int32(score.Total), Valid: true},
+		ScoreBreakdown: breakdown,
+	})
+	if err == sql.ErrNoRows {
+		// A concurrent guess finished it
+		return s.store.GetSession(ctx, session.ID)
+	}
+	if err != nil {
+		log.Printf("unable to finish session %d: %v", session.ID, err)
+		return db.Session{}, err
+	}
+
+	if err := s.stats.RecordSession(ctx, finished); err != nil {
+		return db.Session{}, err
+	}
+	if finished.Status == db.SessionStatusWon {
+		mistakes := int(finished.Mistakes)
+		s.games.activity.Publish(Activity{
+			Type:     ActivitySolved,
+			GameID:   finished.GameID,
+			Player:   PlayerHandle(finished.PlayerID),
+			Mistakes: &mistakes,
+		})
+	}
+
+	return finished, nil
+}
+
+// session loads a session, hiding other players' sessions entirely.
+func (s *SessionService) session(ctx context.Context, playerID string, sessionID int64) (db.Session, error) {
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err == sql.ErrNoRows || (err == nil && session.PlayerID != playerID) {
+		return db.Session{}, fmt.Errorf("%w: %d", ErrSessionNotFound, sessionID)
+	}
+	if err != nil {
+		log.Printf("unable to fetch session %d: %v", sessionID, err)
+		return db.Session{}, err
+	}
+	return session, nil
+}
+
+func (s *SessionService) state(ctx context.Context, session db.Session) (SessionState, error) {
+	state := SessionState{
+		ID:                session.ID,
+		GameID:            session.GameID,
+		Status:            string(session.Status),
+		Mistakes:          int(session.Mistakes),
+		MistakesRemaining: max(0, MaxMistakes-int(session.Mistakes)),
+		Solved:            []SolvedGroup{},
+		Hints:             []SessionHint{},
+		HintPoints:        int(session.HintPoints),
+		StartedAt:         session.StartedAt,
+		Score:             storedScore(session),
+	}
+	if session.DailyDate.Valid {
+		state.DailyDate = session.DailyDate.Time.Format(time.DateOnly)
+	}
+	if session.FinishedAt.Valid {
+		state.FinishedAt = &session.FinishedAt.Time
+	}
+
+	board, err := s.games.board(ctx, session.GameID, session.TileKey)
+	if err != nil {
+		return SessionState{}, err
+	}
+
+	guesses, err := s.store.ListGuessesForSession(ctx, session.ID)
+	if err != nil {
+		log.Printf("unable to fetch guesses for session %d: %v", session.ID, err)
+		return SessionState{}, err
+	}
+	solved := make(map[int64]bool)
+	for _, guess := range guesses {
+		if !guess.Correct || !guess.GroupID.Valid {
+			continue
+		}
+		group, err := s.store.GetGroup(ctx, guess.GroupID.Int64)
+		if err != nil {
+			return SessionState{}, err
+		}
+		state.Solved = append(state.Solved, SolvedGroup{
+			GroupID: group.ID,
+			Link:    group.Link,
+			Tier:    int(group.Tier),
+			Tiles:   board.showIDs(guess.TileIds),
+		})
+		for _, id := range guess.TileIds {
+			solved[id] = true
+		}
+	}
+	state.Tiles = board.show(solved)
+
+	game, err := s.store.GetGame(ctx, session.GameID)
+	if err != nil {
+		log.Printf("unable to fetch game %d: %v", session.GameID, err)
+		return SessionState{}, err
+	}
+	state.HintCosts = *hintCosts(game)
+
+	hints, err := s.store.ListHintsForSession(ctx, session.ID)
+	if err != nil {
+		log.Printf("unable to fetch hints for session %d: %v", session.ID, err)
+		return SessionState{}, err
+	}
+	for _, hint := range hints {
+		group, err := s.store.GetGroup(ctx, hint.GroupID)
+		if err != nil {
+			return SessionState{}, err
+		}
+		state.Hints = append(state.Hints, showHint(hint, group, board))
+	}
+
+	if session.Status != db.SessionStatusInProgress {
+		decoys, err := s.store.ListDecoysForGame(ctx, session.GameID)
+		if err != nil {
+			log.Printf("unable to fetch decoys for game %d: %v", session.GameID, err)
+			return SessionState{}, err
+		}
+		for _, decoy := range decoys {
+			state.Decoys = append(state.Decoys, RevealedDecoy{
+				Token:     board.tokens[decoy.ID],
+				Title:     decoy.Title,
+				Tier:      int(decoy.Tier),
+				DecoyTier: int(decoy.DecoyTier),
+				Reason:    decoy.DecoyReason,
+			})
+		}
+	}
+
+	return state, nil
+}
+
+func sameTiles(a, b []int64) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
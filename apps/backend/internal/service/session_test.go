@@ -0,0 +1,258 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func newSessionService(store service.GameStore, games *service.GameService) *service.SessionService {
+	return service.NewSessionService(store, games, service.NewStatsService(store, time.UTC), service.DefaultScorer, "http://puzzle.test")
+}
+
+// guess submits the tiles with the given titles.
+func guess(t *testing.T, sessions *service.SessionService, playerID string, session service.SessionState, titles ...string) {
+	t.Helper()
+	if _, err := sessions.SubmitGuess(context.Background(), playerID, session.ID, testutil.Tokens(t, session.Tiles, titles...)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wrongSet is the i'th of MaxMistakes different wrong guesses, each one tile
+// of group 1 with the first tile of every other group.
+func wrongSet(game testutil.SeededGame, i int) []string {
+	titles := []string{game.Groups[0].Titles[i]}
+	for _, group := range game.Groups[1:] {
+		titles = append(titles, group.Titles[0])
+	}
+	return titles
+}
+
+// lose makes every mistake allowed.
+func lose(t *testing.T, sessions *service.SessionService, playerID string, session service.SessionState, game testutil.SeededGame) {
+	t.Helper()
+	for i := range service.MaxMistakes {
+		guess(t, sessions, playerID, session, wrongSet(game, i)...)
+	}
+}
+
+// solveAll solves the groups in tier order, after mistakes wrong guesses.
+func solveAll(t *testing.T, sessions *service.SessionService, playerID string, session service.SessionState, game testutil.SeededGame, mistakes int) {
+	t.Helper()
+	for i := range mistakes {
+		guess(t, sessions, playerID, session, wrongSet(game, i)...)
+	}
+	for _, group := range game.Groups {
+		guess(t, sessions, playerID, session, group.Titles...)
+	}
+}
+
+func TestSessionHidesGroups(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		game := testutil.Seed(t, games, store, testutil.NewGame())
+
+		alice, err := sessions.StartSession(ctx, "alice", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bob, err := sessions.StartSession(ctx, "bob", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encoded, err := json.Marshal(alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(encoded), "group_id") {
+			t.Errorf("unsolved session mentions groups: %s", encoded)
+		}
+		for _, group := range game.Groups {
+			for _, id := range group.TileIDs {
+				if strings.Contains(string(encoded), `"`+strconv.FormatInt(id, 10)+`"`) {
+					t.Errorf("session shows tile ID %d", id)
+				}
+			}
+		}
+
+		if len(alice.Tiles) != 16 {
+			t.Fatalf("got %d tiles, want 16", len(alice.Tiles))
+		}
+		if sortedByGroup(alice.Tiles) {
+			t.Error("tiles are in group order")
+		}
+
+		title := game.Groups[0].Titles[0]
+		public := testutil.Tokens(t, publicBoard(t, games, game.ID), title)[0]
+		mine := testutil.Tokens(t, alice.Tiles, title)[0]
+		theirs := testutil.Tokens(t, bob.Tiles, title)[0]
+		if mine == theirs || mine == public {
+			t.Errorf("tile %q has token %s in one session, %s in another and %s on the public board", title, mine, theirs, public)
+		}
+
+		// Tokens from another session or the public board mean nothing here
+		borrowed := testutil.Tokens(t, bob.Tiles, game.Groups[0].Titles...)
+		_, err = sessions.SubmitGuess(ctx, "alice", alice.ID, borrowed)
+		var foreign *service.ForeignTilesError
+		if !errors.As(err, &foreign) {
+			t.Errorf("guessing another session's tokens: got %v, want a ForeignTilesError", err)
+		}
+
+		solving := testutil.Tokens(t, alice.Tiles, game.Groups[0].Titles...)
+		result, err := sessions.SubmitGuess(ctx, "alice", alice.ID, solving)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Correct {
+			t.Fatal("guessing a whole group was wrong")
+		}
+		if result.Session.Mistakes != 0 {
+			t.Errorf("got %d mistakes, want none", result.Session.Mistakes)
+		}
+		if len(result.Session.Tiles) != 12 {
+			t.Errorf("got %d unsolved tiles, want 12", len(result.Session.Tiles))
+		}
+		solved := result.Session.Solved
+		if len(solved) != 1 || solved[0].GroupID != game.Groups[0].ID || len(solved[0].Tiles) != 4 {
+			t.Fatalf("got solved groups %+v, want group %d", solved, game.Groups[0].ID)
+		}
+		for i, tile := range solved[0].Tiles {
+			if tile.Token != solving[i] {
+				t.Errorf("solved tile %d has token %s, want %s", i, tile.Token, solving[i])
+			}
+		}
+	})
+}
+
+// sortedByGroup reports whether the default titles, "Tile <tier>.<n>", come
+// out grouped.
+func sortedByGroup(tiles []models.BoardTile) bool {
+	for i := 1; i < len(tiles); i++ {
+		if tiles[i].Title < tiles[i-1].Title {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGuessSolvedTiles(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		sessions := newSessionService(store, games)
+		game := testutil.Seed(t, games, store, testutil.NewGame())
+
+		session, err := sessions.StartSession(ctx, "alice", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		guess(t, sessions, "alice", session, game.Groups[0].Titles...)
+
+		// A solved tile can't be guessed again, so it can't cost a mistake
+		titles := append([]string{game.Groups[0].Titles[0]}, game.Groups[1].Titles[1:]...)
+		_, err = sessions.SubmitGuess(ctx, "alice", session.ID, testutil.Tokens(t, session.Tiles, titles...))
+		if !errors.Is(err, service.ErrInvalidSelection) {
+			t.Errorf("guessing a solved tile: got %v, want ErrInvalidSelection", err)
+		}
+		state, err := sessions.GetSession(ctx, "alice", session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Mistakes != 0 || len(state.Solved) != 1 {
+			t.Errorf("got %d mistakes and %d groups, want 0 and 1", state.Mistakes, len(state.Solved))
+		}
+	})
+}
+
+func TestConcurrentGuesses(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		games := newGameService(store)
+		stats := service.NewStatsService(store, time.UTC)
+		sessions := service.NewSessionService(store, games, stats, service.DefaultScorer, "http://puzzle.test")
+		game := testutil.Seed(t, games, store, testutil.NewGame())
+
+		session, err := sessions.StartSession(ctx, "alice", game.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		guessAll := func(guesses ...[]string) []error {
+			errs := make([]error, len(guesses))
+			var wg sync.WaitGroup
+			for i, titles := range guesses {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, errs[i] = sessions.SubmitGuess(ctx, "alice", session.ID, testutil.Tokens(t, session.Tiles, titles...))
+				}()
+			}
+			wg.Wait()
+			return errs
+		}
+
+		// The same group solved at once is solved once
+		var same [][]string
+		for range 8 {
+			same = append(same, game.Groups[0].Titles)
+		}
+		solved := 0
+		for _, err := range guessAll(same...) {
+			switch {
+			case err == nil:
+				solved++
+			case !errors.Is(err, service.ErrAlreadyGuessed):
+				t.Errorf("got %v, want ErrAlreadyGuessed", err)
+			}
+		}
+		if solved != 1 {
+			t.Errorf("group solved %d times, want once", solved)
+		}
+
+		// Different wrong guesses at once all count
+		var wrong [][]string
+		for i := range 3 {
+			wrong = append(wrong, []string{game.Groups[1].Titles[i], game.Groups[2].Titles[0], game.Groups[3].Titles[0], game.Groups[3].Titles[1]})
+		}
+		for _, err := range guessAll(wrong...) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		state, err := sessions.GetSession(ctx, "alice", session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Mistakes != 3 || len(state.Solved) != 1 {
+			t.Errorf("got %d mistakes and %d groups, want 3 and 1", state.Mistakes, len(state.Solved))
+		}
+
+		// Finishing at once is recorded once
+		guessAll(game.Groups[1].Titles, game.Groups[2].Titles, game.Groups[3].Titles)
+		state, err = sessions.GetSession(ctx, "alice", session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Status != "won" || len(state.Solved) != 4 {
+			t.Errorf("got %s with %d groups, want won with 4", state.Status, len(state.Solved))
+		}
+		played, err := stats.PlayerStats(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if played.Played != 1 || played.Won != 1 {
+			t.Errorf("got stats %+v, want one win", played)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+var ErrSessionNotFinished = errors.New("session is not finished yet")
+
+// groupColours are assigned to groups by difficulty tier, so every player
+// sees the same colour for the same group.
+var groupColours = []string{"🟨", "🟩", "🟦", "🟪", "🟥", "🟧", "🟫", "⬜"}
+
+// hintMark is the share grid row for a hint.
+const hintMark = "💡"
+
+var shareEncoding = base32.NewEncoding("abcdefghijkmnpqrstuvwxyz23456789").WithPadding(base32.NoPadding)
+
+type Share struct {
+	Code string `json:"code"`
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Share builds the result grid for one of the player's finished sessions,
+// creating its public share code the first time.
+func (s *SessionService) Share(ctx context.Context, playerID string, sessionID int64) (Share, error) {
+	session, err := s.session(ctx, playerID, sessionID)
+	if err != nil {
+		return Share{}, err
+	}
+	if session.Status == db.SessionStatusInProgress {
+		return Share{}, ErrSessionNotFinished
+	}
+
+	if !session.ShareCode.Valid {
+		code, err := newShareCode()
+		if err != nil {
+			return Share{}, err
+		}
+		session.ShareCode, err = s.store.SetSessionShareCode(ctx, db.SetSessionShareCodeParams{
+			ID:        session.ID,
+			ShareCode: sql.NullString{String: code, Valid: true},
+		})
+		if err != nil {
+			log.Printf("unable to set share code for session %d: %v", session.ID, err)
+			return Share{}, err
+		}
+	}
+
+	return s.share(ctx, session)
+}
+
+// SharedResult looks a share up by its public code.
+func (s *SessionService) SharedResult(ctx context.Context, code string) (Share, error) {
+	session, err := s.store.GetSessionByShareCode(ctx, sql.NullString{String: code, Valid: true})
+	if err == sql.ErrNoRows {
+		return Share{}, ErrSessionNotFound
+	}
+	if err != nil {
+		log.Printf("unable to fetch shared session %s: %v", code, err)
+		return Share{}, err
+	}
+	return s.share(ctx, session)
+}
+
+func (s *SessionService) share(ctx context.Context, session db.Session) (Share, error) {
+	grid, err := s.shareGrid(ctx, session)
+	if err != nil {
+		return Share{}, err
+	}
+
+	title := fmt.Sprintf("Puzzle #%d", session.GameID)
+	if session.DailyDate.Valid {
+		title += " · " + session.DailyDate.Time.Format(time.DateOnly)
+	}
+
+	var text strings.Builder
+	text.WriteString(title + "\n")
+	if session.Status == db.SessionStatusWon {
+		fmt.Fprintf(&text, "Solved with %d %s", session.Mistakes, plural(int(session.Mistakes), "mistake", "mistakes"))
+		if session.HintsUsed > 0 {
+			fmt.Fprintf(&text, " and %d %s", session.HintsUsed, plural(int(session.HintsUsed), "hint", "hints"))
+		}
+		text.WriteString("\n")
+	} else {
+		text.WriteString("Not solved\n")
+	}
+	for _, row := range grid {
+		text.WriteString(row + "\n")
+	}
+
+	url := s.publicURL + "/share/" + session.ShareCode.String
+	text.WriteString(url)
+
+	return Share{
+		Code: session.ShareCode.String,
+		Text: text.String(),
+		URL:  url,
+	}, nil
+}
+
+// shareGrid renders one row per guess, colouring each selected tile by the
+// group it actually belongs to, with a hintMark row wherever a hint was
+// taken.
+func (s *SessionService) shareGrid(ctx context.Context, session db.Session) ([]string, error) {
+	groups, err := s.store.ListGroupsForGame(ctx, session.GameID)
+	if err != nil {
+		return nil, err
+	}
+	colours := make(map[int64]string, len(groups))
+	for _, group := range groups {
+		colours[group.ID] = groupColours[int(group.Tier-1)%len(groupColours)]
+	}
+
+	tiles, err := s.store.ListTilesForGame(ctx, session.GameID)
+	if err != nil {
+		return nil, err
+	}
+	tileColours := make(map[int64]string, len(tiles))
+	for _, tile := range tiles {
+		tileColours[tile.ID] = colours[tile.GroupID]
+	}
+
+	guesses, err := s.store.ListGuessesForSession(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	hints, err := s.store.ListHintsForSession(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]string, 0, len(guesses)+len(hints))
+	for _, guess := range guesses {
+		for len(hints) > 0 && !hints[0].CreatedAt.After(guess.CreatedAt) {
+			rows = append(rows, hintMark)
+			hints = hints[1:]
+		}
+
+		var row strings.Builder
+		for _, tileID := range guess.TileIds {
+			colour, ok := tileColours[tileID]
+			if !ok {
+				colour = "⬛"
+			}
+			row.WriteString(colour)
+		}
+		rows = append(rows, row.String())
+	}
+	for range hints {
+		rows = append(rows, hintMark)
+	}
+	return rows, nil
+}
+
+func newShareCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return shareEncoding.EncodeToString(b), nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+type StatsService struct {
+	store    GameStore
+	location *time.Location
+}
+
+func NewStatsService(store GameStore, location *time.Location) *StatsService {
+	return &StatsService{
+		store:    store,
+		location: location,
+	}
+}
+
+type PlayerStats struct {
+	Played        int `json:"played"`
+	Won           int `json:"won"`
+	WinPercentage int `json:"win_percentage"`
+	CurrentStreak int `json:"current_streak"`
+	MaxStreak     int `json:"max_streak"`
+	// MistakeDistribution[n] is the number of puzzles solved with n mistakes.
+	MistakeDistribution []int `json:"mistake_distribution"`
+}
+
+func (s *StatsService) PlayerStats(ctx context.Context, playerID string) (PlayerStats, error) {
+	result := PlayerStats{MistakeDistribution: make([]int, MaxMistakes)}
+
+	stats, err := s.store.GetPlayerStats(ctx, playerID)
+	if err == sql.ErrNoRows {
+		return result, nil
+	}
+	if err != nil {
+		log.Printf("unable to fetch stats for player %s: %v", playerID, err)
+		return PlayerStats{}, err
+	}
+
+	result.Played = int(stats.Played)
+	result.Won = int(stats.Won)
+	result.MaxStreak = int(stats.MaxStreak)
+	if stats.Played > 0 {
+		result.WinPercentage = int(math.Round(float64(stats.Won) * 100 / float64(stats.Played)))
+	}
+
+	// A streak survives until a daily puzzle is missed, so it is only current
+	// if the last daily was played today or yesterday.
+	yesterday := dateOf(time.Now().In(s.location)).AddDate(0, 0, -1)
+	if stats.LastDailyDate.Valid && !dateOf(stats.LastDailyDate.Time).Before(yesterday) {
+		result.CurrentStreak = int(stats.CurrentStreak)
+	}
+
+	distribution, err := s.store.ListPlayerMistakes(ctx, playerID)
+	if err != nil {
+		log.Printf("unable to fetch mistakes for player %s: %v", playerID, err)
+		return PlayerStats{}, err
+	}
+	for _, row := range distribution {
+		if row.Mistakes >= 0 && int(row.Mistakes) < len(result.MistakeDistribution) {
+			result.MistakeDistribution[row.Mistakes] = int(row.Solved)
+		}
+	}
+
+	return result, nil
+}
+
+// RecordSession folds a finished session into its player's stats.
+func (s *StatsService) RecordSession(ctx context.Context, session db.Session) error {
+	won := session.Status == db.SessionStatusWon
+	err := s.store.RecordPlayerResult(ctx, db.RecordPlayerResultParams{
+		PlayerID:  session.PlayerID,
+		Won:       won,
+		DailyDate: session.DailyDate,
+	})
+	if err != nil {
+		log.Printf("unable to update stats for player %s: %v", session.PlayerID, err)
+		return err
+	}
+
+	if won {
+		err := s.store.IncrementPlayerMistakes(ctx, db.IncrementPlayerMistakesParams{
+			PlayerID: session.PlayerID,
+			Mistakes: session.Mistakes,
+		})
+		if err != nil {
+			log.Printf("unable to update mistakes for player %s: %v", session.PlayerID, err)
+			return err
+		}
+	}
+
+	return nil
+}
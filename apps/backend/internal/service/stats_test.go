@@ -0,0 +1,77 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+	"github.com/lukeberry99/puzzle/internal/service"
+	"github.com/lukeberry99/puzzle/internal/testutil"
+)
+
+func TestRecordSession(t *testing.T) {
+	testutil.ForEachStore(t, func(t *testing.T, store service.GameStore) {
+		ctx := context.Background()
+		stats := service.NewStatsService(store, time.UTC)
+		now := time.Now().UTC()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		daily := func(daysAgo int, status db.SessionStatus) db.Session {
+			return db.Session{
+				PlayerID:  "alice",
+				Status:    status,
+				DailyDate: sql.NullTime{Time: today.AddDate(0, 0, -daysAgo), Valid: true},
+			}
+		}
+
+		// Three dailies in a row, a loss, then two more; the practice game in
+		// between leaves the streak alone
+		for _, session := range []db.Session{
+			daily(6, db.SessionStatusWon),
+			daily(5, db.SessionStatusWon),
+			{PlayerID: "alice", Status: db.SessionStatusLost},
+			daily(4, db.SessionStatusWon),
+			daily(3, db.SessionStatusLost),
+			daily(1, db.SessionStatusWon),
+			daily(0, db.SessionStatusWon),
+		} {
+			if err := stats.RecordSession(ctx, session); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := stats.PlayerStats(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Played != 7 || got.Won != 5 || got.CurrentStreak != 2 || got.MaxStreak != 3 {
+			t.Errorf("got stats %+v, want 7 played, 5 won and streaks of 2 and 3", got)
+		}
+
+		// Sessions finishing at once all count
+		const finishing = 20
+		var wg sync.WaitGroup
+		errs := make([]error, finishing)
+		for i := range finishing {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = stats.RecordSession(ctx, db.Session{PlayerID: "bob", Status: db.SessionStatusWon, Mistakes: 1})
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err = stats.PlayerStats(ctx, "bob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Played != finishing || got.Won != finishing || got.MistakeDistribution[1] != finishing {
+			t.Errorf("got stats %+v, want all %d wins", got, finishing)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+// GameStore is the storage behind the services. Methods keep the names and
+// types sqlc generates, and report missing rows with sql.ErrNoRows, so
+// PostgresStore is a thin wrapper and MemoryStore can stand in for it in
+// tests.
+type GameStore interface {
+	// InTx runs fn against a store whose writes all land if fn returns nil,
+	// and none of them otherwise.
+	InTx(ctx context.Context, fn func(store GameStore) error) error
+
+	// Games
+	CreateGame(ctx context.Context, arg db.CreateGameParams) (int64, error)
+	GetGame(ctx context.Context, id int64) (db.Game, error)
+	DeleteGame(ctx context.Context, id int64) (int64, error)
+	GetReleasedGames(ctx context.Context, arg db.GetReleasedGamesParams) ([]db.GetReleasedGamesRow, error)
+	IsGameEmbargoed(ctx context.Context, arg db.IsGameEmbargoedParams) (bool, error)
+	SetModerationStatus(ctx context.Context, arg db.SetModerationStatusParams) (db.Game, error)
+	SearchGames(ctx context.Context, arg db.SearchGamesParams) ([]db.SearchGamesRow, error)
+
+	// Moderation
+	ListGamesForModeration(ctx context.Context, moderationStatus db.ModerationStatus) ([]db.ListGamesForModerationRow, error)
+	ReturnGameToReview(ctx context.Context, arg db.ReturnGameToReviewParams) error
+	CreateGameReport(ctx context.Context, arg db.CreateGameReportParams) error
+	ListGameReports(ctx context.Context, gameID int64) ([]db.ListGameReportsRow, error)
+	ClearGameReports(ctx context.Context, gameID int64) error
+
+	// Groups
+	CreateGroup(ctx context.Context, arg db.CreateGroupParams) (int64, error)
+	GetGroup(ctx context.Context, id int64) (db.Group, error)
+	GetGroupsForGame(ctx context.Context, gameID int64) ([]int64, error)
+	ListGroupsForGame(ctx context.Context, gameID int64) ([]db.Group, error)
+
+	// Tiles
+	CreateTilesForGroup(ctx context.Context, arg db.CreateTilesForGroupParams) error
+	GetTilesForGroup(ctx context.Context, groupID int64) ([]db.GetTilesForGroupRow, error)
+	GetGameTilesByIDs(ctx context.Context, arg db.GetGameTilesByIDsParams) ([]db.Tile, error)
+	ListTilesForGame(ctx context.Context, gameID int64) ([]db.ListTilesForGameRow, error)
+	ListDecoysForGame(ctx context.Context, gameID int64) ([]db.ListDecoysForGameRow, error)
+
+	// Daily puzzles
+	GetDailyPuzzle(ctx context.Context, puzzleDate time.Time) (db.DailyPuzzle, error)
+	ListDailyPuzzles(ctx context.Context, arg db.ListDailyPuzzlesParams) ([]db.DailyPuzzle, error)
+	UpsertDailyPuzzle(ctx context.Context, arg db.UpsertDailyPuzzleParams) (db.DailyPuzzle, error)
+	MoveDailyPuzzle(ctx context.Context, arg db.MoveDailyPuzzleParams) (db.DailyPuzzle, error)
+	DeleteDailyPuzzle(ctx context.Context, puzzleDate time.Time) (int64, error)
+
+	// Tags
+	ListTags(ctx context.Context) ([]db.ListTagsRow, error)
+	CreateTag(ctx context.Context, name string) (db.Tag, error)
+	RenameTag(ctx context.Context, arg db.RenameTagParams) (db.Tag, error)
+	DeleteTag(ctx context.Context, id int64) (int64, error)
+	EnsureTags(ctx context.Context, names []string) error
+	ClearGameTags(ctx context.Context, gameID int64) error
+	AddGameTags(ctx context.Context, arg db.AddGameTagsParams) error
+	ListTagsForGame(ctx context.Context, gameID int64) ([]string, error)
+
+	// Sessions, guesses and hints
+	StartSession(ctx context.Context, arg db.StartSessionParams) (db.Session, error)
+	GetSession(ctx context.Context, id int64) (db.Session, error)
+	GetSessionForPlayer(ctx context.Context, arg db.GetSessionForPlayerParams) (db.Session, error)
+	AdvanceSession(ctx context.Context, arg db.AdvanceSessionParams) (db.Session, error)
+	FinishSession(ctx context.Context, arg db.FinishSessionParams) (db.Session, error)
+	UseHint(ctx context.Context, arg db.UseHintParams) (db.Session, error)
+	CreateGuess(ctx context.Context, arg db.CreateGuessParams) (db.Guess, error)
+	DeleteGuess(ctx context.Context, id int64) error
+	ListGuessesForSession(ctx context.Context, sessionID int64) ([]db.Guess, error)
+	CreateHint(ctx context.Context, arg db.CreateHintParams) (db.Hint, error)
+	DeleteHint(ctx context.Context, id int64) error
+	ListHintsForSession(ctx context.Context, sessionID int64) ([]db.Hint, error)
+	SetSessionShareCode(ctx context.Context, arg db.SetSessionShareCodeParams) (sql.NullString, error)
+	GetSessionByShareCode(ctx context.Context, shareCode sql.NullString) (db.Session, error)
+	ListFinishedSessionsForPlayer(ctx context.Context, playerID string) ([]db.Session, error)
+	// MoveSessions gives one player's sessions to another
+	MoveSessions(ctx context.Context, arg db.MoveSessionsParams) (int64, error)
+
+	// Ratings
+	UpsertRating(ctx context.Context, arg db.UpsertRatingParams) (db.Rating, error)
+	GetRating(ctx context.Context, arg db.GetRatingParams) (db.Rating, error)
+
+	// Collections
+	ListCollections(ctx context.Context) ([]db.ListCollectionsRow, error)
+	GetCollection(ctx context.Context, id int64) (db.Collection, error)
+	CreateCollection(ctx context.Context, arg db.CreateCollectionParams) (db.Collection, error)
+	UpdateCollection(ctx context.Context, arg db.UpdateCollectionParams) (db.Collection, error)
+	DeleteCollection(ctx context.Context, id int64) (int64, error)
+	ClearCollectionGames(ctx context.Context, collectionID int64) error
+	AddCollectionGames(ctx context.Context, arg db.AddCollectionGamesParams) error
+	ListCollectionGames(ctx context.Context, arg db.ListCollectionGamesParams) ([]db.ListCollectionGamesRow, error)
+
+	// Leaderboards
+	GetLeaderboard(ctx context.Context, arg db.GetLeaderboardParams) ([]db.LeaderboardEntry, error)
+	CountLeaderboard(ctx context.Context, gameID int64) (int64, error)
+	GetLeaderboardEntryForPlayer(ctx context.Context, arg db.GetLeaderboardEntryForPlayerParams) (db.LeaderboardEntry, error)
+
+	// Analytics. Outcomes and solve positions are materialized views, only
+	// as fresh as their last refresh.
+	GetGameOutcomes(ctx context.Context, gameID int64) (db.GameOutcome, error)
+	ListGroupSolvePositions(ctx context.Context, gameID int64) ([]db.ListGroupSolvePositionsRow, error)
+	ListIncorrectSets(ctx context.Context, arg db.ListIncorrectSetsParams) ([]db.ListIncorrectSetsRow, error)
+	ListWrongPairings(ctx context.Context, arg db.ListWrongPairingsParams) ([]db.ListWrongPairingsRow, error)
+	RefreshGameOutcomes(ctx context.Context) error
+	RefreshGroupSolvePositions(ctx context.Context) error
+
+	// Player stats, updated as sessions finish
+	GetPlayerStats(ctx context.Context, playerID string) (db.PlayerStat, error)
+	RecordPlayerResult(ctx context.Context, arg db.RecordPlayerResultParams) error
+	IncrementPlayerMistakes(ctx context.Context, arg db.IncrementPlayerMistakesParams) error
+	ListPlayerMistakes(ctx context.Context, playerID string) ([]db.ListPlayerMistakesRow, error)
+	DeletePlayerStats(ctx context.Context, playerID string) error
+
+	// Accounts
+	CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error)
+	GetUserByUsername(ctx context.Context, username string) (db.User, error)
+	LockUser(ctx context.Context, id int64) error
+}
+
+// PostgresStore is the GameStore used in production, backed by the sqlc
+// generated queries.
+type PostgresStore struct {
+	*db.Queries
+	// conn is nil for a store already inside a transaction.
+	conn *sql.DB
+}
+
+var _ GameStore = (*PostgresStore)(nil)
+
+func NewPostgresStore(conn *sql.DB) *PostgresStore {
+	return &PostgresStore{Queries: db.New(conn), conn: conn}
+}
+
+func (s *PostgresStore) InTx(ctx context.Context, fn func(store GameStore) error) error {
+	if s.conn == nil {
+		return fn(s)
+	}
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(&PostgresStore{Queries: s.WithTx(tx)}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
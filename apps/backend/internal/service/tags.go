@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/lib/pq"
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("a tag with that name already exists")
+)
+
+type Tag struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Games int64  `json:"games"`
+}
+
+func (s *GameService) ListTags(ctx context.Context) ([]Tag, error) {
+	rows, err := s.store.ListTags(ctx)
+	if err != nil {
+		log.Printf("unable to fetch tags: %v", err)
+		return nil, err
+	}
+
+	tags := make([]Tag, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, Tag{ID: row.ID, Name: row.Name, Games: row.Games})
+	}
+	return tags, nil
+}
+
+func (s *GameService) CreateTag(ctx context.Context, req models.TagRequest) (Tag, error) {
+	if err := req.Validate(); err != nil {
+		return Tag{}, err
+	}
+
+	tag, err := s.store.CreateTag(ctx, models.NormalizeTag(req.Name))
+	if err != nil {
+		return Tag{}, tagError(err, 0)
+	}
+	return Tag{ID: tag.ID, Name: tag.Name}, nil
+}
+
+func (s *GameService) RenameTag(ctx context.Context, tagID int64, req models.TagRequest) (Tag, error) {
+	if err := req.Validate(); err != nil {
+		return Tag{}, err
+	}
+
+	tag, err := s.store.RenameTag(ctx, db.RenameTagParams{
+		ID:   tagID,
+		Name: models.NormalizeTag(req.Name),
+	})
+	if err != nil {
+		return Tag{}, tagError(err, tagID)
+	}
+	return Tag{ID: tag.ID, Name: tag.Name}, nil
+}
+
+// DeleteTag removes a tag; games keep everything but the label.
+func (s *GameService) DeleteTag(ctx context.Context, tagID int64) error {
+	rows, err := s.store.DeleteTag(ctx, tagID)
+	if err != nil {
+		log.Printf("unable to delete tag %d: %v", tagID, err)
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: %d", ErrTagNotFound, tagID)
+	}
+	return nil
+}
+
+// SetGameTags replaces a game's tags, creating any tags that don't exist yet.
+func (s *GameService) SetGameTags(ctx context.Context, gameID int64, req models.GameTagsRequest) ([]string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetGame(ctx, gameID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
+		}
+		return nil, err
+	}
+
+	if err := s.store.ClearGameTags(ctx, gameID); err != nil {
+		log.Printf("unable to clear tags for game %d: %v", gameID, err)
+		return nil, err
+	}
+	if err := s.tagGame(ctx, gameID, req.Tags); err != nil {
+		return nil, err
+	}
+
+	return s.store.ListTagsForGame(ctx, gameID)
+}
+
+func (s *GameService) tagGame(ctx context.Context, gameID int64, tags []string) error {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, models.NormalizeTag(tag))
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	if err := s.store.EnsureTags(ctx, names); err != nil {
+		log.Printf("unable to create tags for game %d: %v", gameID, err)
+		return err
+	}
+	if err := s.store.AddGameTags(ctx, db.AddGameTagsParams{GameID: gameID, Names: names}); err != nil {
+		log.Printf("unable to tag game %d: %v", gameID, err)
+		return err
+	}
+	return nil
+}
+
+func tagError(err error, tagID int64) error {
+	var pqErr *pq.Error
+	switch {
+	case err == sql.ErrNoRows:
+		return fmt.Errorf("%w: %d", ErrTagNotFound, tagID)
+	case errors.As(err, &pqErr) && pqErr.Code == "23505":
+		return ErrTagExists
+	}
+	log.Printf("unable to save tag: %v", err)
+	return err
+}
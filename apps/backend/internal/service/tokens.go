@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"log"
+	"slices"
+	"strings"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+)
+
+// Players never see tile IDs: they are handed out group by group, so runs of
+// consecutive IDs are the answers. Each board has a random key instead, the
+// game's for the public board, the session's during a play and the race's
+// during a race, and a tile is known by a token derived from its ID under
+// that key. Boards are ordered by
+// token, which is as good as shuffled but stays put between requests.
+
+// tileTokenBytes is how much of the HMAC a token keeps: 96 bits, 16
+// characters once encoded.
+const tileTokenBytes = 12
+
+// ForeignTilesError is returned for a selection that includes tokens which
+// are not on the board, whether they came from another game or session or
+// were made up. It is an ErrInvalidSelection.
+type ForeignTilesError struct {
+	GameID int64
+	Tiles  []string
+}
+
+func (e *ForeignTilesError) Error() string {
+	return ErrInvalidSelection.Error() + ": tiles " + strings.Join(e.Tiles, ", ") + " are not on this board"
+}
+
+func (e *ForeignTilesError) Unwrap() error {
+	return ErrInvalidSelection
+}
+
+// tileKey is a new random board key, like the default of the tile_key
+// columns.
+func tileKey() []byte {
+	key := make([]byte, 16)
+	rand.Read(key)
+	return key
+}
+
+func tileToken(key []byte, tileID int64) string {
+	mac := hmac.New(sha256.New, key)
+	binary.Write(mac, binary.BigEndian, tileID)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:tileTokenBytes])
+}
+
+// tileBoard is a game's tiles under one key.
+type tileBoard struct {
+	gameID  int64
+	tiles   []db.ListTilesForGameRow
+	tokens  map[int64]string
+	tileIDs map[string]int64
+}
+
+// board loads the game's tiles and derives their tokens under key.
+func (s *GameService) board(ctx context.Context, gameID int64, key []byte) (*tileBoard, error) {
+	tiles, err := s.store.ListTilesForGame(ctx, gameID)
+	if err != nil {
+		log.Printf("unable to fetch tiles for game %d: %v", gameID, err)
+		return nil, err
+	}
+
+	b := &tileBoard{
+		gameID:  gameID,
+		tiles:   tiles,
+		tokens:  make(map[int64]string, len(tiles)),
+		tileIDs: make(map[string]int64, len(tiles)),
+	}
+	for _, tile := range tiles {
+		token := tileToken(key, tile.ID)
+		b.tokens[tile.ID] = token
+		b.tileIDs[token] = tile.ID
+	}
+	slices.SortFunc(b.tiles, func(x, y db.ListTilesForGameRow) int {
+		return strings.Compare(b.tokens[x.ID], b.tokens[y.ID])
+	})
+	return b, nil
+}
+
+// resolve turns tokens back into tile IDs, in the same order.
+func (b *tileBoard) resolve(tokens []string) ([]int64, error) {
+	ids := make([]int64, 0, len(tokens))
+	var foreign []string
+	for _, token := range tokens {
+		id, ok := b.tileIDs[token]
+		if !ok {
+			foreign = append(foreign, token)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(foreign) > 0 {
+		return nil, &ForeignTilesError{GameID: b.gameID, Tiles: foreign}
+	}
+	return ids, nil
+}
+
+// show returns the tiles as players see them, leaving out any in hidden.
+func (b *tileBoard) show(hidden map[int64]bool) []models.BoardTile {
+	shown := make([]models.BoardTile, 0, len(b.tiles))
+	for _, tile := range b.tiles {
+		if !hidden[tile.ID] {
+			shown = append(shown, models.BoardTile{Token: b.tokens[tile.ID], Title: tile.Title})
+		}
+	}
+	return shown
+}
+
+// showIDs returns the given tiles as players see them, in the given order.
+func (b *tileBoard) showIDs(ids []int64) []models.BoardTile {
+	titles := make(map[int64]string, len(b.tiles))
+	for _, tile := range b.tiles {
+		titles[tile.ID] = tile.Title
+	}
+	shown := make([]models.BoardTile, 0, len(ids))
+	for _, id := range ids {
+		shown = append(shown, models.BoardTile{Token: b.tokens[id], Title: titles[id]})
+	}
+	return shown
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	models "github.com/lukeberry99/puzzle/internal"
+)
+
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	ImportInvalid ImportStatus = "invalid"
+	ImportFailed  ImportStatus = "failed"
+)
+
+// ImportResult reports the outcome for a single puzzle in a bulk import.
+// Index is the zero-based position of the puzzle in the submitted file.
+type ImportResult struct {
+	Index    int          `json:"index"`
+	Status   ImportStatus `json:"status"`
+	GameID   int64        `json:"game_id,omitempty"`
+	Problems []string     `json:"problems,omitempty"`
+}
+
+// ImportGames creates each puzzle independently, so one bad puzzle does not
+// prevent the rest of the file from being imported.
+func (s *GameService) ImportGames(ctx context.Context, reqs []models.CreateGameRequest) []ImportResult {
+	results := make([]ImportResult, 0, len(reqs))
+	for i, req := range reqs {
+		result := ImportResult{Index: i}
+
+		gameID, err := s.CreateGame(ctx, req)
+		var verr *models.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			result.Status = ImportInvalid
+			result.Problems = verr.Problems
+		case err != nil:
+			log.Printf("unable to import puzzle %d: %v", i, err)
+			result.Status = ImportFailed
+			result.Problems = []string{"failed to save puzzle"}
+		default:
+			result.Status = ImportCreated
+			result.GameID = gameID
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// ExportGame loads a game with all of its groups and tiles, in the same shape
+// that CreateGame accepts.
+func (s *GameService) ExportGame(ctx context.Context, gameID int64) (models.CreateGameRequest, error) {
+	game, err := s.store.GetGame(ctx, gameID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.CreateGameRequest{}, fmt.Errorf("%w: %d", ErrGameNotFound, gameID)
+		}
+		log.Printf("error fetching game %d: %v", gameID, err)
+		return models.CreateGameRequest{}, err
+	}
+
+	groups, err := s.store.ListGroupsForGame(ctx, gameID)
+	if err != nil {
+		log.Printf("unable to fetch groups for game: %d, %v", gameID, err)
+		return models.CreateGameRequest{}, err
+	}
+
+	decoys, err := s.decoysByTile(ctx, gameID)
+	if err != nil {
+		return models.CreateGameRequest{}, err
+	}
+
+	req := models.CreateGameRequest{
+		Author:     game.Author,
+		Difficulty: string(game.Difficulty),
+		TimeLimit:  string(game.TimeLimit),
+		GroupCount: int(game.GroupCount),
+		GroupSize:  int(game.GroupSize),
+		Groups:     make([]models.Group, 0, len(groups)),
+		Hints:      hintCosts(game),
+	}
+	for _, group := range groups {
+		tiles, err := s.store.GetTilesForGroup(ctx, group.ID)
+		if err != nil {
+			log.Printf("unable to fetch tiles for group %d: %v", group.ID, err)
+			return models.CreateGameRequest{}, err
+		}
+
+		g := models.Group{
+			Link:      group.Link,
+			LinkTerms: splitLinkTerms(group.LinkTerms),
+			Tier:      int(group.Tier),
+			Tiles:     make([]models.Tile, 0, len(tiles)),
+		}
+		for _, tile := range tiles {
+			g.Tiles = append(g.Tiles, models.Tile{ID: tile.ID, Title: tile.Title, Decoy: decoys[tile.ID]})
+		}
+		req.Groups = append(req.Groups, g)
+	}
+
+	return req, nil
+}
+
+func (s *GameService) decoysByTile(ctx context.Context, gameID int64) (map[int64]*models.Decoy, error) {
+	rows, err := s.store.ListDecoysForGame(ctx, gameID)
+	if err != nil {
+		log.Printf("unable to fetch decoys for game %d: %v", gameID, err)
+		return nil, err
+	}
+
+	decoys := make(map[int64]*models.Decoy, len(rows))
+	for _, row := range rows {
+		decoys[row.ID] = &models.Decoy{Tier: int(row.DecoyTier), Reason: row.DecoyReason}
+	}
+	return decoys, nil
+}
+
+func splitLinkTerms(terms string) []string {
+	var result []string
+	for _, term := range strings.Split(terms, ",") {
+		if term = strings.TrimSpace(term); term != "" {
+			result = append(result, term)
+		}
+	}
+	return result
+}
@@ -0,0 +1,244 @@
+// Package testutil holds what the service and handler tests share: builders
+// for games, groups and tiles, and a throwaway Postgres for integration tests.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	models "github.com/lukeberry99/puzzle/internal"
+	"github.com/lukeberry99/puzzle/internal/db"
+	"github.com/lukeberry99/puzzle/internal/service"
+)
+
+// GameBuilder builds a CreateGameRequest that is valid until a test breaks
+// it. Groups are named "Link 1", "Link 2", ... and tiles "Tile 1.1",
+// "Tile 1.2", ... after their group's tier.
+type GameBuilder struct {
+	req models.CreateGameRequest
+}
+
+// NewGame starts a valid, default sized game.
+func NewGame() *GameBuilder {
+	b := &GameBuilder{req: models.CreateGameRequest{
+		Author:     "Tester",
+		Difficulty: "medium",
+		TimeLimit:  "unlimited",
+	}}
+	return b.Size(models.DefaultGroupCount, models.DefaultGroupSize)
+}
+
+func (b *GameBuilder) Author(author string) *GameBuilder {
+	b.req.Author = author
+	return b
+}
+
+func (b *GameBuilder) Difficulty(difficulty string) *GameBuilder {
+	b.req.Difficulty = difficulty
+	return b
+}
+
+func (b *GameBuilder) TimeLimit(timeLimit string) *GameBuilder {
+	b.req.TimeLimit = timeLimit
+	return b
+}
+
+// Size sets the board shape and replaces every group with a default one.
+func (b *GameBuilder) Size(groupCount, groupSize int) *GameBuilder {
+	b.req.GroupCount, b.req.GroupSize = groupCount, groupSize
+	b.req.Groups = make([]models.Group, groupCount)
+	for i := range b.req.Groups {
+		b.req.Groups[i] = NewGroup(i + 1).DefaultTiles(groupSize).Build()
+	}
+	return b
+}
+
+// Group replaces the group with the same tier, or adds it if there is none.
+func (b *GameBuilder) Group(group *GroupBuilder) *GameBuilder {
+	built := group.Build()
+	for i, existing := range b.req.Groups {
+		if existing.Tier == built.Tier {
+			b.req.Groups[i] = built
+			return b
+		}
+	}
+	b.req.Groups = append(b.req.Groups, built)
+	return b
+}
+
+func (b *GameBuilder) Tags(tags ...string) *GameBuilder {
+	b.req.Tags = tags
+	return b
+}
+
+// Hints sets what each kind of hint costs.
+func (b *GameBuilder) Hints(penalty string, tile, initial, link int) *GameBuilder {
+	b.req.Hints = &models.HintCosts{Penalty: penalty, Tile: tile, Initial: initial, Link: link}
+	return b
+}
+
+// Edit applies an arbitrary change, for cases the builder doesn't cover.
+func (b *GameBuilder) Edit(edit func(*models.CreateGameRequest)) *GameBuilder {
+	edit(&b.req)
+	return b
+}
+
+func (b *GameBuilder) Request() models.CreateGameRequest {
+	req := b.req
+	req.Groups = make([]models.Group, len(b.req.Groups))
+	for i, group := range b.req.Groups {
+		group.LinkTerms = append([]string(nil), group.LinkTerms...)
+		group.Tiles = append([]models.Tile(nil), group.Tiles...)
+		req.Groups[i] = group
+	}
+	return req
+}
+
+type GroupBuilder struct {
+	group models.Group
+}
+
+func NewGroup(tier int) *GroupBuilder {
+	link := fmt.Sprintf("Link %d", tier)
+	return &GroupBuilder{group: models.Group{
+		Link:      link,
+		LinkTerms: []string{strings.ToLower(link)},
+		Tier:      tier,
+	}}
+}
+
+func (g *GroupBuilder) Link(link string, terms ...string) *GroupBuilder {
+	g.group.Link = link
+	g.group.LinkTerms = terms
+	return g
+}
+
+// DefaultTiles replaces the tiles with n named after the group's tier.
+func (g *GroupBuilder) DefaultTiles(n int) *GroupBuilder {
+	g.group.Tiles = nil
+	for i := 1; i <= n; i++ {
+		g.group.Tiles = append(g.group.Tiles, NewTile(fmt.Sprintf("Tile %d.%d", g.group.Tier, i)).Build())
+	}
+	return g
+}
+
+// Tiles replaces the tiles with plain tiles of the given titles.
+func (g *GroupBuilder) Tiles(titles ...string) *GroupBuilder {
+	g.group.Tiles = nil
+	for _, title := range titles {
+		g.group.Tiles = append(g.group.Tiles, NewTile(title).Build())
+	}
+	return g
+}
+
+// Tile replaces the tile at index i.
+func (g *GroupBuilder) Tile(i int, tile *TileBuilder) *GroupBuilder {
+	g.group.Tiles[i] = tile.Build()
+	return g
+}
+
+func (g *GroupBuilder) Build() models.Group {
+	return g.group
+}
+
+type TileBuilder struct {
+	tile models.Tile
+}
+
+func NewTile(title string) *TileBuilder {
+	return &TileBuilder{tile: models.Tile{Title: title}}
+}
+
+// DecoyFor marks the tile as a red herring for the group with the given tier.
+func (t *TileBuilder) DecoyFor(tier int, reason string) *TileBuilder {
+	t.tile.Decoy = &models.Decoy{Tier: tier, Reason: reason}
+	return t
+}
+
+func (t *TileBuilder) Build() models.Tile {
+	return t.tile
+}
+
+// SeededGame is a game saved by Seed. Groups are ordered by tier, so
+// Groups[0] is tier 1.
+type SeededGame struct {
+	ID     int64
+	Groups []SeededGroup
+}
+
+type SeededGroup struct {
+	ID      int64
+	Link    string
+	Tier    int
+	TileIDs []int64
+	Titles  []string
+}
+
+// Seed creates the game through GameService and publishes it, so it can be
+// played straight away.
+func Seed(t testing.TB, games *service.GameService, store service.GameStore, b *GameBuilder) SeededGame {
+	t.Helper()
+	ctx := context.Background()
+
+	gameID, err := games.CreateGame(ctx, b.Request())
+	if err != nil {
+		t.Fatalf("seeding game: %v", err)
+	}
+	if _, err := store.SetModerationStatus(ctx, db.SetModerationStatusParams{
+		ID:               gameID,
+		ModerationStatus: db.ModerationStatusPublished,
+	}); err != nil {
+		t.Fatalf("publishing game %d: %v", gameID, err)
+	}
+
+	groups, err := store.ListGroupsForGame(ctx, gameID)
+	if err != nil {
+		t.Fatalf("listing groups for game %d: %v", gameID, err)
+	}
+	seeded := SeededGame{ID: gameID}
+	for _, group := range groups {
+		tiles, err := store.GetTilesForGroup(ctx, group.ID)
+		if err != nil {
+			t.Fatalf("listing tiles for group %d: %v", group.ID, err)
+		}
+		sg := SeededGroup{ID: group.ID, Link: group.Link, Tier: int(group.Tier)}
+		for _, tile := range tiles {
+			sg.TileIDs = append(sg.TileIDs, tile.ID)
+			sg.Titles = append(sg.Titles, tile.Title)
+		}
+		seeded.Groups = append(seeded.Groups, sg)
+	}
+	return seeded
+}
+
+// Tokens looks up the tokens of the tiles with the given titles on a board,
+// which is how tests find the tiles to guess.
+func Tokens(t testing.TB, board []models.BoardTile, titles ...string) []string {
+	t.Helper()
+	tokens := make([]string, len(titles))
+	for i, title := range titles {
+		for _, tile := range board {
+			if tile.Title == title {
+				tokens[i] = tile.Token
+			}
+		}
+		if tokens[i] == "" {
+			t.Fatalf("no tile %q on the board", title)
+		}
+	}
+	return tokens
+}
+
+// ForEachStore runs test against a MemoryStore, then against Postgres when
+// one is available.
+func ForEachStore(t *testing.T, test func(t *testing.T, store service.GameStore)) {
+	t.Helper()
+	t.Run("memory", func(t *testing.T) {
+		test(t, service.NewMemoryStore())
+	})
+	t.Run("postgres", func(t *testing.T) {
+		test(t, service.NewPostgresStore(Postgres(t)))
+	})
+}
@@ -0,0 +1,235 @@
+package testutil
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// Integration tests run against a Postgres the harness starts itself from
+// local binaries, listening only on a unix socket in a temporary directory.
+// The binaries are looked for in $PG_BIN, then on $PATH, then in the usual
+// install locations. Set PUZZLE_TEST_DATABASE_URL to use a running server
+// instead; if it can't be reached, integration tests fail. Without either,
+// they are skipped.
+const (
+	binDirEnv      = "PG_BIN"
+	databaseURLEnv = "PUZZLE_TEST_DATABASE_URL"
+)
+
+var (
+	server     *postgresServer
+	serverErr  error
+	serverOnce sync.Once
+	databases  atomic.Int64
+)
+
+type postgresServer struct {
+	// dsn connects to the maintenance database, for creating test databases.
+	dsn     string
+	dbDSN   func(name string) string
+	dir     string
+	pgCtl   string
+	dataDir string
+}
+
+// Main runs the tests, then stops the Postgres started for them, if any.
+// Packages with integration tests call it from TestMain.
+func Main(m *testing.M) int {
+	code := m.Run()
+	if server != nil && server.pgCtl != "" {
+		server.stop()
+	}
+	return code
+}
+
+// Postgres returns a connection to a new, empty database with sql/schema.sql
+// applied. The database is dropped when the test finishes.
+func Postgres(t testing.TB) *sql.DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping Postgres integration test in short mode")
+	}
+
+	serverOnce.Do(func() { server, serverErr = startPostgres() })
+	if serverErr != nil && os.Getenv(databaseURLEnv) != "" {
+		t.Fatalf("Postgres: %v", serverErr)
+	}
+	if serverErr != nil {
+		t.Skipf("no local Postgres: %v", serverErr)
+	}
+
+	admin, err := sql.Open("postgres", server.dsn)
+	if err != nil {
+		t.Fatalf("connecting to Postgres: %v", err)
+	}
+	defer admin.Close()
+
+	name := fmt.Sprintf("puzzle_test_%d_%d", os.Getpid(), databases.Add(1))
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("creating database: %v", err)
+	}
+
+	conn, err := sql.Open("postgres", server.dbDSN(name))
+	if err != nil {
+		t.Fatalf("connecting to %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		admin, err := sql.Open("postgres", server.dsn)
+		if err != nil {
+			return
+		}
+		defer admin.Close()
+		admin.Exec("DROP DATABASE IF EXISTS " + name)
+	})
+
+	schema, err := os.ReadFile(SchemaPath())
+	if err != nil {
+		t.Fatalf("reading schema: %v", err)
+	}
+	if _, err := conn.Exec(string(schema)); err != nil {
+		t.Fatalf("applying schema: %v", err)
+	}
+	return conn
+}
+
+// SchemaPath is the absolute path of sql/schema.sql.
+func SchemaPath() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "sql", "schema.sql")
+}
+
+func startPostgres() (*postgresServer, error) {
+	if dsn := os.Getenv(databaseURLEnv); dsn != "" {
+		return externalServer(dsn)
+	}
+
+	initdb, err := findBinary("initdb")
+	if err != nil {
+		return nil, err
+	}
+	pgCtl, err := findBinary("pg_ctl")
+	if err != nil {
+		return nil, err
+	}
+	if os.Geteuid() == 0 {
+		return nil, fmt.Errorf("initdb refuses to run as root; set %s instead", databaseURLEnv)
+	}
+
+	dir, err := os.MkdirTemp("", "puzzle-pg-")
+	if err != nil {
+		return nil, err
+	}
+	s := &postgresServer{
+		dir:     dir,
+		pgCtl:   pgCtl,
+		dataDir: filepath.Join(dir, "data"),
+	}
+	s.dsn = fmt.Sprintf("host=%s user=postgres dbname=postgres sslmode=disable", dir)
+	s.dbDSN = func(name string) string {
+		return fmt.Sprintf("host=%s user=postgres dbname=%s sslmode=disable", dir, name)
+	}
+
+	out, err := exec.Command(initdb, "-D", s.dataDir, "-U", "postgres", "-A", "trust", "--no-sync").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %v: %s", err, out)
+	}
+
+	// No TCP listener at all: the socket in dir is the only way in.
+	options := fmt.Sprintf("-k %s -c listen_addresses='' -c fsync=off", dir)
+	out, err = exec.Command(pgCtl, "start", "-w", "-t", "30", "-D", s.dataDir,
+		"-l", filepath.Join(dir, "postgres.log"), "-o", options).CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("pg_ctl start: %v: %s", err, out)
+	}
+
+	if err := s.ping(); err != nil {
+		s.stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// externalServer uses an already running server. Test databases are created
+// beside the one in the URL, which must allow CREATE DATABASE.
+func externalServer(dsn string) (*postgresServer, error) {
+	s := &postgresServer{dsn: dsn}
+	s.dbDSN = func(name string) string {
+		return withDatabase(dsn, name)
+	}
+	if err := s.ping(); err != nil {
+		return nil, fmt.Errorf("%s: %w", databaseURLEnv, err)
+	}
+	return s, nil
+}
+
+// withDatabase points a connection string, in either URL or key=value form,
+// at another database.
+func withDatabase(dsn, name string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		// lib/pq takes the last value given for a key
+		return dsn + " dbname=" + name
+	}
+	u.Path = "/" + name
+	return u.String()
+}
+
+func (s *postgresServer) ping() error {
+	conn, err := sql.Open("postgres", s.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		err := conn.Ping()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (s *postgresServer) stop() {
+	exec.Command(s.pgCtl, "stop", "-m", "immediate", "-D", s.dataDir).Run()
+	os.RemoveAll(s.dir)
+}
+
+func findBinary(name string) (string, error) {
+	if dir := os.Getenv(binDirEnv); dir != "" {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("%s not found in %s=%s", name, binDirEnv, dir)
+		}
+		return path, nil
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+
+	// Debian and Ubuntu keep the server binaries off $PATH
+	matches, _ := filepath.Glob(filepath.Join("/usr/lib/postgresql", "*", "bin", name))
+	for _, pattern := range []string{"/usr/local/opt/postgresql*/bin/", "/opt/homebrew/opt/postgresql*/bin/", "/usr/pgsql-*/bin/"} {
+		more, _ := filepath.Glob(pattern + name)
+		matches = append(matches, more...)
+	}
+	if len(matches) > 0 {
+		return matches[len(matches)-1], nil
+	}
+	return "", fmt.Errorf("%s not found; install Postgres or set %s", name, binDirEnv)
+}
@@ -3,6 +3,7 @@ SELECT
     id,
     author,
     difficulty,
+    moderation_status,
     created_at
 FROM
     games;
@@ -15,6 +16,16 @@ FROM
 WHERE
     game_id = $1;
 
+-- name: ListGroupsForGame :many
+SELECT
+    *
+FROM
+    groups
+WHERE
+    game_id = $1
+ORDER BY
+    tier;
+
 -- name: GetGroup :one
 SELECT
     *
@@ -30,19 +41,21 @@ SELECT
 FROM 
     tiles 
 WHERE
-    group_id = $1;
+    group_id = $1
+ORDER BY
+    id;
 
 -- name: ValidateTilesInSameGroup :one
 WITH tile_count AS (
     SELECT group_id, COUNT(*) as tile_count
     FROM tiles
-    WHERE id = ANY($1::bigint[])
+    WHERE id = ANY(@tile_ids::bigint[])
     GROUP BY group_id
 )
 SELECT EXISTS (
     SELECT 1 
     FROM tile_count 
-    WHERE tile_count = 4
+    WHERE tile_count = @group_size::int
 ) AS is_valid;
 
 
@@ -50,11 +63,27 @@ SELECT EXISTS (
 INSERT INTO games (
     author,
     difficulty,
-    time_limit
+    time_limit,
+    group_count,
+    group_size,
+    moderation_status,
+    moderation_reason,
+    hint_penalty,
+    hint_tile_cost,
+    hint_initial_cost,
+    hint_link_cost
 ) VALUES (
     $1,
     $2::difficulty_level,
-    $3::time_limit
+    $3::time_limit,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
 )
 RETURNING id;
 
@@ -62,24 +91,915 @@ RETURNING id;
 INSERT INTO groups (
     game_id,
     link,
-    link_terms
+    link_terms,
+    tier
 ) VALUES (
     $1,
     $2,
-    $3
+    $3,
+    $4
 )
 RETURNING id;
 
 -- name: CreateTilesForGroup :exec
 INSERT INTO tiles (
     group_id,
-    title
-) VALUES ($1, $2);
+    title,
+    decoy_group_id,
+    decoy_reason
+) VALUES ($1, $2, $3, $4);
 
--- name: GetTilesByIDs :many
-SELECT * FROM tiles
-WHERE id = ANY($1::bigint[]);
+-- name: GetGameTilesByIDs :many
+-- Tiles from other games are left out, so a selection can't borrow them.
+SELECT tiles.* FROM tiles
+JOIN groups ON groups.id = tiles.group_id
+WHERE groups.game_id = @game_id
+  AND tiles.id = ANY(@ids::bigint[])
+ORDER BY tiles.id;
 
 -- name: GetGame :one
 SELECT * FROM games
 WHERE id = $1;
+
+-- name: DeleteGame :execrows
+DELETE FROM games
+WHERE id = $1;
+
+-- name: CountGamesByDifficulty :many
+SELECT
+    difficulty,
+    COUNT(*) AS games
+FROM
+    games
+GROUP BY
+    difficulty
+ORDER BY
+    difficulty;
+
+-- name: GetContentCounts :one
+SELECT
+    (SELECT COUNT(*) FROM games) AS games,
+    (SELECT COUNT(*) FROM groups) AS groups,
+    (SELECT COUNT(*) FROM tiles) AS tiles;
+
+-- name: GetReleasedGames :many
+-- An empty tag matches every game. Sorting by "rating" puts the best rated
+-- first and unrated games last; otherwise games are listed oldest first.
+SELECT
+    games.id,
+    games.author,
+    games.difficulty,
+    games.created_at,
+    ARRAY(
+        SELECT t.name
+        FROM game_tags gt
+            JOIN tags t ON t.id = gt.tag_id
+        WHERE gt.game_id = games.id
+        ORDER BY t.name
+    )::text[] AS tags,
+    COALESCE(r.ratings, 0)::BIGINT AS ratings,
+    COALESCE(r.average_stars, 0)::DOUBLE PRECISION AS average_stars,
+    COALESCE(r.too_easy, 0)::BIGINT AS too_easy,
+    COALESCE(r.too_hard, 0)::BIGINT AS too_hard,
+    COALESCE(r.unfair, 0)::BIGINT AS unfair
+FROM
+    games
+    LEFT JOIN game_ratings r ON r.game_id = games.id
+WHERE games.moderation_status = 'published'
+AND NOT EXISTS (
+    SELECT 1
+    FROM daily_puzzles
+    WHERE game_id = games.id
+    HAVING MIN(puzzle_date) > @today::date
+)
+AND (
+    @tag::text = ''
+    OR EXISTS (
+        SELECT 1
+        FROM game_tags gt
+            JOIN tags t ON t.id = gt.tag_id
+        WHERE gt.game_id = games.id
+            AND t.name = @tag::text
+    )
+)
+ORDER BY
+    CASE WHEN @sort::text = 'rating' THEN r.average_stars END DESC NULLS LAST,
+    CASE WHEN @sort::text = 'rating' THEN r.ratings END DESC NULLS LAST,
+    games.id;
+
+-- name: IsGameEmbargoed :one
+SELECT EXISTS (
+    SELECT 1
+    FROM daily_puzzles
+    WHERE game_id = @game_id
+    HAVING MIN(puzzle_date) > @today::date
+) AS embargoed;
+
+-- name: GetDailyPuzzle :one
+SELECT
+    *
+FROM
+    daily_puzzles
+WHERE
+    puzzle_date = $1;
+
+-- name: ListDailyPuzzles :many
+SELECT
+    *
+FROM
+    daily_puzzles
+WHERE
+    puzzle_date BETWEEN @from_date::date AND @to_date::date
+ORDER BY
+    puzzle_date;
+
+-- name: UpsertDailyPuzzle :one
+INSERT INTO daily_puzzles (
+    puzzle_date,
+    game_id
+) VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (puzzle_date) DO UPDATE
+SET
+    game_id = EXCLUDED.game_id,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: MoveDailyPuzzle :one
+UPDATE daily_puzzles
+SET
+    puzzle_date = @to_date,
+    updated_at = NOW()
+WHERE
+    puzzle_date = @from_date
+RETURNING *;
+
+-- name: DeleteDailyPuzzle :execrows
+DELETE FROM daily_puzzles
+WHERE puzzle_date = $1;
+
+-- name: StartSession :one
+INSERT INTO sessions (
+    game_id,
+    player_id,
+    daily_date
+) VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (player_id, game_id) DO UPDATE
+SET
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetSession :one
+SELECT
+    *
+FROM
+    sessions
+WHERE
+    id = $1;
+
+-- name: AdvanceSession :one
+-- Counts are added to rather than set, so that concurrent guesses all count
+UPDATE sessions
+SET
+    mistakes = mistakes + @mistakes,
+    groups_solved = groups_solved + @groups_solved,
+    updated_at = NOW()
+WHERE
+    id = @id
+    AND status = 'in_progress'
+RETURNING *;
+
+-- name: FinishSession :one
+-- Only one request gets to finish a session
+UPDATE sessions
+SET
+    status = $2,
+    finished_at = $3,
+    score = $4,
+    score_breakdown = $5,
+    updated_at = NOW()
+WHERE
+    id = $1
+    AND status = 'in_progress'
+RETURNING *;
+
+-- name: UseHint :one
+-- A hint is never paid for with the last mistake, however many guesses land
+-- at the same time
+UPDATE sessions
+SET
+    hints_used = hints_used + 1,
+    mistakes = mistakes + @mistakes,
+    hint_points = hint_points + @points,
+    updated_at = NOW()
+WHERE
+    id = @id
+    AND status = 'in_progress'
+    AND mistakes + @mistakes < @max_mistakes::int
+RETURNING *;
+
+-- name: CreateHint :one
+INSERT INTO hints (
+    session_id,
+    group_id,
+    kind,
+    tile_id
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: DeleteHint :exec
+DELETE FROM hints
+WHERE id = $1;
+
+-- name: ListHintsForSession :many
+SELECT
+    *
+FROM
+    hints
+WHERE
+    session_id = $1
+ORDER BY
+    id;
+
+-- name: CreateGuess :one
+INSERT INTO guesses (
+    session_id,
+    tile_ids,
+    correct,
+    group_id
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: DeleteGuess :exec
+DELETE FROM guesses
+WHERE id = $1;
+
+-- name: ListGuessesForSession :many
+SELECT
+    *
+FROM
+    guesses
+WHERE
+    session_id = $1
+ORDER BY
+    id;
+
+-- name: GetPlayerStats :one
+SELECT
+    *
+FROM
+    player_stats
+WHERE
+    player_id = $1;
+
+-- name: RecordPlayerResult :exec
+-- Totals are added to rather than set, so that sessions finishing at the same
+-- time all count. Only daily puzzles played on their day affect the streak: a
+-- win on the day after the last daily extends it, any other win starts a new
+-- one and a loss resets it.
+INSERT INTO player_stats (
+    player_id,
+    played,
+    won,
+    current_streak,
+    max_streak,
+    last_daily_date
+) VALUES (
+    @player_id,
+    1,
+    CASE WHEN @won::boolean THEN 1 ELSE 0 END,
+    CASE WHEN @won::boolean AND sqlc.narg(daily_date)::date IS NOT NULL THEN 1 ELSE 0 END,
+    CASE WHEN @won::boolean AND sqlc.narg(daily_date)::date IS NOT NULL THEN 1 ELSE 0 END,
+    sqlc.narg(daily_date)::date
+)
+ON CONFLICT (player_id) DO UPDATE
+SET
+    played = player_stats.played + 1,
+    won = player_stats.won + EXCLUDED.won,
+    current_streak = CASE
+        WHEN EXCLUDED.last_daily_date IS NULL THEN player_stats.current_streak
+        WHEN EXCLUDED.won = 0 THEN 0
+        WHEN player_stats.last_daily_date = EXCLUDED.last_daily_date - 1 THEN player_stats.current_streak + 1
+        ELSE 1
+    END,
+    max_streak = GREATEST(player_stats.max_streak, CASE
+        WHEN EXCLUDED.last_daily_date IS NULL THEN player_stats.current_streak
+        WHEN EXCLUDED.won = 0 THEN 0
+        WHEN player_stats.last_daily_date = EXCLUDED.last_daily_date - 1 THEN player_stats.current_streak + 1
+        ELSE 1
+    END),
+    last_daily_date = COALESCE(EXCLUDED.last_daily_date, player_stats.last_daily_date),
+    updated_at = NOW();
+
+-- name: IncrementPlayerMistakes :exec
+INSERT INTO player_mistakes (
+    player_id,
+    mistakes,
+    solved
+) VALUES (
+    $1,
+    $2,
+    1
+)
+ON CONFLICT (player_id, mistakes) DO UPDATE
+SET
+    solved = player_mistakes.solved + 1;
+
+-- name: ListPlayerMistakes :many
+SELECT
+    mistakes,
+    solved
+FROM
+    player_mistakes
+WHERE
+    player_id = $1
+ORDER BY
+    mistakes;
+
+-- name: DeletePlayerStats :exec
+DELETE FROM player_stats
+WHERE player_id = $1;
+
+-- name: ListFinishedSessionsForPlayer :many
+SELECT
+    *
+FROM
+    sessions
+WHERE
+    player_id = $1
+    AND status <> 'in_progress'
+ORDER BY
+    finished_at,
+    id;
+
+-- name: MoveSessions :execrows
+-- Sessions of games the target player has already played stay where they are
+UPDATE sessions
+SET
+    player_id = @to_player,
+    updated_at = NOW()
+WHERE
+    sessions.player_id = @from_player
+    AND sessions.game_id NOT IN (
+        SELECT
+            owned.game_id
+        FROM
+            sessions owned
+        WHERE
+            owned.player_id = @to_player
+    );
+
+-- name: CreateUser :one
+INSERT INTO users (
+    username,
+    password_hash,
+    player_id
+) VALUES (
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: GetUserByUsername :one
+SELECT
+    *
+FROM
+    users
+WHERE
+    username = $1;
+
+-- name: LockUser :exec
+-- Merges into an account wait for each other, so stats are rebuilt once
+SELECT id FROM users WHERE id = $1 FOR UPDATE;
+
+-- name: GetLeaderboard :many
+SELECT
+    *
+FROM
+    leaderboard_entries
+WHERE
+    game_id = @game_id
+ORDER BY
+    rank,
+    session_id
+LIMIT @page_limit
+OFFSET @page_offset;
+
+-- name: CountLeaderboard :one
+SELECT
+    COUNT(*)
+FROM
+    leaderboard_entries
+WHERE
+    game_id = $1;
+
+-- name: GetLeaderboardEntryForPlayer :one
+SELECT
+    *
+FROM
+    leaderboard_entries
+WHERE
+    game_id = $1
+    AND player_id = $2;
+
+-- name: ListTilesForGame :many
+SELECT
+    t.id,
+    t.group_id,
+    t.title
+FROM
+    tiles t
+    JOIN groups g ON g.id = t.group_id
+WHERE
+    g.game_id = $1
+ORDER BY
+    t.id;
+
+-- name: SetSessionShareCode :one
+UPDATE sessions
+SET
+    share_code = COALESCE(share_code, @share_code)
+WHERE
+    id = @id
+RETURNING share_code;
+
+-- name: GetSessionByShareCode :one
+SELECT
+    *
+FROM
+    sessions
+WHERE
+    share_code = $1;
+
+-- name: ListDecoysForGame :many
+SELECT
+    t.id,
+    t.title,
+    g.tier,
+    dg.tier AS decoy_tier,
+    COALESCE(t.decoy_reason, '')::text AS decoy_reason
+FROM
+    tiles t
+    JOIN groups g ON g.id = t.group_id
+    JOIN groups dg ON dg.id = t.decoy_group_id
+WHERE
+    g.game_id = $1
+ORDER BY
+    g.tier,
+    t.id;
+
+-- name: ListWrongPairings :many
+SELECT
+    ta.id AS tile_id,
+    ta.title AS tile_title,
+    tb.id AS paired_tile_id,
+    tb.title AS paired_tile_title,
+    COUNT(*) AS times,
+    COALESCE(
+        CASE
+            WHEN ta.decoy_group_id = tb.group_id THEN ta.decoy_reason
+            WHEN tb.decoy_group_id = ta.group_id THEN tb.decoy_reason
+        END,
+        ''
+    )::text AS decoy_reason
+FROM
+    guesses gu
+    JOIN sessions s ON s.id = gu.session_id
+    JOIN tiles ta ON ta.id = ANY(gu.tile_ids)
+    JOIN tiles tb ON tb.id = ANY(gu.tile_ids) AND tb.id > ta.id
+WHERE
+    s.game_id = @game_id
+    AND NOT gu.correct
+    AND ta.group_id <> tb.group_id
+GROUP BY
+    ta.id,
+    tb.id
+ORDER BY
+    times DESC,
+    ta.id,
+    tb.id
+LIMIT @row_limit;
+
+-- name: GetGameOutcomes :one
+SELECT
+    *
+FROM
+    game_outcomes
+WHERE
+    game_id = $1;
+
+-- name: ListGroupSolvePositions :many
+SELECT
+    p.group_id,
+    gr.link,
+    gr.tier,
+    p.position,
+    p.times
+FROM
+    group_solve_positions p
+    JOIN groups gr ON gr.id = p.group_id
+WHERE
+    p.game_id = $1
+ORDER BY
+    gr.tier,
+    p.position;
+
+-- name: RefreshGameOutcomes :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY game_outcomes;
+
+-- name: RefreshGroupSolvePositions :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY group_solve_positions;
+
+-- name: ListIncorrectSets :many
+SELECT
+    sorted.tile_ids::BIGINT[] AS tile_ids,
+    COUNT(*) AS times
+FROM (
+    SELECT
+        ARRAY(SELECT unnest(gu.tile_ids) ORDER BY 1) AS tile_ids
+    FROM
+        guesses gu
+        JOIN sessions s ON s.id = gu.session_id
+    WHERE
+        s.game_id = @game_id
+        AND NOT gu.correct
+) sorted
+GROUP BY
+    sorted.tile_ids
+ORDER BY
+    times DESC,
+    sorted.tile_ids
+LIMIT @row_limit;
+
+-- name: SearchGames :many
+-- Links only match, rank and are snippeted for games the player has finished,
+-- and tile snippets are in title order rather than group order, so searching
+-- can't be used to read the answers to a puzzle.
+WITH search AS (
+    SELECT websearch_to_tsquery('english', @query::text) AS query
+),
+finished AS (
+    SELECT DISTINCT
+        s.game_id
+    FROM
+        sessions s
+    WHERE
+        s.player_id = @player_id::text
+        AND s.status <> 'in_progress'
+),
+group_hits AS (
+    SELECT
+        g.game_id,
+        MAX(ts_rank(g.search_vector, search.query)) AS rank,
+        string_agg(
+            ts_headline('english', g.link, search.query, 'StartSel=**, StopSel=**, HighlightAll=true'),
+            ' · ' ORDER BY g.tier
+        ) AS snippet
+    FROM
+        groups g,
+        search
+    WHERE
+        g.search_vector @@ search.query
+    GROUP BY
+        g.game_id
+),
+tile_hits AS (
+    SELECT
+        g.game_id,
+        MAX(ts_rank(t.search_vector, search.query)) AS rank,
+        string_agg(
+            ts_headline('english', t.title, search.query, 'StartSel=**, StopSel=**, HighlightAll=true'),
+            ' · ' ORDER BY t.title
+        ) AS snippet
+    FROM
+        tiles t
+        JOIN groups g ON g.id = t.group_id,
+        search
+    WHERE
+        t.search_vector @@ search.query
+    GROUP BY
+        g.game_id
+)
+SELECT
+    ga.id,
+    ga.author,
+    ga.difficulty,
+    ga.created_at,
+    (COALESCE(gh.rank, 0) + COALESCE(th.rank, 0))::REAL AS relevance,
+    COALESCE(th.snippet, '')::text AS tile_snippet,
+    COALESCE(gh.snippet, '')::text AS link_snippet
+FROM
+    games ga
+    LEFT JOIN finished f ON f.game_id = ga.id
+    LEFT JOIN group_hits gh ON gh.game_id = ga.id
+        AND f.game_id IS NOT NULL
+    LEFT JOIN tile_hits th ON th.game_id = ga.id
+WHERE
+    (gh.game_id IS NOT NULL OR th.game_id IS NOT NULL)
+    AND ga.moderation_status = 'published'
+    AND NOT EXISTS (
+        SELECT 1
+        FROM daily_puzzles
+        WHERE game_id = ga.id
+        HAVING MIN(puzzle_date) > @today::date
+    )
+ORDER BY
+    relevance DESC,
+    ga.id DESC
+LIMIT @page_limit
+OFFSET @page_offset;
+
+-- name: ListTags :many
+SELECT
+    t.id,
+    t.name,
+    COUNT(gt.game_id) AS games
+FROM
+    tags t
+    LEFT JOIN game_tags gt ON gt.tag_id = t.id
+GROUP BY
+    t.id
+ORDER BY
+    t.name;
+
+-- name: CreateTag :one
+INSERT INTO tags (
+    name
+) VALUES (
+    $1
+)
+RETURNING *;
+
+-- name: RenameTag :one
+UPDATE tags
+SET
+    name = $2
+WHERE
+    id = $1
+RETURNING *;
+
+-- name: DeleteTag :execrows
+DELETE FROM tags
+WHERE id = $1;
+
+-- name: EnsureTags :exec
+INSERT INTO tags (
+    name
+)
+SELECT
+    unnest(@names::text[])
+ON CONFLICT (name) DO NOTHING;
+
+-- name: ClearGameTags :exec
+DELETE FROM game_tags
+WHERE game_id = $1;
+
+-- name: AddGameTags :exec
+INSERT INTO game_tags (
+    game_id,
+    tag_id
+)
+SELECT
+    @game_id,
+    id
+FROM
+    tags
+WHERE
+    name = ANY(@names::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: ListTagsForGame :many
+SELECT
+    t.name
+FROM
+    game_tags gt
+    JOIN tags t ON t.id = gt.tag_id
+WHERE
+    gt.game_id = $1
+ORDER BY
+    t.name;
+
+-- name: ListCollections :many
+SELECT
+    c.*,
+    COUNT(cg.game_id) AS games
+FROM
+    collections c
+    LEFT JOIN collection_games cg ON cg.collection_id = c.id
+GROUP BY
+    c.id
+ORDER BY
+    c.id;
+
+-- name: GetCollection :one
+SELECT
+    *
+FROM
+    collections
+WHERE
+    id = $1;
+
+-- name: CreateCollection :one
+INSERT INTO collections (
+    title,
+    description
+) VALUES (
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: UpdateCollection :one
+UPDATE collections
+SET
+    title = $2,
+    description = $3,
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING *;
+
+-- name: DeleteCollection :execrows
+DELETE FROM collections
+WHERE id = $1;
+
+-- name: ClearCollectionGames :exec
+DELETE FROM collection_games
+WHERE collection_id = $1;
+
+-- name: AddCollectionGames :exec
+INSERT INTO collection_games (
+    collection_id,
+    game_id,
+    position
+)
+SELECT
+    @collection_id,
+    g.game_id,
+    g.position
+FROM
+    unnest(@game_ids::bigint[]) WITH ORDINALITY AS g(game_id, position);
+
+-- name: ListCollectionGames :many
+-- Progress comes from the player's sessions; an empty player ID has none.
+SELECT
+    g.id,
+    g.author,
+    g.difficulty,
+    cg.position,
+    COALESCE(s.status::text, '')::text AS status
+FROM
+    collection_games cg
+    JOIN games g ON g.id = cg.game_id
+    LEFT JOIN sessions s ON s.game_id = g.id AND s.player_id = @player_id::text
+WHERE
+    cg.collection_id = @collection_id
+    AND g.moderation_status = 'published'
+    AND NOT EXISTS (
+        SELECT 1
+        FROM daily_puzzles
+        WHERE game_id = g.id
+        HAVING MIN(puzzle_date) > @today::date
+    )
+ORDER BY
+    cg.position;
+
+-- name: UpsertRating :one
+INSERT INTO ratings (
+    game_id,
+    player_id,
+    stars,
+    feedback,
+    comment
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (game_id, player_id) DO UPDATE
+SET
+    stars = EXCLUDED.stars,
+    feedback = EXCLUDED.feedback,
+    comment = EXCLUDED.comment,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetRating :one
+SELECT
+    *
+FROM
+    ratings
+WHERE
+    game_id = $1
+    AND player_id = $2;
+
+-- name: GetSessionForPlayer :one
+SELECT
+    *
+FROM
+    sessions
+WHERE
+    game_id = $1
+    AND player_id = $2;
+
+-- name: ListGamesForModeration :many
+SELECT
+    g.id,
+    g.author,
+    g.difficulty,
+    g.moderation_status,
+    g.moderation_reason,
+    g.moderated_at,
+    g.created_at,
+    COUNT(r.id) AS reports
+FROM
+    games g
+    LEFT JOIN game_reports r ON r.game_id = g.id
+WHERE
+    g.moderation_status = $1
+GROUP BY
+    g.id
+ORDER BY
+    g.created_at,
+    g.id;
+
+-- name: SetModerationStatus :one
+UPDATE games
+SET
+    moderation_status = $2,
+    moderation_reason = $3,
+    moderated_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $1
+RETURNING *;
+
+-- name: CreateGameReport :exec
+INSERT INTO game_reports (
+    game_id,
+    player_id,
+    issued,
+    reason
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (game_id, player_id) DO UPDATE
+SET
+    reason = EXCLUDED.reason,
+    created_at = NOW();
+
+-- name: ReturnGameToReview :exec
+-- Only once enough players with issued IDs have reported the game
+UPDATE games
+SET
+    moderation_status = 'pending',
+    updated_at = NOW()
+WHERE
+    games.id = @id
+    AND games.moderation_status = 'published'
+    AND (
+        SELECT
+            COUNT(*)
+        FROM
+            game_reports r
+        WHERE
+            r.game_id = @id
+            AND r.issued
+    ) >= @min_reports::bigint;
+
+-- name: ListGameReports :many
+SELECT
+    reason,
+    created_at
+FROM
+    game_reports
+WHERE
+    game_id = $1
+ORDER BY
+    created_at;
+
+-- name: ClearGameReports :exec
+DELETE FROM game_reports
+WHERE game_id = $1;
@@ -3,31 +3,312 @@ CREATE TYPE difficulty_level AS ENUM ('easy', 'medium', 'hard', 'impossible');
 CREATE TYPE time_limit AS ENUM ('unlimited', '15', '10', '5');
 
 -- Games table
+-- Submitted games wait in 'pending' until a moderator publishes them.
+-- 'flagged' games are held for a closer look, e.g. after tripping the
+-- blocklist. Only 'published' games are playable.
+CREATE TYPE moderation_status AS ENUM ('pending', 'published', 'rejected', 'flagged');
+
+-- What hints cost players: extra mistakes, or points off their score
+CREATE TYPE hint_penalty AS ENUM ('mistake', 'score');
+
 CREATE TABLE games (
     id BIGSERIAL PRIMARY KEY,
     author VARCHAR(255) NOT NULL,
     difficulty difficulty_level NOT NULL,
     time_limit time_limit NOT NULL,
+    -- Board shape: group_count groups of group_size tiles each
+    group_count INT NOT NULL DEFAULT 4 CHECK (group_count BETWEEN 2 AND 8),
+    group_size INT NOT NULL DEFAULT 4 CHECK (group_size BETWEEN 2 AND 8),
+    moderation_status moderation_status NOT NULL DEFAULT 'pending',
+    -- Why the game was rejected or flagged
+    moderation_reason TEXT NOT NULL DEFAULT '',
+    moderated_at TIMESTAMP WITH TIME ZONE,
+    -- The cost of each kind of hint, counted in hint_penalty
+    hint_penalty hint_penalty NOT NULL DEFAULT 'score',
+    hint_tile_cost INT NOT NULL DEFAULT 50 CHECK (hint_tile_cost >= 0),
+    hint_initial_cost INT NOT NULL DEFAULT 100 CHECK (hint_initial_cost >= 0),
+    hint_link_cost INT NOT NULL DEFAULT 200 CHECK (hint_link_cost >= 0),
+    -- Secret the board's tile tokens are derived from, so tile IDs stay private
+    tile_key BYTEA NOT NULL DEFAULT uuid_send(gen_random_uuid()),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
 );
 
--- Groups table (4 groups per game)
+-- Groups table (games.group_count groups per game)
 CREATE TABLE groups (
     id BIGSERIAL PRIMARY KEY,
     game_id BIGINT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
     link TEXT NOT NULL,
     link_terms TEXT NOT NULL,
+    -- Difficulty within the game, from 1 (yellow, easiest) to games.group_count
+    tier INT NOT NULL CHECK (tier > 0),
+    -- Search document; the link is weighted above its terms
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', link), 'A') ||
+        setweight(to_tsvector('english', link_terms), 'B')
+    ) STORED,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (game_id, tier)
 );
 
--- Tiles table (4 tiles per group)
+-- Tiles table (games.group_size tiles per group)
 CREATE TABLE tiles (
     id BIGSERIAL PRIMARY KEY,
     group_id BIGINT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
     title TEXT NOT NULL,
+    -- Red herring: another group this tile seems to fit, and why
+    decoy_group_id BIGINT REFERENCES groups(id) ON DELETE SET NULL,
+    decoy_reason TEXT,
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', title)) STORED,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX groups_search_vector_idx ON groups USING GIN (search_vector);
+CREATE INDEX tiles_search_vector_idx ON tiles USING GIN (search_vector);
+
+-- Daily puzzle schedule (one game per calendar date in the configured timezone)
+CREATE TABLE daily_puzzles (
+    puzzle_date DATE PRIMARY KEY,
+    game_id BIGINT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX daily_puzzles_game_id_idx ON daily_puzzles (game_id);
+
+CREATE TYPE session_status AS ENUM ('in_progress', 'won', 'lost');
+
+-- Sessions table (one play of a game by a player)
+CREATE TABLE sessions (
+    id BIGSERIAL PRIMARY KEY,
+    game_id BIGINT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    player_id TEXT NOT NULL,
+    -- Set when the game was started as that day's daily puzzle; drives streaks
+    daily_date DATE,
+    status session_status NOT NULL DEFAULT 'in_progress',
+    mistakes INT NOT NULL DEFAULT 0,
+    groups_solved INT NOT NULL DEFAULT 0,
+    hints_used INT NOT NULL DEFAULT 0,
+    -- Score points lost to hints, for games whose hints cost score
+    hint_points INT NOT NULL DEFAULT 0,
+    -- Set when the session finishes, with the points behind it by name
+    score INT,
+    score_breakdown JSONB NOT NULL DEFAULT '{}',
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    -- Public code for the shareable result, created on first share
+    share_code TEXT UNIQUE,
+    -- Secret this session's tile tokens are derived from
+    tile_key BYTEA NOT NULL DEFAULT uuid_send(gen_random_uuid()),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (player_id, game_id)
+);
+
+-- Guesses table (every submitted selection, in order)
+CREATE TABLE guesses (
+    id BIGSERIAL PRIMARY KEY,
+    session_id BIGINT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    tile_ids BIGINT[] NOT NULL,
+    correct BOOLEAN NOT NULL,
+    -- The group solved by a correct guess
+    group_id BIGINT REFERENCES groups(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX guesses_session_id_idx ON guesses (session_id);
+-- Each group is solved at most once per session, however many requests race
+-- to solve it. Wrong guesses have no group, so any number of them can be made
+CREATE UNIQUE INDEX guesses_session_id_group_id_idx ON guesses (session_id, group_id);
+
+-- Hints escalate through these kinds for each group
+CREATE TYPE hint_kind AS ENUM ('tile', 'initial', 'link');
+
+-- Hints table (every hint taken, in order)
+CREATE TABLE hints (
+    id BIGSERIAL PRIMARY KEY,
+    session_id BIGINT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    group_id BIGINT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    kind hint_kind NOT NULL,
+    -- The tile revealed by a 'tile' hint
+    tile_id BIGINT REFERENCES tiles(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (session_id, group_id, kind)
+);
+
+-- Player statistics, updated as each session finishes
+CREATE TABLE player_stats (
+    player_id TEXT PRIMARY KEY,
+    played INT NOT NULL DEFAULT 0,
+    won INT NOT NULL DEFAULT 0,
+    current_streak INT NOT NULL DEFAULT 0,
+    max_streak INT NOT NULL DEFAULT 0,
+    last_daily_date DATE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Registered players. Each account plays under a player ID of its own, which
+-- the history of anonymous players is merged into when they sign up or log in
+CREATE TABLE users (
+    id BIGSERIAL PRIMARY KEY,
+    -- Stored lowercased
+    username TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    player_id TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Number of solved puzzles per mistake count, per player
+CREATE TABLE player_mistakes (
+    player_id TEXT NOT NULL REFERENCES player_stats(player_id) ON DELETE CASCADE,
+    mistakes INT NOT NULL,
+    solved INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (player_id, mistakes)
+);
+
+CREATE INDEX sessions_game_id_status_idx ON sessions (game_id, status);
+
+-- Leaderboard for timed games: won sessions finished within the time limit
+-- without hints, ranked by fewest mistakes, then fastest solve, then earliest
+-- finish
+CREATE VIEW leaderboard_entries AS
+SELECT
+    s.id AS session_id,
+    s.game_id,
+    s.player_id,
+    s.mistakes,
+    EXTRACT(EPOCH FROM s.finished_at - s.started_at)::DOUBLE PRECISION AS solve_seconds,
+    s.finished_at,
+    RANK() OVER (
+        PARTITION BY s.game_id
+        ORDER BY s.mistakes, s.finished_at - s.started_at, s.finished_at
+    ) AS rank
+FROM
+    sessions s
+    JOIN games g ON g.id = s.game_id
+WHERE
+    s.status = 'won'
+    AND s.hints_used = 0
+    AND g.time_limit <> 'unlimited'
+    AND s.finished_at - s.started_at <= make_interval(mins => g.time_limit::TEXT::INT);
+
+-- Tags label games by theme, e.g. "music" or "geography"
+CREATE TABLE tags (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE game_tags (
+    game_id BIGINT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (game_id, tag_id)
+);
+
+CREATE INDEX game_tags_tag_id_idx ON game_tags (tag_id);
+
+-- Collections are curated, ordered packs of games
+CREATE TABLE collections (
+    id BIGSERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
 );
 
+CREATE TABLE collection_games (
+    collection_id BIGINT NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    game_id BIGINT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    PRIMARY KEY (collection_id, game_id),
+    UNIQUE (collection_id, position)
+);
+
+-- How a player felt a puzzle's difficulty was pitched, alongside their stars
+CREATE TYPE rating_feedback AS ENUM ('too_easy', 'too_hard', 'unfair');
+
+-- Ratings, one per player per game
+CREATE TABLE ratings (
+    id BIGSERIAL PRIMARY KEY,
+    game_id BIGINT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    player_id TEXT NOT NULL,
+    stars INT NOT NULL CHECK (stars BETWEEN 1 AND 5),
+    feedback rating_feedback,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (game_id, player_id)
+);
+
+CREATE VIEW game_ratings AS
+SELECT
+    game_id,
+    COUNT(*) AS ratings,
+    AVG(stars)::DOUBLE PRECISION AS average_stars,
+    COUNT(*) FILTER (WHERE feedback = 'too_easy') AS too_easy,
+    COUNT(*) FILTER (WHERE feedback = 'too_hard') AS too_hard,
+    COUNT(*) FILTER (WHERE feedback = 'unfair') AS unfair
+FROM
+    ratings
+GROUP BY
+    game_id;
+
+-- Abuse reports from players; enough of them send a published game back to
+-- review
+CREATE TABLE game_reports (
+    id BIGSERIAL PRIMARY KEY,
+    game_id BIGINT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    player_id TEXT NOT NULL,
+    -- Whether the server issued player_id, rather than the client choosing it.
+    -- Only these reports count towards taking a game down.
+    issued BOOLEAN NOT NULL DEFAULT FALSE,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (game_id, player_id)
+);
+
+CREATE INDEX games_moderation_status_idx ON games (moderation_status);
+
+-- Per-game outcome totals for authors. Refreshed periodically by the
+-- analytics service rather than on every guess.
+CREATE MATERIALIZED VIEW game_outcomes AS
+SELECT
+    g.id AS game_id,
+    COUNT(s.id) FILTER (WHERE s.status <> 'in_progress') AS finished,
+    COUNT(s.id) FILTER (WHERE s.status = 'won') AS won,
+    COALESCE(AVG(s.mistakes) FILTER (WHERE s.status <> 'in_progress'), 0)::DOUBLE PRECISION AS average_mistakes
+FROM
+    games g
+    LEFT JOIN sessions s ON s.game_id = g.id
+GROUP BY
+    g.id;
+
+CREATE UNIQUE INDEX game_outcomes_game_id_idx ON game_outcomes (game_id);
+
+-- How often each group was the first, second, ... group a player solved
+CREATE MATERIALIZED VIEW group_solve_positions AS
+SELECT
+    gr.game_id,
+    gr.id AS group_id,
+    solved.position,
+    COUNT(*) AS times
+FROM (
+    SELECT
+        gu.group_id,
+        ROW_NUMBER() OVER (PARTITION BY gu.session_id ORDER BY gu.created_at, gu.id) AS position
+    FROM
+        guesses gu
+    WHERE
+        gu.correct
+        AND gu.group_id IS NOT NULL
+) solved
+    JOIN groups gr ON gr.id = solved.group_id
+GROUP BY
+    gr.game_id,
+    gr.id,
+    solved.position;
+
+CREATE UNIQUE INDEX group_solve_positions_group_id_position_idx ON group_solve_positions (group_id, position);
+CREATE INDEX group_solve_positions_game_id_idx ON group_solve_positions (game_id);
@@ -112,10 +112,14 @@ export default function Create() {
       author: values.authorName,
       difficulty: values.difficulty,
       time_limit: values.timeLimit,
-      groups: values.groups.map((g) => ({
+      groups: values.groups.map((g, index) => ({
         tiles: g.tiles.map((title) => ({ title })),
         link: g.link,
-        linking_terms: "",
+        link_terms: g.linkingTerms
+          ? g.linkingTerms.split(",").map((term) => term.trim())
+          : [g.link],
+        // Groups are entered from easiest (A) to hardest (D)
+        tier: index + 1,
       })),
     };
 